@@ -10,6 +10,37 @@ const (
 	defaultSecret = "secret"
 	defaultDbUrl  = "mongodb://localhost:27017"
 	defaultDbName = "tracker"
+	defaultDriver = DriverMongo
+	defaultDir    = "data"
+	// exports stream every session of a user, so they get longer than the other operations
+	defaultDbOperationTimeout = "StreamSessions=5m"
+	defaultDbTimeout          = "10s"
+	defaultAuthCacheTTL       = "30s"
+	defaultMailer             = MailerLog
+	defaultSMTPPort           = "587"
+	defaultMailFrom           = "no-reply@localhost"
+	defaultAppURL             = "http://localhost:8080"
+	defaultUnverifiedAccess   = AccessFull
+)
+
+// supported values of MAILER
+const (
+	MailerSMTP = "smtp"
+	MailerLog  = "log"
+)
+
+// supported values of UNVERIFIED_ACCESS
+const (
+	AccessFull     = "full"
+	AccessReadOnly = "read-only"
+)
+
+// supported values of DB_DRIVER
+const (
+	DriverMongo    = "mongo"
+	DriverPostgres = "postgres"
+	DriverBolt     = "bolt"
+	DriverMemory   = "memory"
 )
 
 // Secrets contain all the config that this application needs
@@ -17,7 +48,36 @@ type Secrets struct {
 	Port      string `json:"port"`
 	JWTSecret string `json:"jwt_secret"`
 	DBName    string `json:"db_name"`
-	DBURL     string `json:"dburl"`
+	// DBURL is the connection string of the mongo or postgres database selected by DBDriver
+	DBURL string `json:"dburl"`
+	// DBDriver selects the datastore, the memory driver needs no database and loses its data on exit
+	DBDriver string `json:"db_driver"`
+	// DataDir holds the database file of the bolt driver
+	DataDir string `json:"data_dir"`
+	// BackupInterval is how often the bolt driver snapshots its file, e.g 24h, empty disables snapshots
+	BackupInterval string `json:"backup_interval"`
+	// DBTimeout is the deadline of a datastore operation, DBOperationTimeouts overrides it
+	// for single operations, e.g GetReport=30s,StreamSessions=5m
+	DBTimeout           string `json:"db_timeout"`
+	DBOperationTimeouts string `json:"db_operation_timeouts"`
+	// AuthCacheTTL is how long token generations and login sessions are cached, a logout on another
+	// instance takes up to this long to reject the access tokens it revoked
+	AuthCacheTTL string `json:"auth_cache_ttl"`
+	// TrustProxy takes the ip address of devices from the X-Forwarded-For and X-Real-IP headers,
+	// only enable it behind a proxy that sets them
+	TrustProxy bool `json:"trust_proxy"`
+	// Mailer selects how mails are delivered, the log mailer writes them to the log for local development
+	Mailer       string `json:"mailer"`
+	SMTPHost     string `json:"smtp_host"`
+	SMTPPort     string `json:"smtp_port"`
+	SMTPUsername string `json:"smtp_username"`
+	SMTPPassword string `json:"smtp_password"`
+	MailFrom     string `json:"mail_from"`
+	// AppURL is the address of the app that links in mails point to
+	AppURL string `json:"app_url"`
+	// UnverifiedAccess restricts users that did not verify their email, read-only refuses their
+	// mutations except the ones managing their account
+	UnverifiedAccess string `json:"unverified_access"`
 }
 
 // LoadSecrets loads secrets from the environment and returns it
@@ -54,5 +114,74 @@ func LoadSecrets(filename ...string) *Secrets {
 	}
 	secrets.DBName = dbName
 
+	dbDriver, ok := os.LookupEnv("DB_DRIVER")
+	if !ok {
+		dbDriver = defaultDriver
+	}
+	secrets.DBDriver = dbDriver
+
+	dataDir, ok := os.LookupEnv("DATA_DIR")
+	if !ok {
+		dataDir = defaultDir
+	}
+	secrets.DataDir = dataDir
+
+	secrets.BackupInterval = os.Getenv("BACKUP_INTERVAL")
+
+	dbTimeout, ok := os.LookupEnv("DB_TIMEOUT")
+	if !ok {
+		dbTimeout = defaultDbTimeout
+	}
+	secrets.DBTimeout = dbTimeout
+
+	dbOperationTimeouts, ok := os.LookupEnv("DB_OPERATION_TIMEOUTS")
+	if !ok {
+		dbOperationTimeouts = defaultDbOperationTimeout
+	}
+	secrets.DBOperationTimeouts = dbOperationTimeouts
+
+	authCacheTTL, ok := os.LookupEnv("AUTH_CACHE_TTL")
+	if !ok {
+		authCacheTTL = defaultAuthCacheTTL
+	}
+	secrets.AuthCacheTTL = authCacheTTL
+
+	secrets.TrustProxy = os.Getenv("TRUST_PROXY") == "true"
+
+	mailer, ok := os.LookupEnv("MAILER")
+	if !ok {
+		mailer = defaultMailer
+	}
+	secrets.Mailer = mailer
+
+	secrets.SMTPHost = os.Getenv("SMTP_HOST")
+
+	smtpPort, ok := os.LookupEnv("SMTP_PORT")
+	if !ok {
+		smtpPort = defaultSMTPPort
+	}
+	secrets.SMTPPort = smtpPort
+
+	secrets.SMTPUsername = os.Getenv("SMTP_USERNAME")
+	secrets.SMTPPassword = os.Getenv("SMTP_PASSWORD")
+
+	mailFrom, ok := os.LookupEnv("MAIL_FROM")
+	if !ok {
+		mailFrom = defaultMailFrom
+	}
+	secrets.MailFrom = mailFrom
+
+	appURL, ok := os.LookupEnv("APP_URL")
+	if !ok {
+		appURL = defaultAppURL
+	}
+	secrets.AppURL = appURL
+
+	unverifiedAccess, ok := os.LookupEnv("UNVERIFIED_ACCESS")
+	if !ok {
+		unverifiedAccess = defaultUnverifiedAccess
+	}
+	secrets.UnverifiedAccess = unverifiedAccess
+
 	return secrets
 }
@@ -24,20 +24,45 @@ func TestConfig_LoadSecrets(t *testing.T) {
 			name:     "Test default variables",
 			scenario: defaultEnv,
 			expected: Secrets{
-				Port:      defaultPort,
-				JWTSecret: defaultSecret,
-				DBName:    defaultDbName,
-				DBURL:     defaultDbUrl,
+				Port:                defaultPort,
+				JWTSecret:           defaultSecret,
+				DBName:              defaultDbName,
+				DBURL:               defaultDbUrl,
+				DBDriver:            defaultDriver,
+				DataDir:             defaultDir,
+				DBTimeout:           defaultDbTimeout,
+				DBOperationTimeouts: defaultDbOperationTimeout,
+				AuthCacheTTL:        defaultAuthCacheTTL,
+				Mailer:              defaultMailer,
+				SMTPPort:            defaultSMTPPort,
+				MailFrom:            defaultMailFrom,
+				AppURL:              defaultAppURL,
+				UnverifiedAccess:    defaultUnverifiedAccess,
 			},
 		},
 		{
 			name:     "Test .env file",
 			scenario: envFile,
 			expected: Secrets{
-				Port:      "1234",
-				JWTSecret: "secret",
-				DBName:    "track",
-				DBURL:     "someUrl",
+				Port:                "1234",
+				JWTSecret:           "secret",
+				DBName:              "track",
+				DBURL:               "someUrl",
+				DBDriver:            DriverBolt,
+				DataDir:             "/var/lib/tracker",
+				BackupInterval:      "24h",
+				DBTimeout:           "3s",
+				DBOperationTimeouts: "GetReport=30s",
+				AuthCacheTTL:        "5s",
+				TrustProxy:          true,
+				Mailer:              MailerSMTP,
+				SMTPHost:            "smtp.email.com",
+				SMTPPort:            "465",
+				SMTPUsername:        "tracker",
+				SMTPPassword:        "password",
+				MailFrom:            "tracker@email.com",
+				AppURL:              "https://tracker.email.com",
+				UnverifiedAccess:    AccessReadOnly,
 			},
 		},
 	}
@@ -58,11 +83,27 @@ func TestConfig_LoadSecrets(t *testing.T) {
 
 				// add sample env data to temp file
 				_, err = file.Write([]byte(fmt.Sprintf(
-					"PORT=%v\nDATABASE_URL=%v\nDATABASE_NAME=%v\nJWT_SECRET=%v",
+					"PORT=%v\nDATABASE_URL=%v\nDATABASE_NAME=%v\nJWT_SECRET=%v\nDB_DRIVER=%v\nDATA_DIR=%v\nBACKUP_INTERVAL=%v\nDB_TIMEOUT=%v\nDB_OPERATION_TIMEOUTS=%v\nAUTH_CACHE_TTL=%v\nTRUST_PROXY=%v"+
+						"\nMAILER=%v\nSMTP_HOST=%v\nSMTP_PORT=%v\nSMTP_USERNAME=%v\nSMTP_PASSWORD=%v\nMAIL_FROM=%v\nAPP_URL=%v\nUNVERIFIED_ACCESS=%v",
 					testCase.expected.Port,
 					testCase.expected.DBURL,
 					testCase.expected.DBName,
 					testCase.expected.JWTSecret,
+					testCase.expected.DBDriver,
+					testCase.expected.DataDir,
+					testCase.expected.BackupInterval,
+					testCase.expected.DBTimeout,
+					testCase.expected.DBOperationTimeouts,
+					testCase.expected.AuthCacheTTL,
+					testCase.expected.TrustProxy,
+					testCase.expected.Mailer,
+					testCase.expected.SMTPHost,
+					testCase.expected.SMTPPort,
+					testCase.expected.SMTPUsername,
+					testCase.expected.SMTPPassword,
+					testCase.expected.MailFrom,
+					testCase.expected.AppURL,
+					testCase.expected.UnverifiedAccess,
 				)))
 				assert.NoError(t, err)
 
@@ -0,0 +1,165 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/victor-nach/time-tracker/lib/clock"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+// pruneAuthCache is the number of cached entries above which expired ones are dropped
+const pruneAuthCache = 10000
+
+// authCacheStore caches the token generation of users and their login sessions, which are read on
+// every authenticated request. Changes through this store update the cache at once, other instances
+// sharing the database see them once their entry is older than ttl
+type authCacheStore struct {
+	Datastore
+	ttl   time.Duration
+	clock clock.Clock
+
+	mu          sync.Mutex
+	generations map[string]cachedGeneration
+	logins      map[string]cachedLogin
+	// forgets counts the removals of cached sessions, a read that overlapped one is not cached
+	forgets int
+}
+
+type cachedGeneration struct {
+	generation int64
+	expires    time.Time
+}
+
+type cachedLogin struct {
+	session models.LoginSession
+	expires time.Time
+}
+
+// WithAuthCache returns a store that caches token generations and login sessions for ttl, a zero ttl disables the cache
+func WithAuthCache(store Datastore, ttl time.Duration) Datastore {
+	if ttl <= 0 {
+		return store
+	}
+	return &authCacheStore{
+		Datastore:   store,
+		ttl:         ttl,
+		clock:       clock.New(),
+		generations: map[string]cachedGeneration{},
+		logins:      map[string]cachedLogin{},
+	}
+}
+
+func (a *authCacheStore) GetTokenGeneration(ctx context.Context, id string) (int64, error) {
+	now := a.clock.Now()
+	a.mu.Lock()
+	cached, ok := a.generations[id]
+	a.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.generation, nil
+	}
+
+	generation, err := a.Datastore.GetTokenGeneration(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	a.setGeneration(id, generation, now)
+	return generation, nil
+}
+
+func (a *authCacheStore) IncrementTokenGeneration(ctx context.Context, id string) (int64, error) {
+	generation, err := a.Datastore.IncrementTokenGeneration(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	a.setGeneration(id, generation, a.clock.Now())
+	return generation, nil
+}
+
+func (a *authCacheStore) setGeneration(id string, generation int64, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// a read that started before an increment must not replace the newer generation
+	if cached, ok := a.generations[id]; ok && cached.generation > generation && now.Before(cached.expires) {
+		return
+	}
+	if len(a.generations) >= pruneAuthCache {
+		for key, cached := range a.generations {
+			if !now.Before(cached.expires) {
+				delete(a.generations, key)
+			}
+		}
+	}
+	a.generations[id] = cachedGeneration{generation: generation, expires: now.Add(a.ttl)}
+}
+
+// GetLoginSession caches the sessions that exist, a signed out device is looked up on every request
+// but its tokens are refused anyway. The last use of a cached session can be up to ttl old
+func (a *authCacheStore) GetLoginSession(ctx context.Context, id, owner string) (*models.LoginSession, error) {
+	now := a.clock.Now()
+	a.mu.Lock()
+	cached, ok := a.logins[id]
+	forgets := a.forgets
+	a.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		if cached.session.Owner != owner {
+			return nil, ErrNotFound
+		}
+		session := cached.session
+		return &session, nil
+	}
+
+	session, err := a.Datastore.GetLoginSession(ctx, id, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// the session may have been deleted while it was read
+	if forgets != a.forgets {
+		return session, nil
+	}
+	if len(a.logins) >= pruneAuthCache {
+		for key, cached := range a.logins {
+			if !now.Before(cached.expires) {
+				delete(a.logins, key)
+			}
+		}
+	}
+	a.logins[id] = cachedLogin{session: *session, expires: now.Add(a.ttl)}
+	return session, nil
+}
+
+func (a *authCacheStore) UpdateLoginSession(ctx context.Context, id string, info models.LoginSessionInfo) error {
+	a.forgetLogins(func(s *models.LoginSession) bool { return s.ID == id })
+	return a.Datastore.UpdateLoginSession(ctx, id, info)
+}
+
+func (a *authCacheStore) DeleteLoginSession(ctx context.Context, id string) error {
+	if err := a.Datastore.DeleteLoginSession(ctx, id); err != nil {
+		return err
+	}
+	a.forgetLogins(func(s *models.LoginSession) bool { return s.ID == id })
+	return nil
+}
+
+func (a *authCacheStore) DeleteLoginSessions(ctx context.Context, owner string) error {
+	if err := a.Datastore.DeleteLoginSessions(ctx, owner); err != nil {
+		return err
+	}
+	a.forgetLogins(func(s *models.LoginSession) bool { return s.Owner == owner })
+	return nil
+}
+
+func (a *authCacheStore) forgetLogins(match func(*models.LoginSession) bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.forgets++
+	for key, cached := range a.logins {
+		if match(&cached.session) {
+			delete(a.logins, key)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+// generationStore counts the reads of the token generation
+type generationStore struct {
+	Datastore
+	generation int64
+	reads      int
+}
+
+func (g *generationStore) GetTokenGeneration(ctx context.Context, id string) (int64, error) {
+	g.reads++
+	return g.generation, nil
+}
+
+func (g *generationStore) IncrementTokenGeneration(ctx context.Context, id string) (int64, error) {
+	g.generation++
+	return g.generation, nil
+}
+
+// loginStore counts the reads of login sessions
+type loginStore struct {
+	Datastore
+	sessions map[string]models.LoginSession
+	reads    int
+}
+
+func (l *loginStore) GetLoginSession(ctx context.Context, id, owner string) (*models.LoginSession, error) {
+	l.reads++
+	session, ok := l.sessions[id]
+	if !ok || session.Owner != owner {
+		return nil, ErrNotFound
+	}
+	return &session, nil
+}
+
+func (l *loginStore) DeleteLoginSession(ctx context.Context, id string) error {
+	delete(l.sessions, id)
+	return nil
+}
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (f *fixedClock) Now() time.Time {
+	return f.now
+}
+
+func TestWithAuthCache(t *testing.T) {
+	ctx := context.Background()
+	inner := &generationStore{}
+	clock := &fixedClock{now: time.Unix(1000, 0)}
+	store := WithAuthCache(inner, time.Minute).(*authCacheStore)
+	store.clock = clock
+
+	for i := 0; i < 3; i++ {
+		generation, err := store.GetTokenGeneration(ctx, "userId")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), generation)
+	}
+	assert.Equal(t, 1, inner.reads)
+
+	// an increment through the cache is seen at once
+	_, err := store.IncrementTokenGeneration(ctx, "userId")
+	assert.NoError(t, err)
+	generation, err := store.GetTokenGeneration(ctx, "userId")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), generation)
+	assert.Equal(t, 1, inner.reads)
+
+	// an increment made by another instance is seen once the entry expires
+	inner.generation = 2
+	clock.now = clock.now.Add(time.Minute)
+	generation, err = store.GetTokenGeneration(ctx, "userId")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), generation)
+	assert.Equal(t, 2, inner.reads)
+
+	assert.Equal(t, Datastore(inner), WithAuthCache(inner, 0))
+}
+
+func TestWithAuthCache_LoginSessions(t *testing.T) {
+	ctx := context.Background()
+	inner := &loginStore{sessions: map[string]models.LoginSession{
+		"phone":  {ID: "phone", Owner: "userId"},
+		"laptop": {ID: "laptop", Owner: "userId"},
+	}}
+	clock := &fixedClock{now: time.Unix(1000, 0)}
+	store := WithAuthCache(inner, time.Minute).(*authCacheStore)
+	store.clock = clock
+
+	for i := 0; i < 3; i++ {
+		session, err := store.GetLoginSession(ctx, "phone", "userId")
+		assert.NoError(t, err)
+		assert.Equal(t, "phone", session.ID)
+	}
+	assert.Equal(t, 1, inner.reads)
+	_, err := store.GetLoginSession(ctx, "phone", "otherId")
+	assert.Equal(t, ErrNotFound, err)
+
+	// a device signed out through the cache is refused at once
+	assert.NoError(t, store.DeleteLoginSession(ctx, "phone"))
+	_, err = store.GetLoginSession(ctx, "phone", "userId")
+	assert.Equal(t, ErrNotFound, err)
+
+	// a device signed out by another instance is refused once the entry expires
+	_, err = store.GetLoginSession(ctx, "laptop", "userId")
+	assert.NoError(t, err)
+	delete(inner.sessions, "laptop")
+	_, err = store.GetLoginSession(ctx, "laptop", "userId")
+	assert.NoError(t, err)
+	clock.now = clock.now.Add(time.Minute)
+	_, err = store.GetLoginSession(ctx, "laptop", "userId")
+	assert.Equal(t, ErrNotFound, err)
+}
@@ -0,0 +1,45 @@
+package bolt
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"go.etcd.io/bbolt"
+)
+
+// Backup writes a consistent copy of the database to w. It runs in a read transaction,
+// so the store keeps serving reads and writes while the copy is made
+func Backup(conn *bbolt.DB, w io.Writer) (int64, error) {
+	var n int64
+	err := conn.View(func(tx *bbolt.Tx) error {
+		var err error
+		n, err = tx.WriteTo(w)
+		return err
+	})
+	return n, err
+}
+
+// Snapshot backs the database up to the file at path. The copy is written next to it first
+// and renamed once complete, so an interrupted backup never replaces a good snapshot
+func Snapshot(conn *bbolt.DB, path string) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := Backup(conn, tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
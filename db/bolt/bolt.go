@@ -0,0 +1,262 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/lib/clock"
+	"github.com/victor-nach/time-tracker/models"
+	"go.etcd.io/bbolt"
+)
+
+// FileName is the name of the database file in the data directory
+const FileName = "tracker.db"
+
+var (
+	usersBucket    = []byte("users")
+	sessionsBucket = []byte("sessions")
+	projectsBucket = []byte("projects")
+	clientsBucket  = []byte("clients")
+	ratesBucket    = []byte("rates")
+	tokensBucket   = []byte("refreshtokens")
+	loginsBucket   = []byte("loginsessions")
+	onetimeBucket  = []byte("onetimetokens")
+)
+
+// boltStore keeps every entity as json in a bucket keyed by id. Lookups other than by id
+// scan the bucket and filter in Go, which suits the single user data sets it is meant for.
+// Writes are serialized by bbolt so checks and updates in one transaction cannot interleave
+type boltStore struct {
+	conn  *bbolt.DB
+	clock clock.Clock
+}
+
+// ensure boltStore implements the datastore interface
+var _ db.Datastore = &boltStore{}
+
+//New opens the database file in dataDir, creating the directory and file when they do not exist
+func New(dataDir string) (db.Datastore, *bbolt.DB, error) {
+	if err := os.MkdirAll(dataDir, 0700); err != nil {
+		return nil, nil, err
+	}
+
+	// the file is locked while open, the timeout stops a second instance from waiting forever
+	conn, err := bbolt.Open(filepath.Join(dataDir, FileName), 0600, &bbolt.Options{Timeout: 10 * time.Second})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = conn.Update(func(tx *bbolt.Tx) error {
+		for _, bucket := range [][]byte{usersBucket, sessionsBucket, projectsBucket, clientsBucket, ratesBucket, tokensBucket, loginsBucket, onetimeBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return verifyExistingUsers(tx)
+	})
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	return &boltStore{conn: conn, clock: clock.New()}, conn, nil
+}
+
+// verifyExistingUsers marks the users saved before verification existed as verified,
+// users saved since always store the field
+func verifyExistingUsers(tx *bbolt.Tx) error {
+	bucket := tx.Bucket(usersBucket)
+	var verify []*models.User
+	err := bucket.ForEach(func(k, v []byte) error {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(v, &fields); err != nil {
+			return err
+		}
+		if _, ok := fields["emailVerified"]; ok {
+			return nil
+		}
+		var user models.User
+		if err := json.Unmarshal(v, &user); err != nil {
+			return err
+		}
+		user.EmailVerified = true
+		verify = append(verify, &user)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// the bucket can not be changed while iterating it
+	for _, user := range verify {
+		if err := put(tx, usersBucket, user.ID, user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// put saves the entity under id
+func put(tx *bbolt.Tx, bucket []byte, id string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(bucket).Put([]byte(id), data)
+}
+
+// get loads the entity saved under id into v
+func get(tx *bbolt.Tx, bucket []byte, id string, v interface{}) error {
+	data := tx.Bucket(bucket).Get([]byte(id))
+	if data == nil {
+		return db.ErrNotFound
+	}
+	return json.Unmarshal(data, v)
+}
+
+// update loads the entity saved under id into v, applies change and saves it back.
+// Updating an entity that does not exist does nothing, like with the other stores
+func (b *boltStore) update(bucket []byte, id string, v interface{}, change func()) error {
+	return b.conn.Update(func(tx *bbolt.Tx) error {
+		err := get(tx, bucket, id, v)
+		if err == db.ErrNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		change()
+		return put(tx, bucket, id, v)
+	})
+}
+
+// CreateUser checks the email is free and saves the user in the same write transaction
+func (b *boltStore) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	err := b.conn.Update(func(tx *bbolt.Tx) error {
+		_, err := findUser(tx, func(u *models.User) bool { return u.Email == user.Email })
+		if err == nil {
+			return db.ErrEmailExists
+		}
+		if err != db.ErrNotFound {
+			return err
+		}
+		return put(tx, usersBucket, user.ID, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (b *boltStore) GetUser(ctx context.Context, id string) (*models.User, error) {
+	user := &models.User{}
+	err := b.conn.View(func(tx *bbolt.Tx) error {
+		return get(tx, usersBucket, id, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (b *boltStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	return b.findUser(func(u *models.User) bool { return u.Email == email })
+}
+
+// GetUserByCalendarToken returns the user owning the hashed calendar feed token
+func (b *boltStore) GetUserByCalendarToken(ctx context.Context, token string) (*models.User, error) {
+	if token == "" {
+		return nil, db.ErrNotFound
+	}
+	return b.findUser(func(u *models.User) bool { return u.CalendarToken == token })
+}
+
+func (b *boltStore) findUser(match func(*models.User) bool) (*models.User, error) {
+	var found *models.User
+	err := b.conn.View(func(tx *bbolt.Tx) error {
+		var err error
+		found, err = findUser(tx, match)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// findUser returns the first user that matches
+func findUser(tx *bbolt.Tx, match func(*models.User) bool) (*models.User, error) {
+	var found *models.User
+	err := tx.Bucket(usersBucket).ForEach(func(_, data []byte) error {
+		user := &models.User{}
+		if err := json.Unmarshal(data, user); err != nil {
+			return err
+		}
+		if found == nil && match(user) {
+			found = user
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, db.ErrNotFound
+	}
+	return found, nil
+}
+
+func (b *boltStore) UpdateUser(ctx context.Context, id string, info models.UserInfo) error {
+	user := &models.User{}
+	return b.update(usersBucket, id, user, func() {
+		if info.Name != nil {
+			user.Name = *info.Name
+		}
+		if info.TimeZone != nil {
+			user.TimeZone = *info.TimeZone
+		}
+		if info.WeekStart != nil {
+			user.WeekStart = *info.WeekStart
+		}
+		if info.CalendarToken != nil {
+			user.CalendarToken = *info.CalendarToken
+		}
+		if info.OverlapPolicy != nil {
+			user.OverlapPolicy = *info.OverlapPolicy
+		}
+		if info.Password != nil {
+			user.Password = *info.Password
+		}
+		if info.EmailVerified != nil {
+			user.EmailVerified = *info.EmailVerified
+		}
+	})
+}
+
+func (b *boltStore) GetTokenGeneration(ctx context.Context, id string) (int64, error) {
+	user, err := b.GetUser(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	return user.TokenGeneration, nil
+}
+
+// IncrementTokenGeneration increments the generation in a write transaction so concurrent increments are not lost
+func (b *boltStore) IncrementTokenGeneration(ctx context.Context, id string) (int64, error) {
+	user := &models.User{}
+	err := b.conn.Update(func(tx *bbolt.Tx) error {
+		if err := get(tx, usersBucket, id, user); err != nil {
+			return err
+		}
+		user.TokenGeneration++
+		return put(tx, usersBucket, id, user)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return user.TokenGeneration, nil
+}
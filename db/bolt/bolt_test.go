@@ -0,0 +1,126 @@
+package bolt
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-nach/time-tracker/db/dbtest"
+	"github.com/victor-nach/time-tracker/models"
+	"go.etcd.io/bbolt"
+)
+
+func newTestStore(t *testing.T) (*boltStore, string) {
+	dir, err := ioutil.TempDir("", "tracker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	store, conn, err := New(filepath.Join(dir, "data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return store.(*boltStore), dir
+}
+
+func TestBoltStore(t *testing.T) {
+	store, _ := newTestStore(t)
+	dbtest.RunSuite(t, store)
+}
+
+func TestBoltStore_ConcurrentTimers(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestStore(t)
+
+	// only one of many concurrent timers of a user can start
+	var wg sync.WaitGroup
+	var started int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			timer := &models.Session{ID: fmt.Sprintf("timer%d", i), Owner: "owner", Running: true}
+			if _, err := store.StartTimer(ctx, timer); err == nil {
+				atomic.AddInt32(&started, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), started)
+}
+
+func TestBoltStore_Reopen(t *testing.T) {
+	ctx := context.Background()
+	store, dir := newTestStore(t)
+	session := &models.Session{ID: "session", Owner: "owner", Tags: []string{"design"}, Start: 100, End: 200}
+	_, err := store.CreateSession(ctx, session)
+	assert.NoError(t, err)
+	assert.NoError(t, store.conn.Close())
+
+	// the data outlives the process
+	reopened, conn, err := New(filepath.Join(dir, "data"))
+	assert.NoError(t, err)
+	defer conn.Close()
+	got, err := reopened.GetSession(ctx, "session", "owner")
+	assert.NoError(t, err)
+	assert.Equal(t, session, got)
+}
+
+func TestBoltStore_VerifyExistingUsers(t *testing.T) {
+	ctx := context.Background()
+	store, dir := newTestStore(t)
+	_, err := store.CreateUser(ctx, &models.User{ID: "new", Email: "new@email.com"})
+	assert.NoError(t, err)
+	// a user saved before verification existed has no emailVerified field
+	err = store.conn.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(usersBucket).Put([]byte("old"), []byte(`{"ID":"old","Email":"old@email.com"}`))
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, store.conn.Close())
+
+	reopened, conn, err := New(filepath.Join(dir, "data"))
+	assert.NoError(t, err)
+	defer conn.Close()
+	old, err := reopened.GetUser(ctx, "old")
+	assert.NoError(t, err)
+	assert.True(t, old.EmailVerified)
+	user, err := reopened.GetUser(ctx, "new")
+	assert.NoError(t, err)
+	assert.False(t, user.EmailVerified)
+}
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+	store, dir := newTestStore(t)
+	_, err := store.CreateSession(ctx, &models.Session{ID: "session", Owner: "owner"})
+	assert.NoError(t, err)
+
+	// the snapshot is taken while the store stays open
+	path := filepath.Join(dir, "snapshot.db")
+	assert.NoError(t, Snapshot(store.conn, path))
+	_, err = store.CreateSession(ctx, &models.Session{ID: "later", Owner: "owner"})
+	assert.NoError(t, err)
+
+	// the snapshot is a database file holding the data at the time it was taken
+	conn, err := bbolt.Open(path, 0600, nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+	snapshot := &boltStore{conn: conn}
+	_, err = snapshot.GetSession(ctx, "session", "owner")
+	assert.NoError(t, err)
+	_, err = snapshot.GetSession(ctx, "later", "owner")
+	assert.Error(t, err)
+
+	// no temporary files are left behind
+	files, err := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+}
@@ -0,0 +1,89 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/models"
+	"go.etcd.io/bbolt"
+)
+
+func (b *boltStore) CreateLoginSession(ctx context.Context, session *models.LoginSession) error {
+	return b.conn.Update(func(tx *bbolt.Tx) error {
+		return put(tx, loginsBucket, session.ID, session)
+	})
+}
+
+func (b *boltStore) GetLoginSession(ctx context.Context, id, owner string) (*models.LoginSession, error) {
+	session := &models.LoginSession{}
+	err := b.conn.View(func(tx *bbolt.Tx) error {
+		return get(tx, loginsBucket, id, session)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if session.Owner != owner {
+		return nil, db.ErrNotFound
+	}
+	return session, nil
+}
+
+// GetLoginSessions returns the login sessions of the owner, most recently used first
+func (b *boltStore) GetLoginSessions(ctx context.Context, owner string) ([]*models.LoginSession, error) {
+	var sessions []*models.LoginSession
+	err := b.conn.View(func(tx *bbolt.Tx) error {
+		var err error
+		sessions, err = findLoginSessions(tx, owner)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	db.SortMostRecentlyUsed(sessions)
+	return sessions, nil
+}
+
+func (b *boltStore) UpdateLoginSession(ctx context.Context, id string, info models.LoginSessionInfo) error {
+	session := &models.LoginSession{}
+	return b.update(loginsBucket, id, session, func() {
+		db.ApplyLoginSessionInfo(session, info)
+	})
+}
+
+func (b *boltStore) DeleteLoginSession(ctx context.Context, id string) error {
+	return b.conn.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(loginsBucket).Delete([]byte(id))
+	})
+}
+
+func (b *boltStore) DeleteLoginSessions(ctx context.Context, owner string) error {
+	return b.conn.Update(func(tx *bbolt.Tx) error {
+		sessions, err := findLoginSessions(tx, owner)
+		if err != nil {
+			return err
+		}
+		// a bucket must not be modified while it is iterated
+		for _, s := range sessions {
+			if err := tx.Bucket(loginsBucket).Delete([]byte(s.ID)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func findLoginSessions(tx *bbolt.Tx, owner string) ([]*models.LoginSession, error) {
+	sessions := []*models.LoginSession{}
+	err := tx.Bucket(loginsBucket).ForEach(func(_, data []byte) error {
+		s := &models.LoginSession{}
+		if err := json.Unmarshal(data, s); err != nil {
+			return err
+		}
+		if s.Owner == owner {
+			sessions = append(sessions, s)
+		}
+		return nil
+	})
+	return sessions, err
+}
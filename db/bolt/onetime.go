@@ -0,0 +1,73 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/models"
+	"go.etcd.io/bbolt"
+)
+
+func (b *boltStore) CreateOneTimeToken(ctx context.Context, token *models.OneTimeToken) error {
+	return b.conn.Update(func(tx *bbolt.Tx) error {
+		return put(tx, onetimeBucket, token.ID, token)
+	})
+}
+
+func (b *boltStore) GetOneTimeToken(ctx context.Context, id string) (*models.OneTimeToken, error) {
+	token := &models.OneTimeToken{}
+	err := b.conn.View(func(tx *bbolt.Tx) error {
+		return get(tx, onetimeBucket, id, token)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// UseOneTimeToken checks and marks the token used in the same write transaction
+func (b *boltStore) UseOneTimeToken(ctx context.Context, id, purpose string, now int64) (*models.OneTimeToken, error) {
+	token := &models.OneTimeToken{}
+	err := b.conn.Update(func(tx *bbolt.Tx) error {
+		if err := get(tx, onetimeBucket, id, token); err != nil {
+			return err
+		}
+		if err := db.CheckOneTimeToken(token, purpose, now); err != nil {
+			return err
+		}
+		token.Used = true
+		return put(tx, onetimeBucket, id, token)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (b *boltStore) DeleteOneTimeTokens(ctx context.Context, owner, purpose string) error {
+	return b.conn.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(onetimeBucket)
+		var ids [][]byte
+		err := bucket.ForEach(func(id, data []byte) error {
+			token := &models.OneTimeToken{}
+			if err := json.Unmarshal(data, token); err != nil {
+				return err
+			}
+			if token.Owner == owner && token.Purpose == purpose {
+				ids = append(ids, id)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		// a bucket must not be modified while it is iterated
+		for _, id := range ids {
+			if err := bucket.Delete(id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,201 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/models"
+	"go.etcd.io/bbolt"
+)
+
+func (b *boltStore) CreateProject(ctx context.Context, project *models.Project) (*models.Project, error) {
+	err := b.conn.Update(func(tx *bbolt.Tx) error {
+		return put(tx, projectsBucket, project.ID, project)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+func (b *boltStore) GetProject(ctx context.Context, id, owner string) (*models.Project, error) {
+	project := &models.Project{}
+	err := b.conn.View(func(tx *bbolt.Tx) error {
+		return get(tx, projectsBucket, id, project)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if project.Owner != owner {
+		return nil, db.ErrNotFound
+	}
+	return project, nil
+}
+
+func (b *boltStore) GetProjects(ctx context.Context, owner string, includeArchived bool) ([]*models.Project, error) {
+	projects := []*models.Project{}
+	err := b.conn.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(projectsBucket).ForEach(func(_, data []byte) error {
+			p := &models.Project{}
+			if err := json.Unmarshal(data, p); err != nil {
+				return err
+			}
+			if p.Owner == owner && (includeArchived || !p.Archived) {
+				projects = append(projects, p)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Name < projects[j].Name })
+	return projects, nil
+}
+
+func (b *boltStore) UpdateProject(ctx context.Context, id string, info models.ProjectInfo) error {
+	project := &models.Project{}
+	return b.update(projectsBucket, id, project, func() {
+		if info.ClientID != nil {
+			project.ClientID = *info.ClientID
+		}
+		if info.ClientHistory != nil {
+			project.ClientHistory = *info.ClientHistory
+		}
+		if info.Name != nil {
+			project.Name = *info.Name
+		}
+		if info.Description != nil {
+			project.Description = *info.Description
+		}
+		if info.Archived != nil {
+			project.Archived = *info.Archived
+		}
+	})
+}
+
+// DeleteProject checks for sessions and deletes in the same write transaction
+func (b *boltStore) DeleteProject(ctx context.Context, id string) error {
+	return b.conn.Update(func(tx *bbolt.Tx) error {
+		sessions, err := findSessions(tx, func(s *models.Session) bool { return s.ProjectID == id })
+		if err != nil {
+			return err
+		}
+		if len(sessions) > 0 {
+			return db.ErrProjectInUse
+		}
+		return tx.Bucket(projectsBucket).Delete([]byte(id))
+	})
+}
+
+func (b *boltStore) CreateClient(ctx context.Context, client *models.Client) (*models.Client, error) {
+	err := b.conn.Update(func(tx *bbolt.Tx) error {
+		return put(tx, clientsBucket, client.ID, client)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+func (b *boltStore) GetClient(ctx context.Context, id, owner string) (*models.Client, error) {
+	client := &models.Client{}
+	err := b.conn.View(func(tx *bbolt.Tx) error {
+		return get(tx, clientsBucket, id, client)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if client.Owner != owner {
+		return nil, db.ErrNotFound
+	}
+	return client, nil
+}
+
+func (b *boltStore) GetClients(ctx context.Context, owner string, includeArchived bool) ([]*models.Client, error) {
+	clients := []*models.Client{}
+	err := b.conn.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(clientsBucket).ForEach(func(_, data []byte) error {
+			c := &models.Client{}
+			if err := json.Unmarshal(data, c); err != nil {
+				return err
+			}
+			if c.Owner == owner && (includeArchived || !c.Archived) {
+				clients = append(clients, c)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].Name < clients[j].Name })
+	return clients, nil
+}
+
+func (b *boltStore) UpdateClient(ctx context.Context, id string, info models.ClientInfo) error {
+	client := &models.Client{}
+	return b.update(clientsBucket, id, client, func() {
+		if info.Name != nil {
+			client.Name = *info.Name
+		}
+		if info.Archived != nil {
+			client.Archived = *info.Archived
+		}
+	})
+}
+
+// DeleteClient checks for projects and deletes in the same write transaction
+func (b *boltStore) DeleteClient(ctx context.Context, id string) error {
+	return b.conn.Update(func(tx *bbolt.Tx) error {
+		inUse := false
+		err := tx.Bucket(projectsBucket).ForEach(func(_, data []byte) error {
+			p := &models.Project{}
+			if err := json.Unmarshal(data, p); err != nil {
+				return err
+			}
+			inUse = inUse || p.ClientID == id
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return db.ErrClientInUse
+		}
+		return tx.Bucket(clientsBucket).Delete([]byte(id))
+	})
+}
+
+func (b *boltStore) CreateRate(ctx context.Context, rate *models.Rate) (*models.Rate, error) {
+	err := b.conn.Update(func(tx *bbolt.Tx) error {
+		return put(tx, ratesBucket, rate.ID, rate)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+func (b *boltStore) GetRates(ctx context.Context, owner string) ([]*models.Rate, error) {
+	rates := []*models.Rate{}
+	err := b.conn.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(ratesBucket).ForEach(func(_, data []byte) error {
+			r := &models.Rate{}
+			if err := json.Unmarshal(data, r); err != nil {
+				return err
+			}
+			if r.Owner == owner {
+				rates = append(rates, r)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(rates, func(i, j int) bool { return rates[i].EffectiveFrom > rates[j].EffectiveFrom })
+	return rates, nil
+}
@@ -0,0 +1,28 @@
+package bolt
+
+import (
+	"context"
+	"github.com/victor-nach/time-tracker/lib/billing"
+	"github.com/victor-nach/time-tracker/lib/report"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+// GetReport aggregates the sessions that start in the range of the query
+func (b *boltStore) GetReport(ctx context.Context, owner string, query models.ReportQuery) ([]*models.ReportRow, error) {
+	rates, err := b.GetRates(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	projects, err := b.GetProjects(ctx, owner, true)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := b.filterSessions(func(s *models.Session) bool {
+		return s.Owner == owner && !s.Running && !s.Draft && s.Start >= query.From && s.Start < query.To
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report.Rows(sessions, query, billing.NewCalculator(rates, projects), projects), nil
+}
@@ -0,0 +1,340 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/models"
+	"go.etcd.io/bbolt"
+)
+
+// findSessions returns the sessions that match, in no particular order
+func findSessions(tx *bbolt.Tx, match func(*models.Session) bool) ([]*models.Session, error) {
+	sessions := []*models.Session{}
+	err := tx.Bucket(sessionsBucket).ForEach(func(_, data []byte) error {
+		s := &models.Session{}
+		if err := json.Unmarshal(data, s); err != nil {
+			return err
+		}
+		if match(s) {
+			sessions = append(sessions, s)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// filterSessions returns the sessions that match in a read transaction
+func (b *boltStore) filterSessions(match func(*models.Session) bool) ([]*models.Session, error) {
+	var sessions []*models.Session
+	err := b.conn.View(func(tx *bbolt.Tx) error {
+		var err error
+		sessions, err = findSessions(tx, match)
+		return err
+	})
+	return sessions, err
+}
+
+func (b *boltStore) GetSession(ctx context.Context, id, owner string) (*models.Session, error) {
+	session := &models.Session{}
+	err := b.conn.View(func(tx *bbolt.Tx) error {
+		return get(tx, sessionsBucket, id, session)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if session.Owner != owner {
+		return nil, db.ErrNotFound
+	}
+	return session, nil
+}
+
+func (b *boltStore) GetSessions(ctx context.Context, owner string, filter models.SessionFilter) ([]*models.Session, error) {
+	match, err := db.MatchSessions(owner, filter, b.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := b.filterSessions(match)
+	if err != nil {
+		return nil, err
+	}
+	db.SortMostRecent(sessions)
+	return sessions, nil
+}
+
+// GetSessionsPage returns a window of the most recent first sessions, ordered by ts and id
+func (b *boltStore) GetSessionsPage(ctx context.Context, owner string, filter models.SessionFilter, page models.Page) (*models.SessionPage, error) {
+	sessions, err := b.GetSessions(ctx, owner, filter)
+	if err != nil {
+		return nil, err
+	}
+	return db.PageSessions(sessions, page), nil
+}
+
+// StreamSessions calls fn for every session of the owner that passes the filter in order of start,
+// iteration stops at the first error of fn. The sessions are read before fn is called so a slow
+// reader does not keep a transaction open
+func (b *boltStore) StreamSessions(ctx context.Context, owner string, filter models.SessionFilter, fn func(*models.Session) error) error {
+	match, err := db.MatchSessions(owner, filter, b.clock.Now())
+	if err != nil {
+		return err
+	}
+	sessions, err := b.filterSessions(match)
+	if err != nil {
+		return err
+	}
+	db.SortByStart(sessions)
+	for _, s := range sessions {
+		// a cancelled export stops early, the other operations are quick enough to finish
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *boltStore) CreateSession(ctx context.Context, session *models.Session) (*models.Session, error) {
+	err := b.conn.Update(func(tx *bbolt.Tx) error {
+		return put(tx, sessionsBucket, session.ID, session)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// CreateSessions inserts a batch of sessions in one transaction, either all of them are saved or none
+func (b *boltStore) CreateSessions(ctx context.Context, sessions []*models.Session) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+	return b.conn.Update(func(tx *bbolt.Tx) error {
+		for _, s := range sessions {
+			if err := put(tx, sessionsBucket, s.ID, s); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CreateSessionWithPolicy checks for overlaps and saves the session in the same write transaction
+func (b *boltStore) CreateSessionWithPolicy(ctx context.Context, session *models.Session, policy string) ([]*models.Session, error) {
+	match, err := db.MatchSessions(session.Owner, db.OverlapRange(session), b.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []*models.Session
+	err = b.conn.Update(func(tx *bbolt.Tx) error {
+		candidates, err := findSessions(tx, match)
+		if err != nil {
+			return err
+		}
+		conflicts, err = db.ApplyOverlapPolicy(session, candidates, policy)
+		if err != nil {
+			return err
+		}
+		return put(tx, sessionsBucket, session.ID, session)
+	})
+	return conflicts, err
+}
+
+// GetOverlaps returns every pair of saved sessions of the owner that tracked the same time
+func (b *boltStore) GetOverlaps(ctx context.Context, owner string) ([]*models.Overlap, error) {
+	match, err := db.MatchSessions(owner, models.SessionFilter{}, b.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := b.filterSessions(match)
+	if err != nil {
+		return nil, err
+	}
+	db.SortByStart(sessions)
+	return db.FindOverlaps(sessions), nil
+}
+
+// GetImportedHashes returns the hashes that already belong to imported sessions of the owner
+func (b *boltStore) GetImportedHashes(ctx context.Context, owner string, hashes []string) ([]string, error) {
+	wanted := map[string]bool{}
+	for _, hash := range hashes {
+		wanted[hash] = true
+	}
+	sessions, err := b.filterSessions(func(s *models.Session) bool { return s.Owner == owner && wanted[s.ImportHash] })
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[string]bool{}
+	imported := []string{}
+	for _, s := range sessions {
+		if !found[s.ImportHash] {
+			found[s.ImportHash] = true
+			imported = append(imported, s.ImportHash)
+		}
+	}
+	sort.Strings(imported)
+	return imported, nil
+}
+
+// GetDraftSessions returns the unconfirmed sessions of the owner in order of start
+func (b *boltStore) GetDraftSessions(ctx context.Context, owner string) ([]*models.Session, error) {
+	drafts, err := b.filterSessions(func(s *models.Session) bool { return s.Owner == owner && s.Draft })
+	if err != nil {
+		return nil, err
+	}
+	db.SortByStart(drafts)
+	return drafts, nil
+}
+
+// ConfirmDrafts checks every draft for overlaps and confirms it in the same write transaction
+func (b *boltStore) ConfirmDrafts(ctx context.Context, owner string, ids []string, policy string) (int64, []*models.Session, error) {
+	var confirmed int64
+	conflicts := []*models.Session{}
+	err := b.conn.Update(func(tx *bbolt.Tx) error {
+		for _, id := range ids {
+			s := &models.Session{}
+			err := get(tx, sessionsBucket, id, s)
+			if err == db.ErrNotFound || (err == nil && (s.Owner != owner || !s.Draft)) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			match, err := db.MatchSessions(owner, db.OverlapRange(s), b.clock.Now())
+			if err != nil {
+				return err
+			}
+			candidates, err := findSessions(tx, match)
+			if err != nil {
+				return err
+			}
+			overlaps, err := db.ApplyOverlapPolicy(s, candidates, policy)
+			conflicts = append(conflicts, overlaps...)
+			if err == db.ErrSessionOverlap {
+				continue
+			}
+
+			s.Draft = false
+			if err := put(tx, sessionsBucket, id, s); err != nil {
+				return err
+			}
+			confirmed++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return confirmed, conflicts, nil
+}
+
+func (b *boltStore) UpdateSession(ctx context.Context, id string, info models.SessionInfo) error {
+	session := &models.Session{}
+	return b.update(sessionsBucket, id, session, func() {
+		if info.Title != nil {
+			session.Title = *info.Title
+		}
+		if info.Description != nil {
+			session.Description = *info.Description
+		}
+		if info.ProjectID != nil {
+			session.ProjectID = *info.ProjectID
+		}
+		if info.Billable != nil {
+			session.Billable = *info.Billable
+		}
+		if info.Tags != nil {
+			session.Tags = info.Tags
+		}
+	})
+}
+
+func (b *boltStore) DeleteSession(ctx context.Context, id string) error {
+	return b.conn.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(sessionsBucket).Delete([]byte(id))
+	})
+}
+
+func (b *boltStore) StartTimer(ctx context.Context, session *models.Session) (*models.Session, error) {
+	err := b.conn.Update(func(tx *bbolt.Tx) error {
+		running, err := findSessions(tx, isRunning(session.Owner))
+		if err != nil {
+			return err
+		}
+		if len(running) > 0 {
+			return db.ErrTimerRunning
+		}
+		return put(tx, sessionsBucket, session.ID, session)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (b *boltStore) GetRunningTimer(ctx context.Context, owner string) (*models.Session, error) {
+	running, err := b.filterSessions(isRunning(owner))
+	if err != nil {
+		return nil, err
+	}
+	if len(running) == 0 {
+		return nil, db.ErrNoRunningTimer
+	}
+	return running[0], nil
+}
+
+func (b *boltStore) PauseTimer(ctx context.Context, owner string, at int64) (*models.Session, error) {
+	return b.updateTimer(owner, func(session *models.Session) error {
+		return db.PauseTimer(session, at)
+	})
+}
+
+func (b *boltStore) ResumeTimer(ctx context.Context, owner string, at int64) (*models.Session, error) {
+	return b.updateTimer(owner, func(session *models.Session) error {
+		return db.ResumeTimer(session, at)
+	})
+}
+
+func (b *boltStore) StopTimer(ctx context.Context, owner string, end int64) (*models.Session, error) {
+	return b.updateTimer(owner, func(session *models.Session) error {
+		return db.StopTimer(session, end)
+	})
+}
+
+// updateTimer applies change to the running timer of the owner in a write transaction
+func (b *boltStore) updateTimer(owner string, change func(*models.Session) error) (*models.Session, error) {
+	var session *models.Session
+	err := b.conn.Update(func(tx *bbolt.Tx) error {
+		running, err := findSessions(tx, isRunning(owner))
+		if err != nil {
+			return err
+		}
+		if len(running) == 0 {
+			return db.ErrNoRunningTimer
+		}
+
+		session = running[0]
+		if err := change(session); err != nil {
+			return err
+		}
+		return put(tx, sessionsBucket, session.ID, session)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func isRunning(owner string) func(*models.Session) bool {
+	return func(s *models.Session) bool { return s.Owner == owner && s.Running }
+}
@@ -0,0 +1,44 @@
+package bolt
+
+import (
+	"context"
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/models"
+	"go.etcd.io/bbolt"
+)
+
+func (b *boltStore) GetTags(ctx context.Context, owner string) ([]*models.TagCount, error) {
+	sessions, err := b.filterSessions(func(s *models.Session) bool { return s.Owner == owner && !s.Draft })
+	if err != nil {
+		return nil, err
+	}
+	return db.CountTags(sessions), nil
+}
+
+// MergeTags replaces the given tags with a single tag on every session of the owner,
+// renaming a tag is a merge of a single tag
+func (b *boltStore) MergeTags(ctx context.Context, owner string, tags []string, into string) (int64, error) {
+	var modified int64
+	err := b.conn.Update(func(tx *bbolt.Tx) error {
+		sessions, err := findSessions(tx, func(s *models.Session) bool { return s.Owner == owner })
+		if err != nil {
+			return err
+		}
+		for _, s := range sessions {
+			merged, changed := db.MergeTags(s.Tags, tags, into)
+			if !changed {
+				continue
+			}
+			s.Tags = merged
+			if err := put(tx, sessionsBucket, s.ID, s); err != nil {
+				return err
+			}
+			modified++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return modified, nil
+}
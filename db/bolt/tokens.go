@@ -0,0 +1,97 @@
+package bolt
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/models"
+	"go.etcd.io/bbolt"
+)
+
+func (b *boltStore) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	return b.conn.Update(func(tx *bbolt.Tx) error {
+		return put(tx, tokensBucket, token.ID, token)
+	})
+}
+
+func (b *boltStore) GetRefreshToken(ctx context.Context, id string) (*models.RefreshToken, error) {
+	token := &models.RefreshToken{}
+	err := b.conn.View(func(tx *bbolt.Tx) error {
+		return get(tx, tokensBucket, id, token)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// RotateRefreshToken exchanges the token for next in one write transaction, reuse revokes the family
+func (b *boltStore) RotateRefreshToken(ctx context.Context, id string, next *models.RefreshToken) error {
+	var rotateErr error
+	err := b.conn.Update(func(tx *bbolt.Tx) error {
+		current := &models.RefreshToken{}
+		if err := get(tx, tokensBucket, id, current); err != nil {
+			return err
+		}
+		// the revocation of a reused family must be committed, so the error is returned after the transaction
+		if rotateErr = db.CheckRotation(current, next.Owner); rotateErr != nil {
+			if rotateErr == db.ErrTokenReused {
+				return revokeTokens(tx, inFamily(current.Family))
+			}
+			return nil
+		}
+
+		db.NextRefreshToken(current, next)
+		if err := put(tx, tokensBucket, current.ID, current); err != nil {
+			return err
+		}
+		return put(tx, tokensBucket, next.ID, next)
+	})
+	if err != nil {
+		return err
+	}
+	return rotateErr
+}
+
+func (b *boltStore) RevokeRefreshFamily(ctx context.Context, family string) error {
+	return b.conn.Update(func(tx *bbolt.Tx) error {
+		return revokeTokens(tx, inFamily(family))
+	})
+}
+
+func (b *boltStore) RevokeUserRefreshTokens(ctx context.Context, owner string) error {
+	return b.conn.Update(func(tx *bbolt.Tx) error {
+		return revokeTokens(tx, func(token *models.RefreshToken) bool { return token.Owner == owner })
+	})
+}
+
+func inFamily(family string) func(*models.RefreshToken) bool {
+	return func(token *models.RefreshToken) bool { return token.Family == family }
+}
+
+// revokeTokens revokes every token that matches
+func revokeTokens(tx *bbolt.Tx, match func(*models.RefreshToken) bool) error {
+	var revoked []*models.RefreshToken
+	err := tx.Bucket(tokensBucket).ForEach(func(_, data []byte) error {
+		token := &models.RefreshToken{}
+		if err := json.Unmarshal(data, token); err != nil {
+			return err
+		}
+		if match(token) && !token.Revoked {
+			token.Revoked = true
+			revoked = append(revoked, token)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// a bucket must not be modified while it is iterated
+	for _, token := range revoked {
+		if err := put(tx, tokensBucket, token.ID, token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
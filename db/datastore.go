@@ -1,17 +1,81 @@
 package db
 
-import "github.com/victor-nach/time-tracker/models"
+import (
+	"context"
+
+	"github.com/victor-nach/time-tracker/models"
+)
 
 //Datastore defines the required store methods
 type Datastore interface {
-	CreateUser(user *models.User) (*models.User, error)
-	GetUser(id string) (*models.User, error)
-	GetUserByEmail(email string) (*models.User, error)
+	CreateUser(ctx context.Context, user *models.User) (*models.User, error)
+	GetUser(ctx context.Context, id string) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetUserByCalendarToken(ctx context.Context, token string) (*models.User, error)
+	UpdateUser(ctx context.Context, id string, info models.UserInfo) error
+	GetTokenGeneration(ctx context.Context, id string) (int64, error)
+	IncrementTokenGeneration(ctx context.Context, id string) (int64, error)
+
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	GetRefreshToken(ctx context.Context, id string) (*models.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, id string, next *models.RefreshToken) error
+	RevokeRefreshFamily(ctx context.Context, family string) error
+	RevokeUserRefreshTokens(ctx context.Context, owner string) error
+
+	CreateLoginSession(ctx context.Context, session *models.LoginSession) error
+	GetLoginSession(ctx context.Context, id, owner string) (*models.LoginSession, error)
+	GetLoginSessions(ctx context.Context, owner string) ([]*models.LoginSession, error)
+	UpdateLoginSession(ctx context.Context, id string, info models.LoginSessionInfo) error
+	DeleteLoginSession(ctx context.Context, id string) error
+	DeleteLoginSessions(ctx context.Context, owner string) error
+
+	CreateOneTimeToken(ctx context.Context, token *models.OneTimeToken) error
+	GetOneTimeToken(ctx context.Context, id string) (*models.OneTimeToken, error)
+	// UseOneTimeToken marks the token used and returns it, it fails unless the token has the purpose,
+	// is unused and not expired at now. Of two concurrent uses only one succeeds
+	UseOneTimeToken(ctx context.Context, id, purpose string, now int64) (*models.OneTimeToken, error)
+	DeleteOneTimeTokens(ctx context.Context, owner, purpose string) error
+
+	GetSession(ctx context.Context, id, owner string) (*models.Session, error)
+	GetSessions(ctx context.Context, owner string, filter models.SessionFilter) ([]*models.Session, error)
+	GetSessionsPage(ctx context.Context, owner string, filter models.SessionFilter, page models.Page) (*models.SessionPage, error)
+	StreamSessions(ctx context.Context, owner string, filter models.SessionFilter, fn func(*models.Session) error) error
+
+	CreateSession(ctx context.Context, session *models.Session) (*models.Session, error)
+	CreateSessions(ctx context.Context, sessions []*models.Session) error
+	CreateSessionWithPolicy(ctx context.Context, session *models.Session, policy string) ([]*models.Session, error)
+	GetOverlaps(ctx context.Context, owner string) ([]*models.Overlap, error)
+	GetImportedHashes(ctx context.Context, owner string, hashes []string) ([]string, error)
+	GetDraftSessions(ctx context.Context, owner string) ([]*models.Session, error)
+	// ConfirmDrafts turns the drafts into regular sessions following the overlap policy like CreateSessionWithPolicy,
+	// drafts the policy rejects stay drafts. It returns how many were confirmed and the sessions the drafts overlap
+	ConfirmDrafts(ctx context.Context, owner string, ids []string, policy string) (int64, []*models.Session, error)
+	UpdateSession(ctx context.Context, id string, info models.SessionInfo) error
+	DeleteSession(ctx context.Context, id string) error
+
+	GetReport(ctx context.Context, owner string, query models.ReportQuery) ([]*models.ReportRow, error)
+
+	GetTags(ctx context.Context, owner string) ([]*models.TagCount, error)
+	MergeTags(ctx context.Context, owner string, tags []string, into string) (int64, error)
+
+	StartTimer(ctx context.Context, session *models.Session) (*models.Session, error)
+	GetRunningTimer(ctx context.Context, owner string) (*models.Session, error)
+	PauseTimer(ctx context.Context, owner string, at int64) (*models.Session, error)
+	ResumeTimer(ctx context.Context, owner string, at int64) (*models.Session, error)
+	StopTimer(ctx context.Context, owner string, end int64) (*models.Session, error)
+
+	CreateProject(ctx context.Context, project *models.Project) (*models.Project, error)
+	GetProject(ctx context.Context, id, owner string) (*models.Project, error)
+	GetProjects(ctx context.Context, owner string, includeArchived bool) ([]*models.Project, error)
+	UpdateProject(ctx context.Context, id string, info models.ProjectInfo) error
+	DeleteProject(ctx context.Context, id string) error
 
-	GetSession(id, owner string) (*models.Session, error)
-	GetSessions(owner string, filter string) ([]*models.Session, error)
+	CreateClient(ctx context.Context, client *models.Client) (*models.Client, error)
+	GetClient(ctx context.Context, id, owner string) (*models.Client, error)
+	GetClients(ctx context.Context, owner string, includeArchived bool) ([]*models.Client, error)
+	UpdateClient(ctx context.Context, id string, info models.ClientInfo) error
+	DeleteClient(ctx context.Context, id string) error
 
-	CreateSession(session *models.Session) (*models.Session, error)
-	UpdateSession(id string, info models.SessionInfo) error
-	DeleteSession(id string) error
+	CreateRate(ctx context.Context, rate *models.Rate) (*models.Rate, error)
+	GetRates(ctx context.Context, owner string) ([]*models.Rate, error)
 }
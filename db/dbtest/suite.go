@@ -0,0 +1,845 @@
+// Package dbtest holds the behaviour every db.Datastore implementation must have
+package dbtest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/lib/ulid"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+// RunSuite runs the conformance tests against the store, every test works with its own owner
+// so the store can be shared between tests and hold data from other tests
+func RunSuite(t *testing.T, store db.Datastore) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, store db.Datastore)
+	}{
+		{name: "Users", test: testUsers},
+		{name: "RefreshTokens", test: testRefreshTokens},
+		{name: "TokenGeneration", test: testTokenGeneration},
+		{name: "LoginSessions", test: testLoginSessions},
+		{name: "OneTimeTokens", test: testOneTimeTokens},
+		{name: "Sessions", test: testSessions},
+		{name: "SessionFilters", test: testSessionFilters},
+		{name: "SessionsPage", test: testSessionsPage},
+		{name: "StreamSessions", test: testStreamSessions},
+		{name: "Drafts", test: testDrafts},
+		{name: "Overlaps", test: testOverlaps},
+		{name: "Timer", test: testTimer},
+		{name: "ProjectsAndClients", test: testProjectsAndClients},
+		{name: "Rates", test: testRates},
+		{name: "Tags", test: testTags},
+		{name: "Report", test: testReport},
+		{name: "ReportMovedProject", test: testReportMovedProject},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.test(t, store)
+		})
+	}
+}
+
+func newID() string {
+	return ulid.New().Generate()
+}
+
+// createSessions stores a session for each of the given sessions with a new id and the owner set
+func createSessions(t *testing.T, store db.Datastore, owner string, sessions ...models.Session) []*models.Session {
+	ctx := context.Background()
+	var created []*models.Session
+	for _, s := range sessions {
+		s := s
+		s.ID = newID()
+		s.Owner = owner
+		if s.End == 0 {
+			s.End = s.Start + 60
+		}
+		_, err := store.CreateSession(ctx, &s)
+		assert.NoError(t, err)
+		created = append(created, &s)
+	}
+	return created
+}
+
+func sessionIDs(sessions []*models.Session) []string {
+	ids := []string{}
+	for _, s := range sessions {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+func loginSessionIDs(sessions []*models.LoginSession) []string {
+	ids := []string{}
+	for _, s := range sessions {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+func testUsers(t *testing.T, store db.Datastore) {
+	ctx := context.Background()
+	user := models.User{ID: newID(), Name: "Ada", Email: newID() + "@email.com", Password: "hashed", Ts: 100}
+	_, err := store.CreateUser(ctx, &user)
+	assert.NoError(t, err)
+
+	got, err := store.GetUser(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, user, *got)
+
+	// emails are unique
+	duplicate := models.User{ID: newID(), Name: "Grace", Email: user.Email, Password: "hashed", Ts: 200}
+	_, err = store.CreateUser(ctx, &duplicate)
+	assert.Equal(t, db.ErrEmailExists, err)
+	_, err = store.GetUser(ctx, duplicate.ID)
+	assert.Error(t, err)
+
+	got, err = store.GetUserByEmail(ctx, user.Email)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, got.ID)
+
+	_, err = store.GetUser(ctx, newID())
+	assert.Error(t, err)
+	_, err = store.GetUserByEmail(ctx, newID()+"@email.com")
+	assert.Error(t, err)
+
+	name, timeZone, weekStart, token := "Lovelace", "Africa/Lagos", 1, newID()
+	err = store.UpdateUser(ctx, user.ID, models.UserInfo{Name: &name, TimeZone: &timeZone, WeekStart: &weekStart, CalendarToken: &token})
+	assert.NoError(t, err)
+
+	got, err = store.GetUserByCalendarToken(ctx, token)
+	assert.NoError(t, err)
+	assert.Equal(t, name, got.Name)
+	assert.Equal(t, timeZone, got.TimeZone)
+	assert.Equal(t, weekStart, got.WeekStart)
+	assert.Equal(t, user.Email, got.Email)
+
+	password, verified := "rehashed", true
+	assert.NoError(t, store.UpdateUser(ctx, user.ID, models.UserInfo{Password: &password, EmailVerified: &verified}))
+	got, err = store.GetUser(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, password, got.Password)
+	assert.True(t, got.EmailVerified)
+	assert.Equal(t, name, got.Name)
+
+	_, err = store.GetUserByCalendarToken(ctx, "")
+	assert.Error(t, err)
+}
+
+func testRefreshTokens(t *testing.T, store db.Datastore) {
+	ctx := context.Background()
+	owner := newID()
+	first := models.RefreshToken{ID: newID(), Family: newID(), Owner: owner, ExpiresAt: 1000, Ts: 100}
+	assert.NoError(t, store.CreateRefreshToken(ctx, &first))
+
+	second := models.RefreshToken{ID: newID(), Owner: owner, ExpiresAt: 2000, Ts: 200}
+	assert.NoError(t, store.RotateRefreshToken(ctx, first.ID, &second))
+	assert.Equal(t, first.Family, second.Family)
+
+	other := models.RefreshToken{ID: newID(), Owner: newID()}
+	assert.Equal(t, db.ErrNotFound, store.RotateRefreshToken(ctx, second.ID, &other))
+	assert.Equal(t, db.ErrNotFound, store.RotateRefreshToken(ctx, newID(), &models.RefreshToken{ID: newID(), Owner: owner}))
+
+	// using the first token again revokes the whole family
+	third := models.RefreshToken{ID: newID(), Owner: owner}
+	assert.Equal(t, db.ErrTokenReused, store.RotateRefreshToken(ctx, first.ID, &third))
+	assert.Equal(t, db.ErrTokenRevoked, store.RotateRefreshToken(ctx, second.ID, &third))
+
+	// other families are left alone by a revocation
+	login := models.RefreshToken{ID: newID(), Family: newID(), Owner: owner}
+	assert.NoError(t, store.CreateRefreshToken(ctx, &login))
+	assert.NoError(t, store.RevokeRefreshFamily(ctx, first.Family))
+	assert.NoError(t, store.RotateRefreshToken(ctx, login.ID, &models.RefreshToken{ID: newID(), Owner: owner}))
+
+	assert.NoError(t, store.RevokeRefreshFamily(ctx, login.Family))
+	assert.Equal(t, db.ErrTokenRevoked, store.RotateRefreshToken(ctx, login.ID, &models.RefreshToken{ID: newID(), Owner: owner}))
+
+	got, err := store.GetRefreshToken(ctx, second.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, first.Family, got.Family)
+	assert.True(t, got.Revoked)
+	_, err = store.GetRefreshToken(ctx, newID())
+	assert.Error(t, err)
+
+	// signing out of every device revokes the families of the owner only
+	device := models.RefreshToken{ID: newID(), Family: newID(), Owner: owner}
+	otherUser := models.RefreshToken{ID: newID(), Family: newID(), Owner: newID()}
+	assert.NoError(t, store.CreateRefreshToken(ctx, &device))
+	assert.NoError(t, store.CreateRefreshToken(ctx, &otherUser))
+	assert.NoError(t, store.RevokeUserRefreshTokens(ctx, owner))
+	got, err = store.GetRefreshToken(ctx, device.ID)
+	assert.NoError(t, err)
+	assert.True(t, got.Revoked)
+	got, err = store.GetRefreshToken(ctx, otherUser.ID)
+	assert.NoError(t, err)
+	assert.False(t, got.Revoked)
+}
+
+func testTokenGeneration(t *testing.T, store db.Datastore) {
+	ctx := context.Background()
+	user := models.User{ID: newID(), Email: newID() + "@email.com"}
+	_, err := store.CreateUser(ctx, &user)
+	assert.NoError(t, err)
+
+	generation, err := store.GetTokenGeneration(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), generation)
+
+	for want := int64(1); want <= 2; want++ {
+		generation, err = store.IncrementTokenGeneration(ctx, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, want, generation)
+	}
+	generation, err = store.GetTokenGeneration(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), generation)
+
+	// profile updates keep the generation
+	name := "Ada"
+	assert.NoError(t, store.UpdateUser(ctx, user.ID, models.UserInfo{Name: &name}))
+	got, err := store.GetUser(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), got.TokenGeneration)
+
+	_, err = store.GetTokenGeneration(ctx, newID())
+	assert.Error(t, err)
+	_, err = store.IncrementTokenGeneration(ctx, newID())
+	assert.Error(t, err)
+}
+
+func testLoginSessions(t *testing.T, store db.Datastore) {
+	ctx := context.Background()
+	owner := newID()
+	first := models.LoginSession{ID: newID(), Owner: owner, UserAgent: "curl", IP: "10.0.0.1", CreatedAt: 100, LastUsedAt: 100}
+	second := models.LoginSession{ID: newID(), Owner: owner, UserAgent: "firefox", IP: "10.0.0.2", CreatedAt: 200, LastUsedAt: 200}
+	other := models.LoginSession{ID: newID(), Owner: newID(), CreatedAt: 300, LastUsedAt: 300}
+	for _, s := range []models.LoginSession{first, second, other} {
+		s := s
+		assert.NoError(t, store.CreateLoginSession(ctx, &s))
+	}
+
+	got, err := store.GetLoginSession(ctx, first.ID, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, first, *got)
+	_, err = store.GetLoginSession(ctx, first.ID, other.Owner)
+	assert.Error(t, err)
+
+	// login sessions are scoped to their owner and sorted most recently used first
+	sessions, err := store.GetLoginSessions(ctx, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{second.ID, first.ID}, loginSessionIDs(sessions))
+
+	ip, lastUsed := "10.0.0.3", int64(400)
+	assert.NoError(t, store.UpdateLoginSession(ctx, first.ID, models.LoginSessionInfo{IP: &ip, LastUsedAt: &lastUsed}))
+	got, err = store.GetLoginSession(ctx, first.ID, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, ip, got.IP)
+	assert.Equal(t, "curl", got.UserAgent)
+	assert.Equal(t, lastUsed, got.LastUsedAt)
+	sessions, err = store.GetLoginSessions(ctx, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{first.ID, second.ID}, loginSessionIDs(sessions))
+
+	assert.NoError(t, store.DeleteLoginSession(ctx, first.ID))
+	_, err = store.GetLoginSession(ctx, first.ID, owner)
+	assert.Error(t, err)
+
+	assert.NoError(t, store.DeleteLoginSessions(ctx, owner))
+	sessions, err = store.GetLoginSessions(ctx, owner)
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+	_, err = store.GetLoginSession(ctx, other.ID, other.Owner)
+	assert.NoError(t, err)
+}
+
+func testOneTimeTokens(t *testing.T, store db.Datastore) {
+	ctx := context.Background()
+	owner := newID()
+	valid := models.OneTimeToken{ID: newID(), Owner: owner, Purpose: models.PurposePasswordReset, ExpiresAt: 200, Ts: 100}
+	expired := models.OneTimeToken{ID: newID(), Owner: owner, Purpose: models.PurposePasswordReset, ExpiresAt: 150, Ts: 100}
+	pending := models.OneTimeToken{ID: newID(), Owner: owner, Purpose: models.PurposePasswordReset, ExpiresAt: 200, Ts: 100}
+	for _, token := range []models.OneTimeToken{valid, expired, pending} {
+		token := token
+		assert.NoError(t, store.CreateOneTimeToken(ctx, &token))
+	}
+
+	got, err := store.GetOneTimeToken(ctx, valid.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, &valid, got)
+	_, err = store.GetOneTimeToken(ctx, newID())
+	assert.Equal(t, db.ErrNotFound, err)
+
+	_, err = store.UseOneTimeToken(ctx, valid.ID, "other", 160)
+	assert.Equal(t, db.ErrNotFound, err)
+	_, err = store.UseOneTimeToken(ctx, expired.ID, models.PurposePasswordReset, 160)
+	assert.Equal(t, db.ErrTokenExpired, err)
+	_, err = store.UseOneTimeToken(ctx, newID(), models.PurposePasswordReset, 160)
+	assert.Equal(t, db.ErrNotFound, err)
+
+	// a token can only be used once
+	used, err := store.UseOneTimeToken(ctx, valid.ID, models.PurposePasswordReset, 160)
+	assert.NoError(t, err)
+	assert.Equal(t, owner, used.Owner)
+	assert.True(t, used.Used)
+	_, err = store.UseOneTimeToken(ctx, valid.ID, models.PurposePasswordReset, 160)
+	assert.Equal(t, db.ErrTokenUsed, err)
+
+	// of concurrent uses of the same token only one succeeds
+	raced := models.OneTimeToken{ID: newID(), Owner: owner, Purpose: models.PurposePasswordReset, ExpiresAt: 200, Ts: 100}
+	assert.NoError(t, store.CreateOneTimeToken(ctx, &raced))
+	var wg sync.WaitGroup
+	results := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := store.UseOneTimeToken(ctx, raced.ID, models.PurposePasswordReset, 160)
+			results <- err
+		}()
+	}
+	wg.Wait()
+	close(results)
+	succeeded := 0
+	for err := range results {
+		if err == nil {
+			succeeded++
+		} else {
+			assert.Equal(t, db.ErrTokenUsed, err)
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+
+	assert.NoError(t, store.DeleteOneTimeTokens(ctx, owner, models.PurposePasswordReset))
+	_, err = store.UseOneTimeToken(ctx, pending.ID, models.PurposePasswordReset, 160)
+	assert.Equal(t, db.ErrNotFound, err)
+}
+
+func testSessions(t *testing.T, store db.Datastore) {
+	ctx := context.Background()
+	owner := newID()
+	created := createSessions(t, store, owner,
+		models.Session{Title: "first", Start: 100, Ts: 100},
+		models.Session{Title: "second", Start: 200, Ts: 200, Tags: []string{"a"}},
+	)
+	createSessions(t, store, newID(), models.Session{Title: "other owner", Start: 300, Ts: 300})
+
+	// sessions are scoped to their owner and sorted most recent first
+	sessions, err := store.GetSessions(ctx, owner, models.SessionFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{created[1].ID, created[0].ID}, sessionIDs(sessions))
+
+	_, err = store.GetSession(ctx, created[0].ID, newID())
+	assert.Error(t, err)
+
+	title, billable := "renamed", true
+	err = store.UpdateSession(ctx, created[0].ID, models.SessionInfo{Title: &title, Billable: &billable, Tags: []string{"b"}})
+	assert.NoError(t, err)
+	session, err := store.GetSession(ctx, created[0].ID, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, title, session.Title)
+	assert.True(t, session.Billable)
+	assert.Equal(t, []string{"b"}, session.Tags)
+	assert.Equal(t, created[0].Start, session.Start)
+
+	// a returned session does not share memory with the store
+	session.Tags[0] = "changed"
+	session, err = store.GetSession(ctx, created[0].ID, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b"}, session.Tags)
+
+	assert.NoError(t, store.DeleteSession(ctx, created[0].ID))
+	_, err = store.GetSession(ctx, created[0].ID, owner)
+	assert.Error(t, err)
+
+	batch := []*models.Session{
+		{ID: newID(), Owner: owner, Start: 400, End: 500, Ts: 400, ImportHash: "hash1"},
+		{ID: newID(), Owner: owner, Start: 500, End: 600, Ts: 500, ImportHash: "hash2"},
+	}
+	assert.NoError(t, store.CreateSessions(ctx, batch))
+	assert.NoError(t, store.CreateSessions(ctx, nil))
+
+	hashes, err := store.GetImportedHashes(ctx, owner, []string{"hash2", "hash3"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hash2"}, hashes)
+	hashes, err = store.GetImportedHashes(ctx, newID(), []string{"hash1"})
+	assert.NoError(t, err)
+	assert.Empty(t, hashes)
+}
+
+func testSessionFilters(t *testing.T, store db.Datastore) {
+	ctx := context.Background()
+	owner := newID()
+	now := time.Now().Unix()
+	created := createSessions(t, store, owner,
+		models.Session{Start: 1000, End: 2000, Ts: now - 3, ProjectID: "project", Tags: []string{"design", "meeting"}},
+		models.Session{Start: 3000, End: 4000, Ts: now, Tags: []string{"design"}},
+		models.Session{Start: 5000, End: 6000, Ts: now - 1, Tags: []string{"code"}},
+		// periods match on start and end, the session runs across the current time so it overlaps today
+		models.Session{Start: now - 60, End: now + 60, Ts: now - 2},
+	)
+
+	var tests = []struct {
+		name     string
+		filter   models.SessionFilter
+		expected []string
+	}{
+		{name: "Test project filter", filter: models.SessionFilter{ProjectID: "project"}, expected: []string{created[0].ID}},
+		{name: "Test any tags filter", filter: models.SessionFilter{AnyTags: []string{"meeting", "code"}}, expected: []string{created[2].ID, created[0].ID}},
+		{name: "Test all tags filter", filter: models.SessionFilter{AllTags: []string{"design", "meeting"}}, expected: []string{created[0].ID}},
+		{name: "Test range overlapping boundaries", filter: models.SessionFilter{From: 1500, To: 3500}, expected: []string{created[1].ID, created[0].ID}},
+		{name: "Test range end is exclusive", filter: models.SessionFilter{From: 4000, To: 5000}, expected: []string{}},
+		{name: "Test day period", filter: models.SessionFilter{Period: "day"}, expected: []string{created[3].ID}},
+		{name: "Test month period within a range", filter: models.SessionFilter{Period: "month", From: 1500}, expected: []string{created[3].ID}},
+		{name: "Test period outside the range", filter: models.SessionFilter{Period: "week", To: 5000}, expected: []string{}},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			sessions, err := store.GetSessions(ctx, owner, testCase.filter)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, sessionIDs(sessions))
+		})
+	}
+
+	_, err := store.GetSessions(ctx, owner, models.SessionFilter{Period: "year"})
+	assert.Error(t, err)
+}
+
+func testSessionsPage(t *testing.T, store db.Datastore) {
+	ctx := context.Background()
+	owner := newID()
+	var sessions []models.Session
+	for _, ts := range []int64{500, 400, 400, 200, 100} {
+		sessions = append(sessions, models.Session{Start: ts, Ts: ts})
+	}
+	created := createSessions(t, store, owner, sessions...)
+	// most recent first, the sessions sharing a ts are ordered by id
+	ordered := []string{created[0].ID, created[1].ID, created[2].ID, created[3].ID, created[4].ID}
+	if ordered[1] < ordered[2] {
+		ordered[1], ordered[2] = ordered[2], ordered[1]
+	}
+
+	page, err := store.GetSessionsPage(ctx, owner, models.SessionFilter{}, models.Page{First: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), page.TotalCount)
+	assert.Equal(t, ordered[:2], sessionIDs(page.Sessions))
+	assert.True(t, page.HasNextPage)
+	assert.False(t, page.HasPreviousPage)
+
+	after := &models.Cursor{Ts: page.Sessions[1].Ts, ID: page.Sessions[1].ID}
+	page, err = store.GetSessionsPage(ctx, owner, models.SessionFilter{}, models.Page{First: 2, After: after})
+	assert.NoError(t, err)
+	assert.Equal(t, ordered[2:4], sessionIDs(page.Sessions))
+	assert.True(t, page.HasNextPage)
+	assert.True(t, page.HasPreviousPage)
+
+	before := &models.Cursor{Ts: page.Sessions[0].Ts, ID: page.Sessions[0].ID}
+	page, err = store.GetSessionsPage(ctx, owner, models.SessionFilter{}, models.Page{Last: 3, Before: before})
+	assert.NoError(t, err)
+	assert.Equal(t, ordered[:2], sessionIDs(page.Sessions))
+	assert.False(t, page.HasPreviousPage)
+	assert.True(t, page.HasNextPage)
+}
+
+func testStreamSessions(t *testing.T, store db.Datastore) {
+	ctx := context.Background()
+	owner := newID()
+	created := createSessions(t, store, owner,
+		models.Session{Start: 300, Ts: 1},
+		models.Session{Start: 100, End: 120, Ts: 2},
+		models.Session{Start: 200, Ts: 3},
+	)
+
+	var ids []string
+	err := store.StreamSessions(ctx, owner, models.SessionFilter{From: 150}, func(s *models.Session) error {
+		ids = append(ids, s.ID)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{created[2].ID, created[0].ID}, ids)
+
+	// iteration stops at the first error
+	stop := errors.New("stop")
+	calls := 0
+	err = store.StreamSessions(ctx, owner, models.SessionFilter{}, func(s *models.Session) error {
+		calls++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, calls)
+}
+
+func testOverlaps(t *testing.T, store db.Datastore) {
+	ctx := context.Background()
+	owner := newID()
+	saved := createSessions(t, store, owner, models.Session{Start: 1000, End: 2000, Ts: 1})
+	createSessions(t, store, newID(), models.Session{Start: 1000, End: 2000, Ts: 1})
+
+	save := func(policy string, session models.Session) (*models.Session, []*models.Session, error) {
+		session.ID = newID()
+		session.Owner = owner
+		session.Duration = session.TotalDuration()
+		conflicts, err := store.CreateSessionWithPolicy(ctx, &session, policy)
+		return &session, conflicts, err
+	}
+
+	// warn saves the session and reports the overlap
+	second, conflicts, err := save(models.OverlapWarn, models.Session{Start: 1500, End: 2500, Ts: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{saved[0].ID}, sessionIDs(conflicts))
+
+	// reject leaves the session unsaved
+	rejected, conflicts, err := save(models.OverlapReject, models.Session{Start: 1800, End: 1900, Ts: 3})
+	assert.Equal(t, db.ErrSessionOverlap, err)
+	assert.ElementsMatch(t, []string{saved[0].ID, second.ID}, sessionIDs(conflicts))
+	_, err = store.GetSession(ctx, rejected.ID, owner)
+	assert.Error(t, err)
+
+	// trim keeps the time before and after the saved sessions
+	trimmed, conflicts, err := save(models.OverlapTrim, models.Session{Start: 500, End: 3000, Ts: 4})
+	assert.NoError(t, err)
+	assert.Len(t, conflicts, 2)
+	got, err := store.GetSession(ctx, trimmed.ID, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Segment{{Start: 500, End: 1000}, {Start: 2500, End: 3000}}, got.Segments)
+	assert.Equal(t, int64(500), got.Start)
+	assert.Equal(t, int64(3000), got.End)
+	assert.Equal(t, int64(1000), got.Duration)
+
+	// a session covered by saved sessions can not be trimmed
+	_, _, err = save(models.OverlapTrim, models.Session{Start: 1200, End: 1300, Ts: 5})
+	assert.Equal(t, db.ErrSessionOverlap, err)
+
+	// touching sessions and time tracked during a break do not overlap
+	_, conflicts, err = save(models.OverlapReject, models.Session{Start: 3000, End: 3100, Ts: 6})
+	assert.NoError(t, err)
+	assert.Empty(t, conflicts)
+	_, _, err = save(models.OverlapReject, models.Session{Start: 4000, End: 4300, Segments: []models.Segment{{Start: 4000, End: 4100}, {Start: 4200, End: 4300}}, Ts: 7})
+	assert.NoError(t, err)
+	_, conflicts, err = save(models.OverlapReject, models.Session{Start: 4100, End: 4200, Ts: 8})
+	assert.NoError(t, err)
+	assert.Empty(t, conflicts)
+
+	overlaps, err := store.GetOverlaps(ctx, owner)
+	assert.NoError(t, err)
+	if assert.Len(t, overlaps, 1) {
+		assert.Equal(t, saved[0].ID, overlaps[0].First.ID)
+		assert.Equal(t, second.ID, overlaps[0].Second.ID)
+		assert.Equal(t, int64(1500), overlaps[0].Start)
+		assert.Equal(t, int64(2000), overlaps[0].End)
+		assert.Equal(t, int64(500), overlaps[0].Duration)
+	}
+
+	overlaps, err = store.GetOverlaps(ctx, newID())
+	assert.NoError(t, err)
+	assert.Empty(t, overlaps)
+}
+
+func testDrafts(t *testing.T, store db.Datastore) {
+	ctx := context.Background()
+	owner := newID()
+	created := createSessions(t, store, owner,
+		models.Session{Start: 200, Ts: 1, Draft: true},
+		models.Session{Start: 100, Ts: 2, Draft: true},
+		models.Session{Start: 300, Ts: 3},
+	)
+
+	// drafts are hidden from the sessions until they are confirmed
+	sessions, err := store.GetSessions(ctx, owner, models.SessionFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{created[2].ID}, sessionIDs(sessions))
+
+	drafts, err := store.GetDraftSessions(ctx, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{created[1].ID, created[0].ID}, sessionIDs(drafts))
+
+	confirmed, conflicts, err := store.ConfirmDrafts(ctx, owner, []string{created[0].ID, created[2].ID}, models.OverlapWarn)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), confirmed)
+	assert.Empty(t, conflicts)
+
+	confirmed, _, err = store.ConfirmDrafts(ctx, newID(), []string{created[1].ID}, models.OverlapWarn)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), confirmed)
+
+	sessions, err = store.GetSessions(ctx, owner, models.SessionFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+
+	// drafts follow the overlap policy when they are confirmed
+	overlapping := createSessions(t, store, owner,
+		models.Session{Start: 1000, End: 2000, Ts: 4},
+		models.Session{Start: 1500, End: 2500, Ts: 5, Draft: true},
+		models.Session{Start: 3000, End: 4000, Ts: 6, Draft: true},
+		models.Session{Start: 1200, End: 1800, Ts: 7, Draft: true},
+	)
+	saved, partly, apart, covered := overlapping[0], overlapping[1], overlapping[2], overlapping[3]
+
+	confirmed, conflicts, err = store.ConfirmDrafts(ctx, owner, []string{partly.ID, apart.ID}, models.OverlapReject)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), confirmed)
+	assert.Equal(t, []string{saved.ID}, sessionIDs(conflicts))
+	drafts, err = store.GetDraftSessions(ctx, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{created[1].ID, covered.ID, partly.ID}, sessionIDs(drafts))
+
+	confirmed, _, err = store.ConfirmDrafts(ctx, owner, []string{partly.ID, covered.ID}, models.OverlapTrim)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), confirmed)
+	trimmed, err := store.GetSession(ctx, partly.ID, owner)
+	assert.NoError(t, err)
+	assert.False(t, trimmed.Draft)
+	assert.Equal(t, int64(2000), trimmed.Start)
+	assert.Equal(t, int64(500), trimmed.Duration)
+	drafts, err = store.GetDraftSessions(ctx, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{created[1].ID, covered.ID}, sessionIDs(drafts))
+}
+
+func testTimer(t *testing.T, store db.Datastore) {
+	ctx := context.Background()
+	owner := newID()
+
+	_, err := store.GetRunningTimer(ctx, owner)
+	assert.Equal(t, db.ErrNoRunningTimer, err)
+
+	start := time.Now().Add(-time.Hour).Unix()
+	timer := models.Session{
+		ID:       newID(),
+		Owner:    owner,
+		Start:    start,
+		Segments: []models.Segment{{Start: start}},
+		Running:  true,
+		Ts:       start,
+	}
+	_, err = store.StartTimer(ctx, &timer)
+	assert.NoError(t, err)
+
+	// only one timer can run per user
+	second := timer
+	second.ID = newID()
+	_, err = store.StartTimer(ctx, &second)
+	assert.Equal(t, db.ErrTimerRunning, err)
+
+	// running timers are excluded from the saved sessions
+	sessions, err := store.GetSessions(ctx, owner, models.SessionFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 0)
+
+	pausedAt := start + 600
+	paused, err := store.PauseTimer(ctx, owner, pausedAt)
+	assert.NoError(t, err)
+	assert.True(t, paused.Paused)
+	assert.Equal(t, int64(600), paused.Duration)
+
+	_, err = store.PauseTimer(ctx, owner, pausedAt)
+	assert.Equal(t, db.ErrTimerPaused, err)
+
+	resumedAt := pausedAt + 300
+	_, err = store.ResumeTimer(ctx, owner, resumedAt)
+	assert.NoError(t, err)
+	_, err = store.ResumeTimer(ctx, owner, resumedAt)
+	assert.Equal(t, db.ErrTimerNotPaused, err)
+
+	end := start + 3600
+	stopped, err := store.StopTimer(ctx, owner, end)
+	assert.NoError(t, err)
+	assert.False(t, stopped.Running)
+	assert.Equal(t, int64(3300), stopped.Duration)
+	assert.Equal(t, []models.Segment{{Start: start, End: pausedAt}, {Start: resumedAt, End: end}}, stopped.Segments)
+
+	session, err := store.GetSession(ctx, timer.ID, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, stopped, session)
+
+	_, err = store.StopTimer(ctx, owner, end)
+	assert.Equal(t, db.ErrNoRunningTimer, err)
+}
+
+func testProjectsAndClients(t *testing.T, store db.Datastore) {
+	ctx := context.Background()
+	owner := newID()
+	client := models.Client{ID: newID(), Owner: owner, Name: "Acme"}
+	_, err := store.CreateClient(ctx, &client)
+	assert.NoError(t, err)
+
+	projects := []models.Project{
+		{ID: newID(), Owner: owner, ClientID: client.ID, Name: "Website"},
+		{ID: newID(), Owner: owner, Name: "Blog"},
+	}
+	for i := range projects {
+		_, err := store.CreateProject(ctx, &projects[i])
+		assert.NoError(t, err)
+	}
+
+	got, err := store.GetProject(ctx, projects[0].ID, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, projects[0], *got)
+	_, err = store.GetProject(ctx, projects[0].ID, newID())
+	assert.Error(t, err)
+
+	// projects are sorted by name and archived ones are hidden unless asked for
+	archived := true
+	assert.NoError(t, store.UpdateProject(ctx, projects[0].ID, models.ProjectInfo{Archived: &archived}))
+	list, err := store.GetProjects(ctx, owner, false)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	list, err = store.GetProjects(ctx, owner, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Blog", "Website"}, []string{list[0].Name, list[1].Name})
+
+	// moving a project to a client keeps the clients it had before
+	history := []models.ClientLink{{Until: 500}}
+	assert.NoError(t, store.UpdateProject(ctx, projects[1].ID, models.ProjectInfo{ClientID: &client.ID, ClientHistory: &history}))
+	moved, err := store.GetProject(ctx, projects[1].ID, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, client.ID, moved.ClientID)
+	assert.Equal(t, history, moved.ClientHistory)
+	noClient := ""
+	assert.NoError(t, store.UpdateProject(ctx, projects[1].ID, models.ProjectInfo{ClientID: &noClient}))
+
+	// projects referenced by sessions and clients referenced by projects can not be deleted
+	createSessions(t, store, owner, models.Session{Start: 100, ProjectID: projects[1].ID})
+	assert.Equal(t, db.ErrProjectInUse, store.DeleteProject(ctx, projects[1].ID))
+	kept, err := store.GetProject(ctx, projects[1].ID, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, projects[1].Name, kept.Name)
+	assert.Equal(t, db.ErrClientInUse, store.DeleteClient(ctx, client.ID))
+
+	assert.NoError(t, store.DeleteProject(ctx, projects[0].ID))
+	assert.NoError(t, store.DeleteClient(ctx, client.ID))
+	_, err = store.GetClient(ctx, client.ID, owner)
+	assert.Error(t, err)
+
+	name := "Acme Inc"
+	other := models.Client{ID: newID(), Owner: owner, Name: "Other"}
+	_, err = store.CreateClient(ctx, &other)
+	assert.NoError(t, err)
+	assert.NoError(t, store.UpdateClient(ctx, other.ID, models.ClientInfo{Name: &name, Archived: &archived}))
+	clients, err := store.GetClients(ctx, owner, false)
+	assert.NoError(t, err)
+	assert.Empty(t, clients)
+	clients, err = store.GetClients(ctx, owner, true)
+	assert.NoError(t, err)
+	assert.Equal(t, name, clients[0].Name)
+}
+
+func testRates(t *testing.T, store db.Datastore) {
+	ctx := context.Background()
+	owner := newID()
+	for _, from := range []int64{100, 300, 200} {
+		_, err := store.CreateRate(ctx, &models.Rate{ID: newID(), Owner: owner, Scope: models.RateScopeUser, HourlyRate: 10, EffectiveFrom: from})
+		assert.NoError(t, err)
+	}
+
+	// the newest rate comes first
+	rates, err := store.GetRates(ctx, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{300, 200, 100}, []int64{rates[0].EffectiveFrom, rates[1].EffectiveFrom, rates[2].EffectiveFrom})
+}
+
+func testTags(t *testing.T, store db.Datastore) {
+	ctx := context.Background()
+	owner := newID()
+	createSessions(t, store, owner,
+		models.Session{Start: 100, Tags: []string{"design", "meeting"}},
+		models.Session{Start: 200, Tags: []string{"design"}},
+		models.Session{Start: 300, Tags: []string{"code", "review"}},
+	)
+
+	tags, err := store.GetTags(ctx, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.TagCount{
+		{Name: "design", Count: 2},
+		{Name: "code", Count: 1},
+		{Name: "meeting", Count: 1},
+		{Name: "review", Count: 1},
+	}, tags)
+
+	modified, err := store.MergeTags(ctx, owner, []string{"meeting", "review"}, "design")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), modified)
+
+	tags, err = store.GetTags(ctx, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.TagCount{
+		{Name: "design", Count: 3},
+		{Name: "code", Count: 1},
+	}, tags)
+}
+
+func testReport(t *testing.T, store db.Datastore) {
+	ctx := context.Background()
+	owner := newID()
+	_, err := store.CreateRate(ctx, &models.Rate{ID: newID(), Owner: owner, Scope: models.RateScopeUser, HourlyRate: 36})
+	assert.NoError(t, err)
+
+	// 2021-03-01 and 2021-03-02 at 23:30 UTC, which is the next day in Lagos
+	createSessions(t, store, owner,
+		models.Session{Start: 1614641400, End: 1614645000, Duration: 3600, Billable: true, Tags: []string{"design", "code"}},
+		models.Session{Start: 1614727800, End: 1614731400, Duration: 3600, Billable: true, Tags: []string{"design"}},
+		models.Session{Start: 1614727800, End: 1614731400, Duration: 3600, Draft: true},
+	)
+
+	loc, _ := time.LoadLocation("Africa/Lagos")
+	rows, err := store.GetReport(ctx, owner, models.ReportQuery{
+		From:     1614556800,
+		To:       1617235200,
+		GroupBy:  []string{models.ReportGroupDay, models.ReportGroupTag},
+		Location: loc,
+	})
+	assert.NoError(t, err)
+
+	totals := map[string]*models.ReportRow{}
+	for _, row := range rows {
+		key := ""
+		for _, k := range row.Keys {
+			key += "/" + k
+		}
+		totals[key] = row
+	}
+	assert.Len(t, rows, 6)
+	assert.Equal(t, &models.ReportRow{Keys: []string{}, Duration: 7200, SessionCount: 2, Amount: 72}, totals[""])
+	assert.Equal(t, int64(1), totals["/2021-03-02"].SessionCount)
+	assert.Equal(t, int64(1), totals["/2021-03-03"].SessionCount)
+	assert.Equal(t, int64(3600), totals["/2021-03-02/code"].Duration)
+	assert.Equal(t, 36.0, totals["/2021-03-02/design"].Amount)
+}
+
+func testReportMovedProject(t *testing.T, store db.Datastore) {
+	ctx := context.Background()
+	owner := newID()
+	acme, globex := newID(), newID()
+	for _, rate := range []models.Rate{
+		{ID: newID(), Owner: owner, Scope: models.RateScopeClient, ScopeID: acme, HourlyRate: 10},
+		{ID: newID(), Owner: owner, Scope: models.RateScopeClient, ScopeID: globex, HourlyRate: 20},
+	} {
+		rate := rate
+		_, err := store.CreateRate(ctx, &rate)
+		assert.NoError(t, err)
+	}
+	// the project moved from acme to globex at 2000
+	project := models.Project{ID: newID(), Owner: owner, ClientID: globex, ClientHistory: []models.ClientLink{{ClientID: acme, Until: 2000}}}
+	_, err := store.CreateProject(ctx, &project)
+	assert.NoError(t, err)
+	createSessions(t, store, owner,
+		models.Session{Start: 1000, End: 4600, Duration: 3600, Billable: true, ProjectID: project.ID},
+		models.Session{Start: 3000, End: 6600, Duration: 3600, Billable: true, ProjectID: project.ID},
+	)
+
+	rows, err := store.GetReport(ctx, owner, models.ReportQuery{From: 0, To: 10000, GroupBy: []string{models.ReportGroupClient}})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []*models.ReportRow{
+		{Keys: []string{}, Duration: 7200, SessionCount: 2, Amount: 30},
+		{Keys: []string{acme}, Duration: 3600, SessionCount: 1, Amount: 10},
+		{Keys: []string{globex}, Duration: 3600, SessionCount: 1, Amount: 20},
+	}, rows)
+}
@@ -0,0 +1,32 @@
+package db
+
+import "errors"
+
+var (
+	// ErrNotFound is returned by stores without a native not found error when an entity does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrEmailExists is returned when a user is created with an email that already belongs to another user
+	ErrEmailExists = errors.New("email already exists")
+	// ErrTimerRunning is returned when a user tries to start a timer while another is still running
+	ErrTimerRunning = errors.New("a timer is already running")
+	// ErrNoRunningTimer is returned when a user has no running timer
+	ErrNoRunningTimer = errors.New("no running timer")
+	// ErrTimerPaused is returned when pausing a timer that is already paused
+	ErrTimerPaused = errors.New("timer is already paused")
+	// ErrTimerNotPaused is returned when resuming a timer that is not paused
+	ErrTimerNotPaused = errors.New("timer is not paused")
+	// ErrSessionOverlap is returned when a session overlaps saved sessions and the policy of the user forbids it
+	ErrSessionOverlap = errors.New("session overlaps saved sessions")
+	// ErrProjectInUse is returned when deleting a project that sessions still reference
+	ErrProjectInUse = errors.New("project is referenced by sessions")
+	// ErrClientInUse is returned when deleting a client that projects still reference
+	ErrClientInUse = errors.New("client is referenced by projects")
+	// ErrTokenReused is returned when a refresh token is rotated a second time, its family is revoked
+	ErrTokenReused = errors.New("refresh token already used")
+	// ErrTokenRevoked is returned when rotating a refresh token of a revoked family
+	ErrTokenRevoked = errors.New("refresh token revoked")
+	// ErrTokenUsed is returned when a one time token is redeemed a second time
+	ErrTokenUsed = errors.New("token already used")
+	// ErrTokenExpired is returned when a one time token is redeemed after it expired
+	ErrTokenExpired = errors.New("token expired")
+)
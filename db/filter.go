@@ -0,0 +1,96 @@
+package db
+
+import (
+	"sort"
+	"time"
+
+	"github.com/victor-nach/time-tracker/lib/period"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+// ApplyPeriod narrows the From and To range of the filter to the current day, week or month
+// of its period and clears the period, so stores only have to support ranges
+func ApplyPeriod(filter models.SessionFilter, now time.Time) (models.SessionFilter, error) {
+	if filter.Period == "" {
+		return filter, nil
+	}
+	start, end, err := period.Bounds(filter.Period, now, filter.Location, filter.WeekStart)
+	if err != nil {
+		return filter, err
+	}
+
+	if filter.From < start.Unix() {
+		filter.From = start.Unix()
+	}
+	if filter.To == 0 || filter.To > end.Unix() {
+		filter.To = end.Unix()
+	}
+	filter.Period = ""
+	return filter, nil
+}
+
+// MatchSessions returns a predicate with the semantics of the sessions queries for stores
+// that filter sessions in Go, the period boundaries are worked out once from now
+func MatchSessions(owner string, filter models.SessionFilter, now time.Time) (func(*models.Session) bool, error) {
+	filter, err := ApplyPeriod(filter, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(s *models.Session) bool {
+		// running timers are only visible through GetRunningTimer and drafts through GetDraftSessions
+		if s.Owner != owner || s.Running || s.Draft {
+			return false
+		}
+		if filter.ProjectID != "" && s.ProjectID != filter.ProjectID {
+			return false
+		}
+		if len(filter.AnyTags) > 0 && !hasAnyTag(s.Tags, filter.AnyTags) {
+			return false
+		}
+		for _, tag := range filter.AllTags {
+			if !hasAnyTag(s.Tags, []string{tag}) {
+				return false
+			}
+		}
+		// sessions overlapping the range boundaries are included
+		if filter.To != 0 && s.Start >= filter.To {
+			return false
+		}
+		if filter.From != 0 && s.End <= filter.From {
+			return false
+		}
+		return true
+	}, nil
+}
+
+func hasAnyTag(tags, wanted []string) bool {
+	for _, tag := range tags {
+		for _, w := range wanted {
+			if tag == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SortMostRecent sorts sessions in the most recent first order of ts and id used for paging
+func SortMostRecent(sessions []*models.Session) {
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].Ts != sessions[j].Ts {
+			return sessions[i].Ts > sessions[j].Ts
+		}
+		return sessions[i].ID > sessions[j].ID
+	})
+}
+
+// SortByStart sorts sessions in order of start and id
+func SortByStart(sessions []*models.Session) {
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].Start != sessions[j].Start {
+			return sessions[i].Start < sessions[j].Start
+		}
+		return sessions[i].ID < sessions[j].ID
+	})
+}
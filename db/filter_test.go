@@ -0,0 +1,93 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-nach/time-tracker/lib/period"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+func TestApplyPeriod(t *testing.T) {
+	lagos, err := time.LoadLocation("Africa/Lagos")
+	assert.NoError(t, err)
+	// wednesday 16 june 2021, 00:30 in Lagos and still tuesday in UTC
+	now := time.Date(2021, 6, 15, 23, 30, 0, 0, time.UTC)
+	dayStart := time.Date(2021, 6, 16, 0, 0, 0, 0, lagos).Unix()
+	dayEnd := time.Date(2021, 6, 17, 0, 0, 0, 0, lagos).Unix()
+
+	var tests = []struct {
+		name     string
+		filter   models.SessionFilter
+		expected models.SessionFilter
+	}{
+		{
+			name:     "Filter without a period is unchanged",
+			filter:   models.SessionFilter{ProjectID: "project", From: 100},
+			expected: models.SessionFilter{ProjectID: "project", From: 100},
+		},
+		{
+			name:     "Period becomes a range in the location of the user",
+			filter:   models.SessionFilter{Period: period.Day, Location: lagos},
+			expected: models.SessionFilter{From: dayStart, To: dayEnd, Location: lagos},
+		},
+		{
+			name:     "Period without a location uses UTC",
+			filter:   models.SessionFilter{Period: period.Day},
+			expected: models.SessionFilter{From: time.Date(2021, 6, 15, 0, 0, 0, 0, time.UTC).Unix(), To: time.Date(2021, 6, 16, 0, 0, 0, 0, time.UTC).Unix()},
+		},
+		{
+			name:     "Period narrows a wider range",
+			filter:   models.SessionFilter{Period: period.Day, Location: lagos, From: 100, To: dayEnd + 3600},
+			expected: models.SessionFilter{From: dayStart, To: dayEnd, Location: lagos},
+		},
+		{
+			name:     "Range narrows the period",
+			filter:   models.SessionFilter{Period: period.Day, Location: lagos, From: dayStart + 60, To: dayEnd - 60},
+			expected: models.SessionFilter{From: dayStart + 60, To: dayEnd - 60, Location: lagos},
+		},
+		{
+			name:     "Month starts on the first day",
+			filter:   models.SessionFilter{Period: period.Month, Location: lagos},
+			expected: models.SessionFilter{From: time.Date(2021, 6, 1, 0, 0, 0, 0, lagos).Unix(), To: time.Date(2021, 7, 1, 0, 0, 0, 0, lagos).Unix(), Location: lagos},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			filter, err := ApplyPeriod(testCase.filter, now)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, filter)
+		})
+	}
+
+	_, err = ApplyPeriod(models.SessionFilter{Period: "year"}, now)
+	assert.Equal(t, period.ErrUnknownPeriod, err)
+}
+
+func TestMatchSessions_Period(t *testing.T) {
+	now := time.Date(2021, 6, 16, 12, 0, 0, 0, time.UTC)
+	dayStart := time.Date(2021, 6, 16, 0, 0, 0, 0, time.UTC).Unix()
+	match, err := MatchSessions("owner", models.SessionFilter{Period: period.Day}, now)
+	assert.NoError(t, err)
+
+	var tests = []struct {
+		name     string
+		session  models.Session
+		expected bool
+	}{
+		{name: "Session during the day", session: models.Session{Start: dayStart + 3600, End: dayStart + 7200}, expected: true},
+		{name: "Session from the day before ending in the day", session: models.Session{Start: dayStart - 3600, End: dayStart + 60}, expected: true},
+		{name: "Session ending at midnight", session: models.Session{Start: dayStart - 3600, End: dayStart}, expected: false},
+		{name: "Session of the day before saved today", session: models.Session{Start: dayStart - 7200, End: dayStart - 3600, Ts: now.Unix()}, expected: false},
+		{name: "Session of the next day", session: models.Session{Start: dayStart + 24*3600, End: dayStart + 25*3600}, expected: false},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.session.Owner = "owner"
+			assert.Equal(t, testCase.expected, match(&testCase.session))
+		})
+	}
+}
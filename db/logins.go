@@ -0,0 +1,30 @@
+package db
+
+import (
+	"sort"
+
+	"github.com/victor-nach/time-tracker/models"
+)
+
+// SortMostRecentlyUsed orders login sessions by last use, most recent first, ties by id
+func SortMostRecentlyUsed(sessions []*models.LoginSession) {
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].LastUsedAt != sessions[j].LastUsedAt {
+			return sessions[i].LastUsedAt > sessions[j].LastUsedAt
+		}
+		return sessions[i].ID > sessions[j].ID
+	})
+}
+
+// ApplyLoginSessionInfo sets the fields of info that are not nil on the session
+func ApplyLoginSessionInfo(session *models.LoginSession, info models.LoginSessionInfo) {
+	if info.UserAgent != nil {
+		session.UserAgent = *info.UserAgent
+	}
+	if info.IP != nil {
+		session.IP = *info.IP
+	}
+	if info.LastUsedAt != nil {
+		session.LastUsedAt = *info.LastUsedAt
+	}
+}
@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+func (m *memoryStore) CreateLoginSession(ctx context.Context, session *models.LoginSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.logins[session.ID] = *session
+	return nil
+}
+
+func (m *memoryStore) GetLoginSession(ctx context.Context, id, owner string) (*models.LoginSession, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	session, ok := m.logins[id]
+	if !ok || session.Owner != owner {
+		return nil, db.ErrNotFound
+	}
+	return &session, nil
+}
+
+// GetLoginSessions returns the login sessions of the owner, most recently used first
+func (m *memoryStore) GetLoginSessions(ctx context.Context, owner string) ([]*models.LoginSession, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	sessions := []*models.LoginSession{}
+	for _, s := range m.logins {
+		if s.Owner == owner {
+			s := s
+			sessions = append(sessions, &s)
+		}
+	}
+	db.SortMostRecentlyUsed(sessions)
+	return sessions, nil
+}
+
+func (m *memoryStore) UpdateLoginSession(ctx context.Context, id string, info models.LoginSessionInfo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.logins[id]
+	if !ok {
+		return nil
+	}
+	db.ApplyLoginSessionInfo(&session, info)
+	m.logins[id] = session
+	return nil
+}
+
+func (m *memoryStore) DeleteLoginSession(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.logins, id)
+	return nil
+}
+
+func (m *memoryStore) DeleteLoginSessions(ctx context.Context, owner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, s := range m.logins {
+		if s.Owner == owner {
+			delete(m.logins, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,355 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/lib/clock"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+// memoryStore keeps every entity in maps keyed by id, it is safe for concurrent use.
+// Entities are copied on the way in and out so callers never share memory with the store
+type memoryStore struct {
+	clock    clock.Clock
+	mu       sync.RWMutex
+	users    map[string]models.User
+	sessions map[string]models.Session
+	projects map[string]models.Project
+	clients  map[string]models.Client
+	rates    map[string]models.Rate
+	tokens   map[string]models.RefreshToken
+	logins   map[string]models.LoginSession
+	onetime  map[string]models.OneTimeToken
+}
+
+// ensure memoryStore implements the datastore interface
+var _ db.Datastore = &memoryStore{}
+
+//New returns an empty in-memory store, its data is lost when the process exits
+func New() db.Datastore {
+	return &memoryStore{
+		clock:    clock.New(),
+		users:    map[string]models.User{},
+		sessions: map[string]models.Session{},
+		projects: map[string]models.Project{},
+		clients:  map[string]models.Client{},
+		rates:    map[string]models.Rate{},
+		tokens:   map[string]models.RefreshToken{},
+		logins:   map[string]models.LoginSession{},
+		onetime:  map[string]models.OneTimeToken{},
+	}
+}
+
+func (m *memoryStore) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, u := range m.users {
+		if u.Email == user.Email {
+			return nil, db.ErrEmailExists
+		}
+	}
+	m.users[user.ID] = *user
+	return user, nil
+}
+
+func (m *memoryStore) GetUser(ctx context.Context, id string) (*models.User, error) {
+	return m.findUser(func(u *models.User) bool { return u.ID == id })
+}
+
+func (m *memoryStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	return m.findUser(func(u *models.User) bool { return u.Email == email })
+}
+
+// GetUserByCalendarToken returns the user owning the hashed calendar feed token
+func (m *memoryStore) GetUserByCalendarToken(ctx context.Context, token string) (*models.User, error) {
+	if token == "" {
+		return nil, db.ErrNotFound
+	}
+	return m.findUser(func(u *models.User) bool { return u.CalendarToken == token })
+}
+
+func (m *memoryStore) findUser(match func(*models.User) bool) (*models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, u := range m.users {
+		if match(&u) {
+			return &u, nil
+		}
+	}
+	return nil, db.ErrNotFound
+}
+
+func (m *memoryStore) UpdateUser(ctx context.Context, id string, info models.UserInfo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	user, ok := m.users[id]
+	if !ok {
+		return nil
+	}
+
+	if info.Name != nil {
+		user.Name = *info.Name
+	}
+	if info.TimeZone != nil {
+		user.TimeZone = *info.TimeZone
+	}
+	if info.WeekStart != nil {
+		user.WeekStart = *info.WeekStart
+	}
+	if info.CalendarToken != nil {
+		user.CalendarToken = *info.CalendarToken
+	}
+	if info.OverlapPolicy != nil {
+		user.OverlapPolicy = *info.OverlapPolicy
+	}
+	if info.Password != nil {
+		user.Password = *info.Password
+	}
+	if info.EmailVerified != nil {
+		user.EmailVerified = *info.EmailVerified
+	}
+	m.users[id] = user
+	return nil
+}
+
+func (m *memoryStore) GetTokenGeneration(ctx context.Context, id string) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	user, ok := m.users[id]
+	if !ok {
+		return 0, db.ErrNotFound
+	}
+	return user.TokenGeneration, nil
+}
+
+func (m *memoryStore) IncrementTokenGeneration(ctx context.Context, id string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	user, ok := m.users[id]
+	if !ok {
+		return 0, db.ErrNotFound
+	}
+	user.TokenGeneration++
+	m.users[id] = user
+	return user.TokenGeneration, nil
+}
+
+func (m *memoryStore) GetSession(ctx context.Context, id, owner string) (*models.Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	session, ok := m.sessions[id]
+	if !ok || session.Owner != owner {
+		return nil, db.ErrNotFound
+	}
+	return copySession(session), nil
+}
+
+func (m *memoryStore) GetSessions(ctx context.Context, owner string, filter models.SessionFilter) ([]*models.Session, error) {
+	match, err := db.MatchSessions(owner, filter, m.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	sessions := m.filterSessions(match)
+	db.SortMostRecent(sessions)
+	return sessions, nil
+}
+
+// GetSessionsPage returns a window of the most recent first sessions, ordered by ts and id
+func (m *memoryStore) GetSessionsPage(ctx context.Context, owner string, filter models.SessionFilter, page models.Page) (*models.SessionPage, error) {
+	sessions, err := m.GetSessions(ctx, owner, filter)
+	if err != nil {
+		return nil, err
+	}
+	return db.PageSessions(sessions, page), nil
+}
+
+// StreamSessions calls fn for every session of the owner that passes the filter in order of start,
+// iteration stops at the first error of fn
+func (m *memoryStore) StreamSessions(ctx context.Context, owner string, filter models.SessionFilter, fn func(*models.Session) error) error {
+	match, err := db.MatchSessions(owner, filter, m.clock.Now())
+	if err != nil {
+		return err
+	}
+	sessions := m.filterSessions(match)
+	db.SortByStart(sessions)
+	for _, s := range sessions {
+		// a cancelled export stops early, the other operations are quick enough to finish
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filterSessions returns copies of the sessions that match
+func (m *memoryStore) filterSessions(match func(*models.Session) bool) []*models.Session {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.findSessions(match)
+}
+
+// findSessions returns copies of the sessions that match, the caller holds the lock
+func (m *memoryStore) findSessions(match func(*models.Session) bool) []*models.Session {
+	sessions := []*models.Session{}
+	for _, s := range m.sessions {
+		if match(&s) {
+			sessions = append(sessions, copySession(s))
+		}
+	}
+	return sessions
+}
+
+func (m *memoryStore) CreateSession(ctx context.Context, session *models.Session) (*models.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[session.ID] = *copySession(*session)
+	return session, nil
+}
+
+// CreateSessionWithPolicy checks for overlaps and saves the session under the same lock
+func (m *memoryStore) CreateSessionWithPolicy(ctx context.Context, session *models.Session, policy string) ([]*models.Session, error) {
+	match, err := db.MatchSessions(session.Owner, db.OverlapRange(session), m.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	conflicts, err := db.ApplyOverlapPolicy(session, m.findSessions(match), policy)
+	if err != nil {
+		return conflicts, err
+	}
+	m.sessions[session.ID] = *copySession(*session)
+	return conflicts, nil
+}
+
+// GetOverlaps returns every pair of saved sessions of the owner that tracked the same time
+func (m *memoryStore) GetOverlaps(ctx context.Context, owner string) ([]*models.Overlap, error) {
+	match, err := db.MatchSessions(owner, models.SessionFilter{}, m.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	sessions := m.filterSessions(match)
+	db.SortByStart(sessions)
+	return db.FindOverlaps(sessions), nil
+}
+
+// CreateSessions inserts a batch of sessions
+func (m *memoryStore) CreateSessions(ctx context.Context, sessions []*models.Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range sessions {
+		m.sessions[s.ID] = *copySession(*s)
+	}
+	return nil
+}
+
+// GetImportedHashes returns the hashes that already belong to imported sessions of the owner
+func (m *memoryStore) GetImportedHashes(ctx context.Context, owner string, hashes []string) ([]string, error) {
+	wanted := map[string]bool{}
+	for _, hash := range hashes {
+		wanted[hash] = true
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	found := map[string]bool{}
+	for _, s := range m.sessions {
+		if s.Owner == owner && wanted[s.ImportHash] {
+			found[s.ImportHash] = true
+		}
+	}
+
+	imported := make([]string, 0, len(found))
+	for hash := range found {
+		imported = append(imported, hash)
+	}
+	sort.Strings(imported)
+	return imported, nil
+}
+
+// GetDraftSessions returns the unconfirmed sessions of the owner in order of start
+func (m *memoryStore) GetDraftSessions(ctx context.Context, owner string) ([]*models.Session, error) {
+	drafts := m.filterSessions(func(s *models.Session) bool { return s.Owner == owner && s.Draft })
+	db.SortByStart(drafts)
+	return drafts, nil
+}
+
+// ConfirmDrafts checks every draft for overlaps and confirms it under the same lock
+func (m *memoryStore) ConfirmDrafts(ctx context.Context, owner string, ids []string, policy string) (int64, []*models.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var confirmed int64
+	conflicts := []*models.Session{}
+	for _, id := range ids {
+		s, ok := m.sessions[id]
+		if !ok || s.Owner != owner || !s.Draft {
+			continue
+		}
+		draft := copySession(s)
+		match, err := db.MatchSessions(owner, db.OverlapRange(draft), m.clock.Now())
+		if err != nil {
+			return 0, nil, err
+		}
+		overlaps, err := db.ApplyOverlapPolicy(draft, m.findSessions(match), policy)
+		conflicts = append(conflicts, overlaps...)
+		if err == db.ErrSessionOverlap {
+			continue
+		}
+		draft.Draft = false
+		m.sessions[id] = *draft
+		confirmed++
+	}
+	return confirmed, conflicts, nil
+}
+
+func (m *memoryStore) UpdateSession(ctx context.Context, id string, info models.SessionInfo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.sessions[id]
+	if !ok {
+		return nil
+	}
+
+	if info.Title != nil {
+		session.Title = *info.Title
+	}
+	if info.Description != nil {
+		session.Description = *info.Description
+	}
+	if info.ProjectID != nil {
+		session.ProjectID = *info.ProjectID
+	}
+	if info.Billable != nil {
+		session.Billable = *info.Billable
+	}
+	if info.Tags != nil {
+		session.Tags = append([]string{}, info.Tags...)
+	}
+	m.sessions[id] = session
+	return nil
+}
+
+func (m *memoryStore) DeleteSession(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, id)
+	return nil
+}
+
+// copySession returns a copy of the session that shares no slices with it
+func copySession(s models.Session) *models.Session {
+	if s.Tags != nil {
+		s.Tags = append([]string{}, s.Tags...)
+	}
+	if s.Segments != nil {
+		s.Segments = append([]models.Segment{}, s.Segments...)
+	}
+	return &s
+}
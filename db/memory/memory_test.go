@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/db/dbtest"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+func TestMemoryStore(t *testing.T) {
+	dbtest.RunSuite(t, New())
+}
+
+func TestMemoryStore_ConcurrentTimers(t *testing.T) {
+	ctx := context.Background()
+	store := New()
+
+	// only one of many concurrent timers of a user can start
+	var wg sync.WaitGroup
+	var started int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			timer := &models.Session{ID: fmt.Sprintf("timer%d", i), Owner: "owner", Running: true}
+			if _, err := store.StartTimer(ctx, timer); err == nil {
+				atomic.AddInt32(&started, 1)
+			} else {
+				assert.Equal(t, db.ErrTimerRunning, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), started)
+}
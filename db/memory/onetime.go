@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+func (m *memoryStore) CreateOneTimeToken(ctx context.Context, token *models.OneTimeToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onetime[token.ID] = *token
+	return nil
+}
+
+func (m *memoryStore) GetOneTimeToken(ctx context.Context, id string) (*models.OneTimeToken, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	token, ok := m.onetime[id]
+	if !ok {
+		return nil, db.ErrNotFound
+	}
+	return &token, nil
+}
+
+// UseOneTimeToken checks and marks the token used under the lock
+func (m *memoryStore) UseOneTimeToken(ctx context.Context, id, purpose string, now int64) (*models.OneTimeToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	token, ok := m.onetime[id]
+	if !ok {
+		return nil, db.ErrNotFound
+	}
+	if err := db.CheckOneTimeToken(&token, purpose, now); err != nil {
+		return nil, err
+	}
+	token.Used = true
+	m.onetime[id] = token
+	return &token, nil
+}
+
+func (m *memoryStore) DeleteOneTimeTokens(ctx context.Context, owner, purpose string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, token := range m.onetime {
+		if token.Owner == owner && token.Purpose == purpose {
+			delete(m.onetime, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+func (m *memoryStore) CreateProject(ctx context.Context, project *models.Project) (*models.Project, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.projects[project.ID] = *project
+	return project, nil
+}
+
+func (m *memoryStore) GetProject(ctx context.Context, id, owner string) (*models.Project, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	project, ok := m.projects[id]
+	if !ok || project.Owner != owner {
+		return nil, db.ErrNotFound
+	}
+	return &project, nil
+}
+
+func (m *memoryStore) GetProjects(ctx context.Context, owner string, includeArchived bool) ([]*models.Project, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	projects := []*models.Project{}
+	for _, p := range m.projects {
+		if p.Owner == owner && (includeArchived || !p.Archived) {
+			p := p
+			projects = append(projects, &p)
+		}
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Name < projects[j].Name })
+	return projects, nil
+}
+
+func (m *memoryStore) UpdateProject(ctx context.Context, id string, info models.ProjectInfo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	project, ok := m.projects[id]
+	if !ok {
+		return nil
+	}
+
+	if info.ClientID != nil {
+		project.ClientID = *info.ClientID
+	}
+	if info.ClientHistory != nil {
+		project.ClientHistory = *info.ClientHistory
+	}
+	if info.Name != nil {
+		project.Name = *info.Name
+	}
+	if info.Description != nil {
+		project.Description = *info.Description
+	}
+	if info.Archived != nil {
+		project.Archived = *info.Archived
+	}
+	m.projects[id] = project
+	return nil
+}
+
+func (m *memoryStore) DeleteProject(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.sessions {
+		if s.ProjectID == id {
+			return db.ErrProjectInUse
+		}
+	}
+	delete(m.projects, id)
+	return nil
+}
+
+func (m *memoryStore) CreateClient(ctx context.Context, client *models.Client) (*models.Client, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clients[client.ID] = *client
+	return client, nil
+}
+
+func (m *memoryStore) GetClient(ctx context.Context, id, owner string) (*models.Client, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	client, ok := m.clients[id]
+	if !ok || client.Owner != owner {
+		return nil, db.ErrNotFound
+	}
+	return &client, nil
+}
+
+func (m *memoryStore) GetClients(ctx context.Context, owner string, includeArchived bool) ([]*models.Client, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	clients := []*models.Client{}
+	for _, c := range m.clients {
+		if c.Owner == owner && (includeArchived || !c.Archived) {
+			c := c
+			clients = append(clients, &c)
+		}
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].Name < clients[j].Name })
+	return clients, nil
+}
+
+func (m *memoryStore) UpdateClient(ctx context.Context, id string, info models.ClientInfo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	client, ok := m.clients[id]
+	if !ok {
+		return nil
+	}
+
+	if info.Name != nil {
+		client.Name = *info.Name
+	}
+	if info.Archived != nil {
+		client.Archived = *info.Archived
+	}
+	m.clients[id] = client
+	return nil
+}
+
+func (m *memoryStore) DeleteClient(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, p := range m.projects {
+		if p.ClientID == id {
+			return db.ErrClientInUse
+		}
+	}
+	delete(m.clients, id)
+	return nil
+}
+
+func (m *memoryStore) CreateRate(ctx context.Context, rate *models.Rate) (*models.Rate, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rates[rate.ID] = *rate
+	return rate, nil
+}
+
+func (m *memoryStore) GetRates(ctx context.Context, owner string) ([]*models.Rate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	rates := []*models.Rate{}
+	for _, r := range m.rates {
+		if r.Owner == owner {
+			r := r
+			rates = append(rates, &r)
+		}
+	}
+	sort.Slice(rates, func(i, j int) bool { return rates[i].EffectiveFrom > rates[j].EffectiveFrom })
+	return rates, nil
+}
@@ -0,0 +1,25 @@
+package memory
+
+import (
+	"context"
+	"github.com/victor-nach/time-tracker/lib/billing"
+	"github.com/victor-nach/time-tracker/lib/report"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+// GetReport aggregates the sessions that start in the range of the query
+func (m *memoryStore) GetReport(ctx context.Context, owner string, query models.ReportQuery) ([]*models.ReportRow, error) {
+	rates, err := m.GetRates(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	projects, err := m.GetProjects(ctx, owner, true)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := m.filterSessions(func(s *models.Session) bool {
+		return s.Owner == owner && !s.Running && !s.Draft && s.Start >= query.From && s.Start < query.To
+	})
+	return report.Rows(sessions, query, billing.NewCalculator(rates, projects), projects), nil
+}
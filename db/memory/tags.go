@@ -0,0 +1,32 @@
+package memory
+
+import (
+	"context"
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+func (m *memoryStore) GetTags(ctx context.Context, owner string) ([]*models.TagCount, error) {
+	sessions := m.filterSessions(func(s *models.Session) bool { return s.Owner == owner && !s.Draft })
+	return db.CountTags(sessions), nil
+}
+
+// MergeTags replaces the given tags with a single tag on every session of the owner,
+// renaming a tag is a merge of a single tag
+func (m *memoryStore) MergeTags(ctx context.Context, owner string, tags []string, into string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var modified int64
+	for id, s := range m.sessions {
+		if s.Owner != owner {
+			continue
+		}
+		merged, changed := db.MergeTags(s.Tags, tags, into)
+		if changed {
+			s.Tags = merged
+			m.sessions[id] = s
+			modified++
+		}
+	}
+	return modified, nil
+}
@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+func (m *memoryStore) StartTimer(ctx context.Context, session *models.Session) (*models.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.runningTimer(session.Owner); ok {
+		return nil, db.ErrTimerRunning
+	}
+	m.sessions[session.ID] = *copySession(*session)
+	return session, nil
+}
+
+func (m *memoryStore) GetRunningTimer(ctx context.Context, owner string) (*models.Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	session, ok := m.runningTimer(owner)
+	if !ok {
+		return nil, db.ErrNoRunningTimer
+	}
+	return copySession(session), nil
+}
+
+func (m *memoryStore) PauseTimer(ctx context.Context, owner string, at int64) (*models.Session, error) {
+	return m.updateTimer(owner, func(session *models.Session) error {
+		return db.PauseTimer(session, at)
+	})
+}
+
+func (m *memoryStore) ResumeTimer(ctx context.Context, owner string, at int64) (*models.Session, error) {
+	return m.updateTimer(owner, func(session *models.Session) error {
+		return db.ResumeTimer(session, at)
+	})
+}
+
+func (m *memoryStore) StopTimer(ctx context.Context, owner string, end int64) (*models.Session, error) {
+	return m.updateTimer(owner, func(session *models.Session) error {
+		return db.StopTimer(session, end)
+	})
+}
+
+// updateTimer applies change to the running timer of the owner under the write lock
+func (m *memoryStore) updateTimer(owner string, change func(*models.Session) error) (*models.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored, ok := m.runningTimer(owner)
+	if !ok {
+		return nil, db.ErrNoRunningTimer
+	}
+
+	session := copySession(stored)
+	if err := change(session); err != nil {
+		return nil, err
+	}
+	m.sessions[session.ID] = *copySession(*session)
+	return session, nil
+}
+
+// runningTimer returns the running timer of the owner, the caller must hold the lock
+func (m *memoryStore) runningTimer(owner string) (models.Session, bool) {
+	for _, s := range m.sessions {
+		if s.Owner == owner && s.Running {
+			return s, true
+		}
+	}
+	return models.Session{}, false
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+func (m *memoryStore) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tokens[token.ID] = *token
+	return nil
+}
+
+func (m *memoryStore) GetRefreshToken(ctx context.Context, id string) (*models.RefreshToken, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	token, ok := m.tokens[id]
+	if !ok {
+		return nil, db.ErrNotFound
+	}
+	return &token, nil
+}
+
+// RotateRefreshToken exchanges the token for next under the lock, reuse revokes the family
+func (m *memoryStore) RotateRefreshToken(ctx context.Context, id string, next *models.RefreshToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current, ok := m.tokens[id]
+	if !ok {
+		return db.ErrNotFound
+	}
+	if err := db.CheckRotation(&current, next.Owner); err != nil {
+		if err == db.ErrTokenReused {
+			m.revokeFamily(current.Family)
+		}
+		return err
+	}
+
+	db.NextRefreshToken(&current, next)
+	m.tokens[id] = current
+	m.tokens[next.ID] = *next
+	return nil
+}
+
+func (m *memoryStore) RevokeRefreshFamily(ctx context.Context, family string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.revokeFamily(family)
+	return nil
+}
+
+func (m *memoryStore) RevokeUserRefreshTokens(ctx context.Context, owner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, token := range m.tokens {
+		if token.Owner == owner {
+			token.Revoked = true
+			m.tokens[id] = token
+		}
+	}
+	return nil
+}
+
+// revokeFamily revokes every token of the family, the caller holds the lock
+func (m *memoryStore) revokeFamily(family string) {
+	for id, token := range m.tokens {
+		if token.Family == family {
+			token.Revoked = true
+			m.tokens[id] = token
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (m mongoStore) CreateClient(ctx context.Context, client *models.Client) (*models.Client, error) {
+	_, err := m.col(clientsCollection).
+		InsertOne(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+func (m mongoStore) GetClient(ctx context.Context, id, owner string) (*models.Client, error) {
+	client := &models.Client{}
+	query := bson.M{
+		"id":    id,
+		"owner": owner,
+	}
+	err := m.col(clientsCollection).FindOne(ctx, query).Decode(client)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+func (m mongoStore) GetClients(ctx context.Context, owner string, includeArchived bool) ([]*models.Client, error) {
+	query := bson.M{"owner": owner}
+	if !includeArchived {
+		query["archived"] = false
+	}
+
+	findOptions := options.Find().SetSort(bson.M{"name": 1})
+	cursor, err := m.col(clientsCollection).Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	clients := []*models.Client{}
+	if err := cursor.All(ctx, &clients); err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+func (m mongoStore) UpdateClient(ctx context.Context, id string, info models.ClientInfo) error {
+	filter := bson.M{
+		"id": id,
+	}
+	setQuery := bson.M{}
+
+	if info.Name != nil {
+		setQuery["name"] = *info.Name
+	}
+	if info.Archived != nil {
+		setQuery["archived"] = *info.Archived
+	}
+
+	query := bson.M{
+		"$set": setQuery,
+	}
+
+	_, err := m.col(clientsCollection).UpdateOne(ctx, filter, query)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteClient only deletes the client once no project references it, like DeleteProject
+func (m mongoStore) DeleteClient(ctx context.Context, id string) error {
+	count, err := m.col(projectsCollection).CountDocuments(ctx, bson.M{"clientid": id}, options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return db.ErrClientInUse
+	}
+
+	if _, err := m.col(clientsCollection).DeleteOne(ctx, bson.M{"id": id}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m mongoStore) CreateRate(ctx context.Context, rate *models.Rate) (*models.Rate, error) {
+	_, err := m.col(ratesCollection).
+		InsertOne(ctx, rate)
+	if err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+func (m mongoStore) GetRates(ctx context.Context, owner string) ([]*models.Rate, error) {
+	query := bson.M{"owner": owner}
+
+	findOptions := options.Find().SetSort(bson.M{"effectivefrom": -1})
+	cursor, err := m.col(ratesCollection).Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := []*models.Rate{}
+	if err := cursor.All(ctx, &rates); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
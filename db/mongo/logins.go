@@ -0,0 +1,67 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/victor-nach/time-tracker/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (m mongoStore) CreateLoginSession(ctx context.Context, session *models.LoginSession) error {
+	_, err := m.col(loginsCollection).InsertOne(ctx, session)
+	return err
+}
+
+func (m mongoStore) GetLoginSession(ctx context.Context, id, owner string) (*models.LoginSession, error) {
+	session := &models.LoginSession{}
+	err := m.col(loginsCollection).FindOne(ctx, bson.M{"id": id, "owner": owner}).Decode(session)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// GetLoginSessions returns the login sessions of the owner, most recently used first
+func (m mongoStore) GetLoginSessions(ctx context.Context, owner string) ([]*models.LoginSession, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "lastusedat", Value: -1}, {Key: "id", Value: -1}})
+	cursor, err := m.col(loginsCollection).Find(ctx, bson.M{"owner": owner}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []*models.LoginSession{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (m mongoStore) UpdateLoginSession(ctx context.Context, id string, info models.LoginSessionInfo) error {
+	setQuery := bson.M{}
+	if info.UserAgent != nil {
+		setQuery["useragent"] = *info.UserAgent
+	}
+	if info.IP != nil {
+		setQuery["ip"] = *info.IP
+	}
+	if info.LastUsedAt != nil {
+		setQuery["lastusedat"] = *info.LastUsedAt
+	}
+	if len(setQuery) == 0 {
+		return nil
+	}
+
+	_, err := m.col(loginsCollection).UpdateOne(ctx, bson.M{"id": id}, bson.M{"$set": setQuery})
+	return err
+}
+
+func (m mongoStore) DeleteLoginSession(ctx context.Context, id string) error {
+	_, err := m.col(loginsCollection).DeleteOne(ctx, bson.M{"id": id})
+	return err
+}
+
+func (m mongoStore) DeleteLoginSessions(ctx context.Context, owner string) error {
+	_, err := m.col(loginsCollection).DeleteMany(ctx, bson.M{"owner": owner})
+	return err
+}
@@ -0,0 +1,257 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migrationsCollection records the migrations applied to the database
+const migrationsCollection = "migrations"
+
+// migration is a versioned change of the database. Migrations are append only, a released
+// migration must never be edited, and up must be idempotent: instances starting at the same
+// time can both apply a migration before either of them records it
+type migration struct {
+	version int
+	name    string
+	up      func(ctx context.Context, database *mongo.Database) error
+}
+
+// appliedMigration is the record of a migration in the migrations collection
+type appliedMigration struct {
+	Version   int
+	Name      string
+	AppliedAt int64
+}
+
+var migrations = []migration{
+	{version: 1, name: "create indexes", up: createIndexes},
+	{version: 2, name: "add validators", up: addValidators},
+	{version: 3, name: "refresh token indexes", up: createTokenIndexes},
+	{version: 4, name: "refresh token owner index", up: createTokenOwnerIndex},
+	{version: 5, name: "login session indexes", up: createLoginIndexes},
+	{version: 6, name: "one time token indexes", up: createOneTimeIndexes},
+	{version: 7, name: "verify existing users", up: verifyExistingUsers},
+}
+
+// migrate applies the migrations that are not recorded yet in order of version
+func migrate(ctx context.Context, database *mongo.Database) error {
+	records := database.Collection(migrationsCollection)
+	_, err := records.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "version", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		err := records.FindOne(ctx, bson.M{"version": m.version}).Err()
+		if err == nil {
+			continue
+		}
+		if err != mongo.ErrNoDocuments {
+			return err
+		}
+
+		if err := m.up(ctx, database); err != nil {
+			return err
+		}
+		// another instance recording the migration first is fine, up is idempotent
+		_, err = records.InsertOne(ctx, appliedMigration{Version: m.version, Name: m.name, AppliedAt: time.Now().Unix()})
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// createIndexes makes emails unique, backs the owner queries with compound indexes and
+// allows a single running timer per user. Creating an index that exists is a no-op
+func createIndexes(ctx context.Context, database *mongo.Database) error {
+	if err := checkDuplicateEmails(ctx, database); err != nil {
+		return err
+	}
+
+	unique := options.Index().SetUnique(true)
+	indexes := map[string][]mongo.IndexModel{
+		usersCollection: {
+			{Keys: bson.D{{Key: "id", Value: 1}}, Options: unique},
+			{Keys: bson.D{{Key: "email", Value: 1}}, Options: unique},
+			{
+				Keys:    bson.D{{Key: "calendartoken", Value: 1}},
+				Options: options.Index().SetPartialFilterExpression(bson.M{"calendartoken": bson.M{"$gt": ""}}),
+			},
+		},
+		sessionCollection: {
+			{Keys: bson.D{{Key: "id", Value: 1}}, Options: unique},
+			{Keys: bson.D{{Key: "owner", Value: 1}, {Key: "ts", Value: -1}, {Key: "id", Value: -1}}},
+			{Keys: bson.D{{Key: "owner", Value: 1}, {Key: "start", Value: 1}}},
+			{Keys: bson.D{{Key: "owner", Value: 1}, {Key: "tags", Value: 1}}},
+			{Keys: bson.D{{Key: "projectid", Value: 1}}},
+			{
+				Keys:    bson.D{{Key: "owner", Value: 1}, {Key: "importhash", Value: 1}},
+				Options: options.Index().SetPartialFilterExpression(bson.M{"importhash": bson.M{"$gt": ""}}),
+			},
+			runningTimerIndex,
+		},
+		projectsCollection: {
+			{Keys: bson.D{{Key: "id", Value: 1}}, Options: unique},
+			{Keys: bson.D{{Key: "owner", Value: 1}}},
+			{Keys: bson.D{{Key: "clientid", Value: 1}}},
+		},
+		clientsCollection: {
+			{Keys: bson.D{{Key: "id", Value: 1}}, Options: unique},
+			{Keys: bson.D{{Key: "owner", Value: 1}}},
+		},
+		ratesCollection: {
+			{Keys: bson.D{{Key: "id", Value: 1}}, Options: unique},
+			{Keys: bson.D{{Key: "owner", Value: 1}, {Key: "effectivefrom", Value: -1}}},
+		},
+	}
+
+	for collection, keys := range indexes {
+		if _, err := database.Collection(collection).Indexes().CreateMany(ctx, keys); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkDuplicateEmails fails with the emails shared by several users, the unique email index can not be
+// built until the operator gives every user an email of their own
+func checkDuplicateEmails(ctx context.Context, database *mongo.Database) error {
+	cursor, err := database.Collection(usersCollection).Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$email", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+		{{Key: "$limit", Value: 20}},
+	})
+	if err != nil {
+		return err
+	}
+	var duplicates []struct {
+		Email string `bson:"_id"`
+		Count int    `bson:"count"`
+	}
+	if err := cursor.All(ctx, &duplicates); err != nil {
+		return err
+	}
+	if len(duplicates) == 0 {
+		return nil
+	}
+
+	emails := make([]string, len(duplicates))
+	for i, d := range duplicates {
+		emails[i] = fmt.Sprintf("%s (%d users)", d.Email, d.Count)
+	}
+	return fmt.Errorf("emails must be unique but users share %s: change or remove the duplicate users, "+
+		"then start again to finish the migration", strings.Join(emails, ", "))
+}
+
+// addValidators rejects users and sessions that are missing their identifying fields or
+// store a field with the wrong type. The moderate level leaves existing invalid documents
+// alone until they are updated
+func addValidators(ctx context.Context, database *mongo.Database) error {
+	validators := map[string]bson.M{
+		usersCollection: {
+			"bsonType": "object",
+			"required": bson.A{"id", "email"},
+			"properties": bson.M{
+				"id":            bson.M{"bsonType": "string", "minLength": 1},
+				"email":         bson.M{"bsonType": "string", "minLength": 1},
+				"name":          bson.M{"bsonType": "string"},
+				"password":      bson.M{"bsonType": "string"},
+				"timezone":      bson.M{"bsonType": "string"},
+				"weekstart":     bson.M{"bsonType": "number", "minimum": 0, "maximum": 6},
+				"calendartoken": bson.M{"bsonType": "string"},
+				"ts":            bson.M{"bsonType": "number"},
+			},
+		},
+		sessionCollection: {
+			"bsonType": "object",
+			"required": bson.A{"id", "owner"},
+			"properties": bson.M{
+				"id":         bson.M{"bsonType": "string", "minLength": 1},
+				"owner":      bson.M{"bsonType": "string", "minLength": 1},
+				"title":      bson.M{"bsonType": "string"},
+				"projectid":  bson.M{"bsonType": "string"},
+				"tags":       bson.M{"bsonType": bson.A{"array", "null"}, "items": bson.M{"bsonType": "string"}},
+				"segments":   bson.M{"bsonType": bson.A{"array", "null"}},
+				"start":      bson.M{"bsonType": "number"},
+				"end":        bson.M{"bsonType": "number"},
+				"duration":   bson.M{"bsonType": "number"},
+				"running":    bson.M{"bsonType": "bool"},
+				"paused":     bson.M{"bsonType": "bool"},
+				"draft":      bson.M{"bsonType": "bool"},
+				"billable":   bson.M{"bsonType": "bool"},
+				"importhash": bson.M{"bsonType": "string"},
+				"ts":         bson.M{"bsonType": "number"},
+			},
+		},
+	}
+
+	// the collections exist after the indexes migration so collMod can set the validators
+	for collection, schema := range validators {
+		err := database.RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: collection},
+			{Key: "validator", Value: bson.M{"$jsonSchema": schema}},
+			{Key: "validationLevel", Value: "moderate"},
+		}).Err()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createTokenIndexes backs the lookups of refresh tokens by id and the revocation of a family
+func createTokenIndexes(ctx context.Context, database *mongo.Database) error {
+	_, err := database.Collection(tokensCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "family", Value: 1}}},
+	})
+	return err
+}
+
+// createTokenOwnerIndex backs the revocation of every refresh token of a user
+func createTokenOwnerIndex(ctx context.Context, database *mongo.Database) error {
+	_, err := database.Collection(tokensCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "owner", Value: 1}},
+	})
+	return err
+}
+
+// createLoginIndexes backs the lookups of login sessions by id and the devices of a user
+func createLoginIndexes(ctx context.Context, database *mongo.Database) error {
+	_, err := database.Collection(loginsCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "owner", Value: 1}, {Key: "lastusedat", Value: -1}}},
+	})
+	return err
+}
+
+// createOneTimeIndexes backs the lookups of one time tokens by id and their removal for a user
+func createOneTimeIndexes(ctx context.Context, database *mongo.Database) error {
+	_, err := database.Collection(onetimeCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "owner", Value: 1}, {Key: "purpose", Value: 1}}},
+	})
+	return err
+}
+
+// verifyExistingUsers marks the users who signed up before verification existed as verified,
+// users created since always store the field
+func verifyExistingUsers(ctx context.Context, database *mongo.Database) error {
+	_, err := database.Collection(usersCollection).UpdateMany(ctx,
+		bson.M{"emailverified": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"emailverified": true}},
+	)
+	return err
+}
@@ -3,6 +3,7 @@ package mongo
 import (
 	"context"
 	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/lib/clock"
 	"github.com/victor-nach/time-tracker/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -12,19 +13,26 @@ import (
 )
 
 const (
-	sessionCollection = "sessions"
-	usersCollection   = "users"
+	sessionCollection  = "sessions"
+	usersCollection    = "users"
+	projectsCollection = "projects"
+	clientsCollection  = "clients"
+	ratesCollection    = "rates"
+	tokensCollection   = "refreshtokens"
+	loginsCollection   = "loginsessions"
+	onetimeCollection  = "onetimetokens"
 )
 
 type mongoStore struct {
 	client *mongo.Client
 	dbName string
+	clock  clock.Clock
 }
 
 // ensure mongostore implements the datastore interface
 var _ db.Datastore = &mongoStore{}
 
-//New returns an instance of mongo store
+// New returns an instance of mongo store, the indexes and validators are created on the first start
 func New(dbUrl, dbName string) (db.Datastore, *mongo.Client, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -39,9 +47,17 @@ func New(dbUrl, dbName string) (db.Datastore, *mongo.Client, error) {
 		return nil, nil, err
 	}
 
+	// building indexes on existing collections can take longer than connecting
+	migrateCtx, cancelMigrate := context.WithTimeout(context.Background(), time.Minute)
+	defer cancelMigrate()
+	if err := migrate(migrateCtx, client.Database(dbName)); err != nil {
+		return nil, nil, err
+	}
+
 	return &mongoStore{
 		client: client,
 		dbName: dbName,
+		clock:  clock.New(),
 	}, client, nil
 }
 
@@ -49,71 +65,133 @@ func (m *mongoStore) col(collectionName string) *mongo.Collection {
 	return m.client.Database(m.dbName).Collection(collectionName)
 }
 
-func (m mongoStore) CreateUser(user *models.User) (*models.User, error) {
+func (m mongoStore) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
 	_, err := m.col(usersCollection).
-		InsertOne(context.Background(), user)
+		InsertOne(ctx, user)
+	// ids are generated, so a duplicate key comes from the unique email index
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, db.ErrEmailExists
+	}
 	if err != nil {
 		return nil, err
 	}
 	return user, nil
 }
 
-func (m mongoStore) GetUser(id string) (*models.User, error) {
+func (m mongoStore) GetUser(ctx context.Context, id string) (*models.User, error) {
 	user := &models.User{}
 	query := bson.M{
 		"id": id,
 	}
-	err := m.col(usersCollection).FindOne(context.Background(), query).Decode(user)
+	err := m.col(usersCollection).FindOne(ctx, query).Decode(user)
 	if err != nil {
 		return nil, err
 	}
 	return user, nil
 }
 
-func (m mongoStore) GetUserByEmail(email string) (*models.User, error) {
+func (m mongoStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	user := &models.User{}
 	query := bson.M{
 		"email": email,
 	}
-	err := m.col(usersCollection).FindOne(context.Background(), query).Decode(user)
+	err := m.col(usersCollection).FindOne(ctx, query).Decode(user)
 	if err != nil {
 		return nil, err
 	}
 	return user, nil
 }
 
-func (m mongoStore) GetSession(id, owner string) (*models.Session, error) {
+// GetUserByCalendarToken returns the user owning the hashed calendar feed token
+func (m mongoStore) GetUserByCalendarToken(ctx context.Context, token string) (*models.User, error) {
+	user := &models.User{}
+	if token == "" {
+		return nil, mongo.ErrNoDocuments
+	}
+	err := m.col(usersCollection).FindOne(ctx, bson.M{"calendartoken": token}).Decode(user)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (m mongoStore) UpdateUser(ctx context.Context, id string, info models.UserInfo) error {
+	filter := bson.M{
+		"id": id,
+	}
+	setQuery := bson.M{}
+
+	if info.Name != nil {
+		setQuery["name"] = *info.Name
+	}
+	if info.TimeZone != nil {
+		setQuery["timezone"] = *info.TimeZone
+	}
+	if info.WeekStart != nil {
+		setQuery["weekstart"] = *info.WeekStart
+	}
+	if info.CalendarToken != nil {
+		setQuery["calendartoken"] = *info.CalendarToken
+	}
+	if info.OverlapPolicy != nil {
+		setQuery["overlappolicy"] = *info.OverlapPolicy
+	}
+	if info.Password != nil {
+		setQuery["password"] = *info.Password
+	}
+	if info.EmailVerified != nil {
+		setQuery["emailverified"] = *info.EmailVerified
+	}
+
+	query := bson.M{
+		"$set": setQuery,
+	}
+
+	_, err := m.col(usersCollection).UpdateOne(ctx, filter, query)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m mongoStore) GetTokenGeneration(ctx context.Context, id string) (int64, error) {
+	user, err := m.GetUser(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	return user.TokenGeneration, nil
+}
+
+func (m mongoStore) IncrementTokenGeneration(ctx context.Context, id string) (int64, error) {
+	user := &models.User{}
+	err := m.col(usersCollection).FindOneAndUpdate(ctx,
+		bson.M{"id": id},
+		bson.M{"$inc": bson.M{"tokengeneration": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(user)
+	if err != nil {
+		return 0, err
+	}
+	return user.TokenGeneration, nil
+}
+
+func (m mongoStore) GetSession(ctx context.Context, id, owner string) (*models.Session, error) {
 	session := &models.Session{}
 	query := bson.M{
 		"id":    id,
 		"owner": owner,
 	}
-	err := m.col(sessionCollection).FindOne(context.Background(), query).Decode(session)
+	err := m.col(sessionCollection).FindOne(ctx, query).Decode(session)
 	if err != nil {
 		return nil, err
 	}
 	return session, nil
 }
 
-func (m mongoStore) GetSessions(owner string, filter string) ([]*models.Session, error) {
-	ctx := context.Background()
-	query := bson.M{"owner": owner}
-
-	if filter != "nil" {
-		now := time.Now()
-		var startTime time.Time
-
-		switch filter {
-		case "day":
-			startTime = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
-		case "week":
-			startTime = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local).
-				AddDate(0, 0, -int(now.Weekday()))
-		case "month":
-			startTime = time.Date(now.Year(), now.Month(), 0, 0, 0, 0, 0, time.Local)
-		}
-
-		query["ts"] = bson.M{"$gt": startTime.Unix()}
+func (m mongoStore) GetSessions(ctx context.Context, owner string, filter models.SessionFilter) ([]*models.Session, error) {
+	query, err := m.sessionsQuery(owner, filter)
+	if err != nil {
+		return nil, err
 	}
 
 	// Sort by most recent
@@ -131,16 +209,195 @@ func (m mongoStore) GetSessions(owner string, filter string) ([]*models.Session,
 	return sessions, nil
 }
 
-func (m mongoStore) CreateSession(session *models.Session) (*models.Session, error) {
+// StreamSessions calls fn for every session of the owner that passes the filter in order of start,
+// sessions are decoded one at a time from the cursor and iteration stops at the first error of fn
+func (m mongoStore) StreamSessions(ctx context.Context, owner string, filter models.SessionFilter, fn func(*models.Session) error) error {
+	query, err := m.sessionsQuery(owner, filter)
+	if err != nil {
+		return err
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "start", Value: 1}, {Key: "id", Value: 1}})
+	cursor, err := m.col(sessionCollection).Find(ctx, query, findOptions)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var session models.Session
+		if err := cursor.Decode(&session); err != nil {
+			return err
+		}
+		if err := fn(&session); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// sessionsQuery builds the query matching the sessions of the owner that pass the filter
+func (m mongoStore) sessionsQuery(owner string, filter models.SessionFilter) (bson.M, error) {
+	filter, err := db.ApplyPeriod(filter, m.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// running timers are only visible through GetRunningTimer and drafts through GetDraftSessions
+	query := bson.M{"owner": owner, "running": bson.M{"$ne": true}, "draft": bson.M{"$ne": true}}
+
+	if filter.ProjectID != "" {
+		query["projectid"] = filter.ProjectID
+	}
+	if len(filter.AnyTags) > 0 || len(filter.AllTags) > 0 {
+		tagsQuery := bson.M{}
+		if len(filter.AnyTags) > 0 {
+			tagsQuery["$in"] = filter.AnyTags
+		}
+		if len(filter.AllTags) > 0 {
+			tagsQuery["$all"] = filter.AllTags
+		}
+		query["tags"] = tagsQuery
+	}
+	// sessions overlapping the range boundaries are included
+	if filter.To != 0 {
+		query["start"] = bson.M{"$lt": filter.To}
+	}
+	if filter.From != 0 {
+		query["end"] = bson.M{"$gt": filter.From}
+	}
+	return query, nil
+}
+
+func (m mongoStore) CreateSession(ctx context.Context, session *models.Session) (*models.Session, error) {
 	_, err := m.col(sessionCollection).
-		InsertOne(context.Background(), session)
+		InsertOne(ctx, session)
 	if err != nil {
 		return nil, err
 	}
 	return session, nil
 }
 
-func (m mongoStore) UpdateSession(id string, info models.SessionInfo) error {
+// CreateSessionWithPolicy checks for overlaps before saving the session. Without multi-document
+// transactions the check is not atomic, two sessions saved at the same instant can still overlap
+// and are then reported by GetOverlaps
+func (m mongoStore) CreateSessionWithPolicy(ctx context.Context, session *models.Session, policy string) ([]*models.Session, error) {
+	candidates, err := m.GetSessions(ctx, session.Owner, db.OverlapRange(session))
+	if err != nil {
+		return nil, err
+	}
+	conflicts, err := db.ApplyOverlapPolicy(session, candidates, policy)
+	if err != nil {
+		return conflicts, err
+	}
+	if _, err := m.col(sessionCollection).InsertOne(ctx, session); err != nil {
+		return nil, err
+	}
+	return conflicts, nil
+}
+
+// GetOverlaps returns every pair of saved sessions of the owner that tracked the same time
+func (m mongoStore) GetOverlaps(ctx context.Context, owner string) ([]*models.Overlap, error) {
+	sessions := []*models.Session{}
+	err := m.StreamSessions(ctx, owner, models.SessionFilter{}, func(session *models.Session) error {
+		sessions = append(sessions, session)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return db.FindOverlaps(sessions), nil
+}
+
+// CreateSessions inserts a batch of sessions in one round trip
+func (m mongoStore) CreateSessions(ctx context.Context, sessions []*models.Session) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, len(sessions))
+	for i, s := range sessions {
+		docs[i] = s
+	}
+	_, err := m.col(sessionCollection).InsertMany(ctx, docs)
+	return err
+}
+
+// GetImportedHashes returns the hashes that already belong to imported sessions of the owner
+func (m mongoStore) GetImportedHashes(ctx context.Context, owner string, hashes []string) ([]string, error) {
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+	values, err := m.col(sessionCollection).Distinct(ctx, "importhash",
+		bson.M{"owner": owner, "importhash": bson.M{"$in": hashes}})
+	if err != nil {
+		return nil, err
+	}
+
+	imported := make([]string, 0, len(values))
+	for _, v := range values {
+		if hash, ok := v.(string); ok {
+			imported = append(imported, hash)
+		}
+	}
+	return imported, nil
+}
+
+// GetDraftSessions returns the unconfirmed sessions of the owner in order of start
+func (m mongoStore) GetDraftSessions(ctx context.Context, owner string) ([]*models.Session, error) {
+	findOptions := options.Find().SetSort(bson.M{"start": 1})
+	cursor, err := m.col(sessionCollection).Find(ctx, bson.M{"owner": owner, "draft": true}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []*models.Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// ConfirmDrafts checks every draft for overlaps before confirming it. Like CreateSessionWithPolicy
+// the check is not atomic, a session saved at the same instant can still overlap a confirmed draft
+func (m mongoStore) ConfirmDrafts(ctx context.Context, owner string, ids []string, policy string) (int64, []*models.Session, error) {
+	var confirmed int64
+	conflicts := []*models.Session{}
+	for _, id := range ids {
+		draft := &models.Session{}
+		err := m.col(sessionCollection).FindOne(ctx, bson.M{"id": id, "owner": owner, "draft": true}).Decode(draft)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+
+		candidates, err := m.GetSessions(ctx, owner, db.OverlapRange(draft))
+		if err != nil {
+			return 0, nil, err
+		}
+		overlaps, err := db.ApplyOverlapPolicy(draft, candidates, policy)
+		conflicts = append(conflicts, overlaps...)
+		if err == db.ErrSessionOverlap {
+			continue
+		}
+
+		res, err := m.col(sessionCollection).UpdateOne(ctx, bson.M{"id": id, "draft": true}, bson.M{"$set": bson.M{
+			"draft":    false,
+			"segments": draft.Segments,
+			"duration": draft.Duration,
+			"start":    draft.Start,
+			"end":      draft.End,
+		}})
+		if err != nil {
+			return 0, nil, err
+		}
+		confirmed += res.ModifiedCount
+	}
+	return confirmed, conflicts, nil
+}
+
+func (m mongoStore) UpdateSession(ctx context.Context, id string, info models.SessionInfo) error {
 	filter := bson.M{
 		"id": id,
 	}
@@ -152,24 +409,158 @@ func (m mongoStore) UpdateSession(id string, info models.SessionInfo) error {
 	if info.Description != nil {
 		setQuery["description"] = *info.Description
 	}
+	if info.ProjectID != nil {
+		setQuery["projectid"] = *info.ProjectID
+	}
+	if info.Billable != nil {
+		setQuery["billable"] = *info.Billable
+	}
+	if info.Tags != nil {
+		setQuery["tags"] = info.Tags
+	}
 
 	query := bson.M{
 		"$set": setQuery,
 	}
 
-	_, err := m.col(sessionCollection).UpdateOne(context.Background(), filter, query)
+	_, err := m.col(sessionCollection).UpdateOne(ctx, filter, query)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (m mongoStore) DeleteSession(id string) error {
+func (m mongoStore) DeleteSession(ctx context.Context, id string) error {
 	filter := bson.M{
 		"id": id,
 	}
-	if _, err := m.col(sessionCollection).DeleteOne(context.Background(), filter); err != nil {
+	if _, err := m.col(sessionCollection).DeleteOne(ctx, filter); err != nil {
+		return err
+	}
+	return nil
+}
+
+// runningTimerIndex allows a single running timer per user. Two upserts racing in StartTimer can both
+// find no running timer, the index makes the second insert fail instead of starting a second timer
+var runningTimerIndex = mongo.IndexModel{
+	Keys:    bson.D{{Key: "owner", Value: 1}},
+	Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"running": true}),
+}
+
+// StartTimer saves the session unless the owner has a running timer, runningTimerIndex keeps concurrent starts apart
+func (m mongoStore) StartTimer(ctx context.Context, session *models.Session) (*models.Session, error) {
+	filter := bson.M{
+		"owner":   session.Owner,
+		"running": true,
+	}
+	query := bson.M{
+		"$setOnInsert": session,
+	}
+
+	// the upsert only inserts when the owner has no running timer,
+	// an existing document means a timer was already started
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
+	err := m.col(sessionCollection).FindOneAndUpdate(ctx, filter, query, opts).Err()
+	// a concurrent start that inserted first makes the upsert fail on the running index
+	if err == nil || mongo.IsDuplicateKeyError(err) {
+		return nil, db.ErrTimerRunning
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (m mongoStore) GetRunningTimer(ctx context.Context, owner string) (*models.Session, error) {
+	session := &models.Session{}
+	query := bson.M{
+		"owner":   owner,
+		"running": true,
+	}
+	err := m.col(sessionCollection).FindOne(ctx, query).Decode(session)
+	if err == mongo.ErrNoDocuments {
+		return nil, db.ErrNoRunningTimer
+	}
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (m mongoStore) PauseTimer(ctx context.Context, owner string, at int64) (*models.Session, error) {
+	session, err := m.GetRunningTimer(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PauseTimer(session, at); err != nil {
+		return nil, err
+	}
+
+	if err := m.updateTimer(ctx, session, false); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (m mongoStore) ResumeTimer(ctx context.Context, owner string, at int64) (*models.Session, error) {
+	session, err := m.GetRunningTimer(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.ResumeTimer(session, at); err != nil {
+		return nil, err
+	}
+
+	if err := m.updateTimer(ctx, session, true); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (m mongoStore) StopTimer(ctx context.Context, owner string, end int64) (*models.Session, error) {
+	session, err := m.GetRunningTimer(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	wasPaused := session.Paused
+	if err := db.StopTimer(session, end); err != nil {
+		return nil, err
+	}
+
+	if err := m.updateTimer(ctx, session, wasPaused); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// updateTimer persists the state of a running timer, the update only applies
+// if the timer is still running and its paused state is unchanged
+func (m mongoStore) updateTimer(ctx context.Context, session *models.Session, wasPaused bool) error {
+	filter := bson.M{
+		"id":      session.ID,
+		"running": true,
+		"paused":  wasPaused,
+	}
+	if !wasPaused {
+		// timers started before pausing was supported have no paused field
+		filter["paused"] = bson.M{"$ne": true}
+	}
+	query := bson.M{
+		"$set": bson.M{
+			"end":      session.End,
+			"duration": session.Duration,
+			"segments": session.Segments,
+			"running":  session.Running,
+			"paused":   session.Paused,
+		},
+	}
+	res, err := m.col(sessionCollection).UpdateOne(ctx, filter, query)
+	if err != nil {
 		return err
 	}
+	// the timer was changed by a concurrent request
+	if res.MatchedCount == 0 {
+		return db.ErrNoRunningTimer
+	}
 	return nil
 }
@@ -5,11 +5,17 @@ import (
 	"fmt"
 	"github.com/ory/dockertest/v3"
 	"github.com/stretchr/testify/assert"
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/db/dbtest"
 	"github.com/victor-nach/time-tracker/lib/ulid"
+	"github.com/victor-nach/time-tracker/mocks"
 	"github.com/victor-nach/time-tracker/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"log"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -77,6 +83,7 @@ func TestMain(m *testing.M) {
 }
 
 func TestMongoStore_GetUser(t *testing.T) {
+	ctx := context.Background()
 	const (
 		getByEmail = iota
 		getById
@@ -123,19 +130,19 @@ func TestMongoStore_GetUser(t *testing.T) {
 			switch testCase.testType {
 
 			case getById:
-				user, err := dataStore.GetUser(testCase.arg)
+				user, err := dataStore.GetUser(ctx, testCase.arg)
 				assert.NotNil(t, user)
 				assert.NoError(t, err)
 			case getByEmail:
-				user, err := dataStore.GetUserByEmail(testCase.arg)
+				user, err := dataStore.GetUserByEmail(ctx, testCase.arg)
 				assert.NotNil(t, user)
 				assert.NoError(t, err)
 			case errorEmailNotFound:
-				user, err := dataStore.GetUser(testCase.arg)
+				user, err := dataStore.GetUser(ctx, testCase.arg)
 				assert.Nil(t, user)
 				assert.Error(t, err)
 			case errorUserIdNotFound:
-				user, err := dataStore.GetUser(testCase.arg)
+				user, err := dataStore.GetUser(ctx, testCase.arg)
 				assert.Nil(t, user)
 				assert.Error(t, err)
 			}
@@ -148,6 +155,7 @@ func TestMongoStore_GetUser(t *testing.T) {
 }
 
 func TestMongoStore_CreateUser(t *testing.T) {
+	ctx := context.Background()
 	connectUri := "mongodb://localhost:" + mongoDbPort
 	dataStore, client, err := New(connectUri, "tracker")
 	assert.Nil(t, err)
@@ -158,28 +166,40 @@ func TestMongoStore_CreateUser(t *testing.T) {
 	mockUser.Email = "userEmail2"
 
 	// assert user not found
-	u, err := dataStore.GetUser(mockUser.ID)
+	u, err := dataStore.GetUser(ctx, mockUser.ID)
 	assert.Error(t, err)
 	assert.Nil(t, u)
 
 	// test create user
-	usr, err := dataStore.CreateUser(&mockUser)
+	usr, err := dataStore.CreateUser(ctx, &mockUser)
 	assert.Nil(t, err)
 	assert.NotNil(t, usr)
 
-	user, err := dataStore.GetUser(mockUser.ID)
+	user, err := dataStore.GetUser(ctx, mockUser.ID)
 	assert.Nil(t, err)
 	assert.NotNil(t, user)
 	assert.Equal(t, mockUser, *user)
+
+	// test update user settings
+	timeZone, weekStart := "Europe/Berlin", 1
+	err = dataStore.UpdateUser(ctx, mockUser.ID, models.UserInfo{TimeZone: &timeZone, WeekStart: &weekStart})
+	assert.NoError(t, err)
+
+	user, err = dataStore.GetUser(ctx, mockUser.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, timeZone, user.TimeZone)
+	assert.Equal(t, weekStart, user.WeekStart)
+	assert.Equal(t, mockUser.Name, user.Name)
 }
 
 func TestMongoStore_CreateSession(t *testing.T) {
+	ctx := context.Background()
 	connectUri := "mongodb://localhost:" + mongoDbPort
 	dataStore, client, err := New(connectUri, "tracker")
 	assert.Nil(t, err)
 	assert.NotNil(t, client)
 
-	session, err := dataStore.CreateSession(&mockData.Session)
+	session, err := dataStore.CreateSession(ctx, &mockData.Session)
 	assert.Nil(t, err)
 	assert.NotNil(t, session)
 
@@ -196,6 +216,7 @@ func TestMongoStore_CreateSession(t *testing.T) {
 }
 
 func TestMongoStore_GetSession(t *testing.T) {
+	ctx := context.Background()
 	const (
 		getSingleSession = iota
 		errorSessionNotFound
@@ -222,7 +243,7 @@ func TestMongoStore_GetSession(t *testing.T) {
 	// seed owner
 	mockUser := mockData.User
 	mockUser.ID = ulid.New().Generate()
-	mockUser.Email = "testt@mail2.com"
+	mockUser.Email = mockUser.ID + "@email.com"
 
 	mockSession := mockData.Session
 	mockSession.ID = ulid.New().Generate()
@@ -238,11 +259,11 @@ func TestMongoStore_GetSession(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			switch testCase.testType {
 			case errorSessionNotFound:
-				user, err := dataStore.GetSession("invalidId", mockUser.ID)
+				user, err := dataStore.GetSession(ctx, "invalidId", mockUser.ID)
 				assert.Nil(t, user)
 				assert.Error(t, err)
 			case getSingleSession:
-				session, err := dataStore.GetSession(mockSession.ID, mockSession.Owner)
+				session, err := dataStore.GetSession(ctx, mockSession.ID, mockSession.Owner)
 				assert.NotNil(t, session)
 				assert.Nil(t, err)
 			}
@@ -251,6 +272,7 @@ func TestMongoStore_GetSession(t *testing.T) {
 }
 
 func TestMongoStore_GetSessions(t *testing.T) {
+	ctx := context.Background()
 	const (
 		getAllSessions = iota
 		getSessionsByDay
@@ -295,17 +317,29 @@ func TestMongoStore_GetSessions(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, client)
 
+	// periods are worked out from a wednesday in the middle of the month
+	now := time.Date(2021, 6, 16, 12, 0, 0, 0, time.UTC)
+	clockMock := new(mocks.Clock)
+	clockMock.On("Now").Return(now)
+	dataStore.(*mongoStore).clock = clockMock
+
 	// seed owner
 	mockUser := mockData.User
 	mockUser.ID = ulid.New().Generate()
-	mockUser.Email = "testt@mail2.com"
+	mockUser.Email = mockUser.ID + "@email.com"
 
 	mockSession := mockData.Session
 	mockSession.ID = ulid.New().Generate()
 	mockSession.Owner = mockUser.ID
+	mockSession.Start = now.Add(-2 * time.Hour).Unix()
+	mockSession.End = now.Add(-time.Hour).Unix()
 
+	// periods match on start and end, the time the session was saved does not matter
 	mockSession2 := mockSession
-	mockSession2.Ts = time.Now().AddDate(0, 0, -10).Unix() // 10 days ago
+	mockSession2.ID = ulid.New().Generate()
+	mockSession2.Start = now.AddDate(0, 0, -10).Unix() // 10 days ago
+	mockSession2.End = now.AddDate(0, 0, -10).Add(time.Hour).Unix()
+	mockSession2.Ts = now.Unix()
 
 	_, err = client.Database(dbName).Collection(usersCollection).InsertOne(context.Background(), mockUser)
 	assert.Nil(t, err)
@@ -318,7 +352,7 @@ func TestMongoStore_GetSessions(t *testing.T) {
 
 	for _, testCase := range tests {
 		t.Run(testCase.name, func(t *testing.T) {
-			sessions, err := dataStore.GetSessions(mockSession.Owner, testCase.filter)
+			sessions, err := dataStore.GetSessions(ctx, mockSession.Owner, models.SessionFilter{Period: testCase.filter})
 			assert.Nil(t, err)
 			assert.Equal(t, testCase.expectedLength, len(sessions))
 		})
@@ -326,6 +360,7 @@ func TestMongoStore_GetSessions(t *testing.T) {
 }
 
 func TestMongoStore_ManageSession(t *testing.T) {
+	ctx := context.Background()
 	connectUri := "mongodb://localhost:" + mongoDbPort
 	dataStore, client, err := New(connectUri, "tracker")
 	assert.Nil(t, err)
@@ -346,19 +381,652 @@ func TestMongoStore_ManageSession(t *testing.T) {
 
 	// test update session
 	title := "new title"
-	err = dataStore.UpdateSession(mockSession.ID, models.SessionInfo{Title: &title})
+	err = dataStore.UpdateSession(ctx, mockSession.ID, models.SessionInfo{Title: &title})
 	assert.NoError(t, err)
 
-	s, err := dataStore.GetSession(mockSession.ID, mockSession.Owner)
+	s, err := dataStore.GetSession(ctx, mockSession.ID, mockSession.Owner)
 	assert.NotNil(t, s)
 	assert.NoError(t, err)
 	assert.Equal(t, title, s.Title)
 
 	//	 test delete session
-	err = dataStore.DeleteSession(mockSession.ID)
+	err = dataStore.DeleteSession(ctx, mockSession.ID)
 	assert.NoError(t, err)
 
-	ss, err := dataStore.GetSession(mockSession.ID, mockSession.Owner)
+	ss, err := dataStore.GetSession(ctx, mockSession.ID, mockSession.Owner)
 	assert.Nil(t, ss)
 	assert.Error(t, err)
 }
+
+func TestMongoStore_ConcurrentTimers(t *testing.T) {
+	ctx := context.Background()
+	connectUri := "mongodb://localhost:" + mongoDbPort
+	dataStore, client, err := New(connectUri, "tracker")
+	assert.Nil(t, err)
+	assert.NotNil(t, client)
+	owner := ulid.New().Generate()
+
+	// only one of many concurrent timers of a user can start
+	var wg sync.WaitGroup
+	var started int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			timer := &models.Session{ID: fmt.Sprintf("%s-%d", owner, i), Owner: owner, Running: true}
+			if _, err := dataStore.StartTimer(ctx, timer); err == nil {
+				atomic.AddInt32(&started, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), started)
+}
+
+func TestMongoStore_Timer(t *testing.T) {
+	ctx := context.Background()
+	connectUri := "mongodb://localhost:" + mongoDbPort
+	dataStore, client, err := New(connectUri, "tracker")
+	assert.Nil(t, err)
+	assert.NotNil(t, client)
+
+	owner := ulid.New().Generate()
+
+	// assert no timer is running
+	s, err := dataStore.GetRunningTimer(ctx, owner)
+	assert.Nil(t, s)
+	assert.Equal(t, db.ErrNoRunningTimer, err)
+
+	// test start timer
+	start := time.Now().Add(-time.Hour).Unix()
+	timer := models.Session{
+		ID:       ulid.New().Generate(),
+		Owner:    owner,
+		Title:    "timer",
+		Start:    start,
+		Segments: []models.Segment{{Start: start}},
+		Running:  true,
+		Ts:       start,
+	}
+	session, err := dataStore.StartTimer(ctx, &timer)
+	assert.NoError(t, err)
+	assert.NotNil(t, session)
+
+	// only one timer can run per user
+	second := timer
+	second.ID = ulid.New().Generate()
+	session, err = dataStore.StartTimer(ctx, &second)
+	assert.Nil(t, session)
+	assert.Equal(t, db.ErrTimerRunning, err)
+
+	running, err := dataStore.GetRunningTimer(ctx, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, timer.ID, running.ID)
+
+	// running timers are excluded from the saved sessions
+	sessions, err := dataStore.GetSessions(ctx, owner, models.SessionFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 0)
+
+	// test pause and resume timer
+	pausedAt := start + 600
+	paused, err := dataStore.PauseTimer(ctx, owner, pausedAt)
+	assert.NoError(t, err)
+	assert.True(t, paused.Paused)
+	assert.Equal(t, int64(600), paused.Duration)
+
+	_, err = dataStore.PauseTimer(ctx, owner, pausedAt)
+	assert.Equal(t, db.ErrTimerPaused, err)
+
+	resumedAt := pausedAt + 300
+	resumed, err := dataStore.ResumeTimer(ctx, owner, resumedAt)
+	assert.NoError(t, err)
+	assert.False(t, resumed.Paused)
+	assert.Len(t, resumed.Segments, 2)
+
+	_, err = dataStore.ResumeTimer(ctx, owner, resumedAt)
+	assert.Equal(t, db.ErrTimerNotPaused, err)
+
+	// test stop timer, the break is excluded from the duration
+	end := time.Now().Unix()
+	stopped, err := dataStore.StopTimer(ctx, owner, end)
+	assert.NoError(t, err)
+	assert.False(t, stopped.Running)
+	assert.Equal(t, end, stopped.End)
+	assert.Equal(t, end-start-300, stopped.Duration)
+	assert.Equal(t, []models.Segment{{Start: start, End: pausedAt}, {Start: resumedAt, End: end}}, stopped.Segments)
+
+	s, err = dataStore.GetSession(ctx, timer.ID, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, stopped, s)
+
+	stopped, err = dataStore.StopTimer(ctx, owner, end)
+	assert.Nil(t, stopped)
+	assert.Equal(t, db.ErrNoRunningTimer, err)
+}
+
+func TestMongoStore_Projects(t *testing.T) {
+	ctx := context.Background()
+	connectUri := "mongodb://localhost:" + mongoDbPort
+	dataStore, client, err := New(connectUri, "tracker")
+	assert.Nil(t, err)
+	assert.NotNil(t, client)
+
+	owner := ulid.New().Generate()
+	project := models.Project{
+		ID:    ulid.New().Generate(),
+		Owner: owner,
+		Name:  "website redesign",
+		Ts:    time.Now().Unix(),
+	}
+
+	// test create project
+	p, err := dataStore.CreateProject(ctx, &project)
+	assert.NoError(t, err)
+	assert.NotNil(t, p)
+
+	p, err = dataStore.GetProject(ctx, project.ID, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, project, *p)
+
+	// seed a session that belongs to the project
+	mockSession := mockData.Session
+	mockSession.ID = ulid.New().Generate()
+	mockSession.Owner = owner
+	mockSession.ProjectID = project.ID
+	_, err = client.Database(dbName).Collection(sessionCollection).InsertOne(context.Background(), mockSession)
+	assert.Nil(t, err)
+
+	sessions, err := dataStore.GetSessions(ctx, owner, models.SessionFilter{ProjectID: project.ID})
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+
+	sessions, err = dataStore.GetSessions(ctx, owner, models.SessionFilter{ProjectID: "otherProject"})
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 0)
+
+	// projects with sessions can not be deleted
+	err = dataStore.DeleteProject(ctx, project.ID)
+	assert.Equal(t, db.ErrProjectInUse, err)
+
+	// test archive project
+	archived := true
+	err = dataStore.UpdateProject(ctx, project.ID, models.ProjectInfo{Archived: &archived})
+	assert.NoError(t, err)
+
+	projects, err := dataStore.GetProjects(ctx, owner, false)
+	assert.NoError(t, err)
+	assert.Len(t, projects, 0)
+
+	projects, err = dataStore.GetProjects(ctx, owner, true)
+	assert.NoError(t, err)
+	assert.Len(t, projects, 1)
+
+	// test delete project
+	err = dataStore.DeleteSession(ctx, mockSession.ID)
+	assert.NoError(t, err)
+
+	err = dataStore.DeleteProject(ctx, project.ID)
+	assert.NoError(t, err)
+
+	p, err = dataStore.GetProject(ctx, project.ID, owner)
+	assert.Nil(t, p)
+	assert.Error(t, err)
+}
+
+func TestMongoStore_ClientsAndRates(t *testing.T) {
+	ctx := context.Background()
+	connectUri := "mongodb://localhost:" + mongoDbPort
+	dataStore, client, err := New(connectUri, "tracker")
+	assert.Nil(t, err)
+	assert.NotNil(t, client)
+
+	owner := ulid.New().Generate()
+	mockClient := models.Client{
+		ID:    ulid.New().Generate(),
+		Owner: owner,
+		Name:  "acme",
+		Ts:    time.Now().Unix(),
+	}
+
+	// test create client
+	c, err := dataStore.CreateClient(ctx, &mockClient)
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+
+	c, err = dataStore.GetClient(ctx, mockClient.ID, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, mockClient, *c)
+
+	// clients with projects can not be deleted
+	project := models.Project{ID: ulid.New().Generate(), Owner: owner, ClientID: mockClient.ID, Name: "app"}
+	_, err = dataStore.CreateProject(ctx, &project)
+	assert.NoError(t, err)
+
+	err = dataStore.DeleteClient(ctx, mockClient.ID)
+	assert.Equal(t, db.ErrClientInUse, err)
+
+	clientID := ""
+	err = dataStore.UpdateProject(ctx, project.ID, models.ProjectInfo{ClientID: &clientID})
+	assert.NoError(t, err)
+
+	err = dataStore.DeleteClient(ctx, mockClient.ID)
+	assert.NoError(t, err)
+
+	clients, err := dataStore.GetClients(ctx, owner, true)
+	assert.NoError(t, err)
+	assert.Len(t, clients, 0)
+
+	// test rate history is kept newest first
+	oldRate := models.Rate{ID: ulid.New().Generate(), Owner: owner, Scope: models.RateScopeUser, HourlyRate: 10, EffectiveFrom: 100}
+	newRate := models.Rate{ID: ulid.New().Generate(), Owner: owner, Scope: models.RateScopeUser, HourlyRate: 20, EffectiveFrom: 200}
+	_, err = dataStore.CreateRate(ctx, &oldRate)
+	assert.NoError(t, err)
+	_, err = dataStore.CreateRate(ctx, &newRate)
+	assert.NoError(t, err)
+
+	rates, err := dataStore.GetRates(ctx, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.Rate{&newRate, &oldRate}, rates)
+}
+
+func TestMongoStore_Tags(t *testing.T) {
+	ctx := context.Background()
+	connectUri := "mongodb://localhost:" + mongoDbPort
+	dataStore, client, err := New(connectUri, "tracker")
+	assert.Nil(t, err)
+	assert.NotNil(t, client)
+
+	owner := ulid.New().Generate()
+	for _, tags := range [][]string{{"design", "meeting"}, {"design"}, {"code", "review"}} {
+		mockSession := mockData.Session
+		mockSession.ID = ulid.New().Generate()
+		mockSession.Owner = owner
+		mockSession.Tags = tags
+		_, err = dataStore.CreateSession(ctx, &mockSession)
+		assert.NoError(t, err)
+	}
+
+	tags, err := dataStore.GetTags(ctx, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.TagCount{
+		{Name: "design", Count: 2},
+		{Name: "code", Count: 1},
+		{Name: "meeting", Count: 1},
+		{Name: "review", Count: 1},
+	}, tags)
+
+	// test tag filters
+	sessions, err := dataStore.GetSessions(ctx, owner, models.SessionFilter{AnyTags: []string{"meeting", "code"}})
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+
+	sessions, err = dataStore.GetSessions(ctx, owner, models.SessionFilter{AllTags: []string{"design", "meeting"}})
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+
+	// test merge tags, sessions with both tags keep a single copy
+	count, err := dataStore.MergeTags(ctx, owner, []string{"meeting", "review"}, "design")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	tags, err = dataStore.GetTags(ctx, owner)
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.TagCount{
+		{Name: "design", Count: 3},
+		{Name: "code", Count: 1},
+	}, tags)
+}
+
+func TestMongoStore_GetSessionsInRange(t *testing.T) {
+	ctx := context.Background()
+	connectUri := "mongodb://localhost:" + mongoDbPort
+	dataStore, client, err := New(connectUri, "tracker")
+	assert.Nil(t, err)
+	assert.NotNil(t, client)
+
+	owner := ulid.New().Generate()
+	for _, span := range [][2]int64{{100, 200}, {250, 350}, {400, 500}} {
+		mockSession := mockData.Session
+		mockSession.ID = ulid.New().Generate()
+		mockSession.Owner = owner
+		mockSession.Start, mockSession.End = span[0], span[1]
+		_, err = dataStore.CreateSession(ctx, &mockSession)
+		assert.NoError(t, err)
+	}
+
+	var tests = []struct {
+		name           string
+		filter         models.SessionFilter
+		expectedLength int
+	}{
+		{
+			name:           "Get sessions inside the range",
+			filter:         models.SessionFilter{From: 240, To: 360},
+			expectedLength: 1,
+		},
+		{
+			name:           "Get sessions overlapping the range boundaries",
+			filter:         models.SessionFilter{From: 150, To: 450},
+			expectedLength: 3,
+		},
+		{
+			name:           "Get sessions with an open ended range",
+			filter:         models.SessionFilter{From: 300},
+			expectedLength: 2,
+		},
+		{
+			name:           "Test sessions touching the range are excluded",
+			filter:         models.SessionFilter{From: 200, To: 250},
+			expectedLength: 0,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			sessions, err := dataStore.GetSessions(ctx, owner, testCase.filter)
+			assert.Nil(t, err)
+			assert.Equal(t, testCase.expectedLength, len(sessions))
+		})
+	}
+}
+
+func TestMongoStore_GetSessionsPage(t *testing.T) {
+	ctx := context.Background()
+	connectUri := "mongodb://localhost:" + mongoDbPort
+	dataStore, client, err := New(connectUri, "tracker")
+	assert.Nil(t, err)
+	assert.NotNil(t, client)
+
+	// seed five sessions, two of them share the same ts
+	owner := ulid.New().Generate()
+	var ids []string
+	for i, ts := range []int64{500, 400, 400, 200, 100} {
+		mockSession := mockData.Session
+		mockSession.ID = fmt.Sprintf("session%d", 9-i)
+		mockSession.Owner = owner
+		mockSession.Ts = ts
+		_, err = dataStore.CreateSession(ctx, &mockSession)
+		assert.NoError(t, err)
+		ids = append(ids, mockSession.ID)
+	}
+	pageIds := func(page *models.SessionPage) []string {
+		var pageIds []string
+		for _, s := range page.Sessions {
+			pageIds = append(pageIds, s.ID)
+		}
+		return pageIds
+	}
+
+	// page forward
+	page, err := dataStore.GetSessionsPage(ctx, owner, models.SessionFilter{}, models.Page{First: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), page.TotalCount)
+	assert.Equal(t, ids[:2], pageIds(page))
+	assert.True(t, page.HasNextPage)
+	assert.False(t, page.HasPreviousPage)
+
+	after := &models.Cursor{Ts: page.Sessions[1].Ts, ID: page.Sessions[1].ID}
+	page, err = dataStore.GetSessionsPage(ctx, owner, models.SessionFilter{}, models.Page{First: 2, After: after})
+	assert.NoError(t, err)
+	assert.Equal(t, ids[2:4], pageIds(page))
+	assert.True(t, page.HasNextPage)
+	assert.True(t, page.HasPreviousPage)
+
+	// page backward
+	before := &models.Cursor{Ts: page.Sessions[0].Ts, ID: page.Sessions[0].ID}
+	page, err = dataStore.GetSessionsPage(ctx, owner, models.SessionFilter{}, models.Page{Last: 3, Before: before})
+	assert.NoError(t, err)
+	assert.Equal(t, ids[:2], pageIds(page))
+	assert.False(t, page.HasPreviousPage)
+	assert.True(t, page.HasNextPage)
+}
+
+func TestMongoStore_GetReport(t *testing.T) {
+	ctx := context.Background()
+	connectUri := "mongodb://localhost:" + mongoDbPort
+	dataStore, client, err := New(connectUri, "tracker")
+	assert.Nil(t, err)
+	assert.NotNil(t, client)
+
+	owner := ulid.New().Generate()
+	_, err = dataStore.CreateRate(ctx, &models.Rate{ID: ulid.New().Generate(), Owner: owner, Scope: models.RateScopeUser, HourlyRate: 36})
+	assert.NoError(t, err)
+
+	// 2021-03-01 and 2021-03-02 at 23:30 UTC, which is the next day in Lagos
+	for _, s := range []struct {
+		start int64
+		tags  []string
+	}{
+		{1614641400, []string{"design", "code"}},
+		{1614727800, []string{"design"}},
+	} {
+		mockSession := mockData.Session
+		mockSession.ID = ulid.New().Generate()
+		mockSession.Owner = owner
+		mockSession.Start = s.start
+		mockSession.End = s.start + 3600
+		mockSession.Duration = 3600
+		mockSession.Billable = true
+		mockSession.Tags = s.tags
+		_, err = dataStore.CreateSession(ctx, &mockSession)
+		assert.NoError(t, err)
+	}
+
+	loc, _ := time.LoadLocation("Africa/Lagos")
+	rows, err := dataStore.GetReport(ctx, owner, models.ReportQuery{
+		From:     1614556800,
+		To:       1617235200,
+		GroupBy:  []string{models.ReportGroupDay, models.ReportGroupTag},
+		Location: loc,
+	})
+	assert.NoError(t, err)
+
+	totals := map[string]*models.ReportRow{}
+	for _, row := range rows {
+		totals[strings.Join(row.Keys, "/")] = row
+	}
+	assert.Equal(t, int64(7200), totals[""].Duration)
+	assert.Equal(t, int64(2), totals[""].SessionCount)
+	assert.Equal(t, 72.0, totals[""].Amount)
+	assert.Equal(t, int64(1), totals["2021-03-02"].SessionCount)
+	assert.Equal(t, int64(1), totals["2021-03-03"].SessionCount)
+	assert.Equal(t, int64(3600), totals["2021-03-02/code"].Duration)
+	assert.Equal(t, int64(3600), totals["2021-03-02/design"].Duration)
+	assert.Len(t, rows, 6)
+}
+
+func TestMongoStore_StreamSessions(t *testing.T) {
+	ctx := context.Background()
+	connectUri := "mongodb://localhost:" + mongoDbPort
+	dataStore, client, err := New(connectUri, "tracker")
+	assert.Nil(t, err)
+	assert.NotNil(t, client)
+
+	owner := ulid.New().Generate()
+	for _, start := range []int64{300, 100, 200} {
+		mockSession := mockData.Session
+		mockSession.ID = ulid.New().Generate()
+		mockSession.Owner = owner
+		mockSession.Start = start
+		mockSession.End = start + 50
+		_, err = dataStore.CreateSession(ctx, &mockSession)
+		assert.NoError(t, err)
+	}
+
+	var starts []int64
+	err = dataStore.StreamSessions(ctx, owner, models.SessionFilter{From: 150}, func(s *models.Session) error {
+		starts = append(starts, s.Start)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{200, 300}, starts)
+
+	// iteration stops at the first error
+	stop := fmt.Errorf("stop")
+	calls := 0
+	err = dataStore.StreamSessions(ctx, owner, models.SessionFilter{}, func(s *models.Session) error {
+		calls++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestMongoStore_ImportedSessions(t *testing.T) {
+	ctx := context.Background()
+	connectUri := "mongodb://localhost:" + mongoDbPort
+	dataStore, client, err := New(connectUri, "tracker")
+	assert.Nil(t, err)
+	assert.NotNil(t, client)
+
+	owner := ulid.New().Generate()
+	var sessions []*models.Session
+	for _, hash := range []string{"hash1", "hash2"} {
+		mockSession := mockData.Session
+		mockSession.ID = ulid.New().Generate()
+		mockSession.Owner = owner
+		mockSession.ImportHash = hash
+		sessions = append(sessions, &mockSession)
+	}
+	assert.NoError(t, dataStore.CreateSessions(ctx, sessions))
+
+	stored, err := dataStore.GetSessions(ctx, owner, models.SessionFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, stored, 2)
+
+	hashes, err := dataStore.GetImportedHashes(ctx, owner, []string{"hash2", "hash3"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hash2"}, hashes)
+
+	// hashes are scoped to the owner
+	hashes, err = dataStore.GetImportedHashes(ctx, ulid.New().Generate(), []string{"hash1"})
+	assert.NoError(t, err)
+	assert.Empty(t, hashes)
+}
+
+func TestMongoStore_Drafts(t *testing.T) {
+	ctx := context.Background()
+	connectUri := "mongodb://localhost:" + mongoDbPort
+	dataStore, client, err := New(connectUri, "tracker")
+	assert.Nil(t, err)
+	assert.NotNil(t, client)
+
+	owner := ulid.New().Generate()
+	var ids []string
+	for _, draft := range []bool{true, true, false} {
+		mockSession := mockData.Session
+		mockSession.ID = ulid.New().Generate()
+		mockSession.Owner = owner
+		mockSession.Draft = draft
+		_, err = dataStore.CreateSession(ctx, &mockSession)
+		assert.NoError(t, err)
+		ids = append(ids, mockSession.ID)
+	}
+
+	// drafts are hidden from the sessions until they are confirmed
+	sessions, err := dataStore.GetSessions(ctx, owner, models.SessionFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+
+	drafts, err := dataStore.GetDraftSessions(ctx, owner)
+	assert.NoError(t, err)
+	assert.Len(t, drafts, 2)
+
+	confirmed, _, err := dataStore.ConfirmDrafts(ctx, owner, []string{ids[0], ids[2]}, models.OverlapWarn)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), confirmed)
+
+	sessions, err = dataStore.GetSessions(ctx, owner, models.SessionFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+}
+
+func TestMongoStore_GetUserByCalendarToken(t *testing.T) {
+	ctx := context.Background()
+	connectUri := "mongodb://localhost:" + mongoDbPort
+	dataStore, client, err := New(connectUri, "tracker")
+	assert.Nil(t, err)
+	assert.NotNil(t, client)
+
+	mockUser := mockData.User
+	mockUser.ID = ulid.New().Generate()
+	mockUser.Email = mockUser.ID + "@email.com"
+	_, err = dataStore.CreateUser(ctx, &mockUser)
+	assert.NoError(t, err)
+
+	token := ulid.New().Generate()
+	assert.NoError(t, dataStore.UpdateUser(ctx, mockUser.ID, models.UserInfo{CalendarToken: &token}))
+
+	user, err := dataStore.GetUserByCalendarToken(ctx, token)
+	assert.NoError(t, err)
+	assert.Equal(t, mockUser.ID, user.ID)
+
+	_, err = dataStore.GetUserByCalendarToken(ctx, "")
+	assert.Error(t, err)
+}
+
+func TestMongoStore_Conformance(t *testing.T) {
+	connectUri := "mongodb://localhost:" + mongoDbPort
+	dataStore, client, err := New(connectUri, "tracker")
+	assert.Nil(t, err)
+	assert.NotNil(t, client)
+
+	dbtest.RunSuite(t, dataStore)
+}
+
+func TestMongoStore_Migrate(t *testing.T) {
+	ctx := context.Background()
+	connectUri := "mongodb://localhost:" + mongoDbPort
+	dataStore, client, err := New(connectUri, "tracker")
+	assert.Nil(t, err)
+	assert.NotNil(t, client)
+
+	// migrating again is a no-op
+	database := client.Database(dbName)
+	assert.NoError(t, migrate(ctx, database))
+	count, err := database.Collection(migrationsCollection).CountDocuments(ctx, bson.M{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(migrations)), count)
+
+	// emails are unique
+	mockUser := mockData.User
+	mockUser.ID = ulid.New().Generate()
+	mockUser.Email = mockUser.ID + "@email.com"
+	_, err = dataStore.CreateUser(ctx, &mockUser)
+	assert.NoError(t, err)
+
+	duplicate := mockUser
+	duplicate.ID = ulid.New().Generate()
+	_, err = dataStore.CreateUser(ctx, &duplicate)
+	assert.Equal(t, db.ErrEmailExists, err)
+
+	// users saved before verification existed are verified, later ones are not
+	old := ulid.New().Generate()
+	_, err = database.Collection(usersCollection).InsertOne(ctx, bson.M{"id": old, "email": old + "@email.com"})
+	assert.NoError(t, err)
+	assert.NoError(t, verifyExistingUsers(ctx, database))
+	got, err := dataStore.GetUser(ctx, old)
+	assert.NoError(t, err)
+	assert.True(t, got.EmailVerified)
+	got, err = dataStore.GetUser(ctx, mockUser.ID)
+	assert.NoError(t, err)
+	assert.False(t, got.EmailVerified)
+
+	// the email index is not built over duplicate emails, the error names them
+	fresh := client.Database("duplicates" + ulid.New().Generate())
+	defer fresh.Drop(ctx)
+	_, err = fresh.Collection(usersCollection).InsertMany(ctx, []interface{}{
+		bson.M{"id": "first", "email": "shared@email.com"},
+		bson.M{"id": "second", "email": "shared@email.com"},
+	})
+	assert.NoError(t, err)
+	err = migrate(ctx, fresh)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "shared@email.com (2 users)")
+	}
+
+	// the validator rejects sessions without an owner
+	_, err = database.Collection(sessionCollection).InsertOne(ctx, bson.M{"id": ulid.New().Generate(), "title": "no owner"})
+	assert.Error(t, err)
+}
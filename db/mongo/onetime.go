@@ -0,0 +1,67 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (m mongoStore) CreateOneTimeToken(ctx context.Context, token *models.OneTimeToken) error {
+	_, err := m.col(onetimeCollection).InsertOne(ctx, token)
+	return err
+}
+
+func (m mongoStore) GetOneTimeToken(ctx context.Context, id string) (*models.OneTimeToken, error) {
+	token := &models.OneTimeToken{}
+	err := m.col(onetimeCollection).FindOne(ctx, bson.M{"id": id}).Decode(token)
+	if err == mongo.ErrNoDocuments {
+		return nil, db.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// UseOneTimeToken marks the token used with a conditional update, so of two concurrent uses only one succeeds
+func (m mongoStore) UseOneTimeToken(ctx context.Context, id, purpose string, now int64) (*models.OneTimeToken, error) {
+	token := &models.OneTimeToken{}
+	err := m.col(onetimeCollection).FindOneAndUpdate(ctx,
+		bson.M{"id": id, "purpose": purpose, "used": false, "expiresat": bson.M{"$gt": now}},
+		bson.M{"$set": bson.M{"used": true}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(token)
+	if err == mongo.ErrNoDocuments {
+		return nil, m.useErr(ctx, id, purpose, now)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// useErr returns why the token could not be used
+func (m mongoStore) useErr(ctx context.Context, id, purpose string, now int64) error {
+	token := &models.OneTimeToken{}
+	err := m.col(onetimeCollection).FindOne(ctx, bson.M{"id": id}).Decode(token)
+	if err == mongo.ErrNoDocuments {
+		return db.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := db.CheckOneTimeToken(token, purpose, now); err != nil {
+		return err
+	}
+	// the token changed between the update and the read
+	return db.ErrTokenUsed
+}
+
+func (m mongoStore) DeleteOneTimeTokens(ctx context.Context, owner, purpose string) error {
+	_, err := m.col(onetimeCollection).DeleteMany(ctx, bson.M{"owner": owner, "purpose": purpose})
+	return err
+}
@@ -0,0 +1,104 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/victor-nach/time-tracker/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetSessionsPage returns a window of the most recent first sessions, ordered by ts and id.
+// Paging happens in the database, one more session than requested is read to know if more exist
+func (m mongoStore) GetSessionsPage(ctx context.Context, owner string, filter models.SessionFilter, page models.Page) (*models.SessionPage, error) {
+	query, err := m.sessionsQuery(owner, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := m.col(sessionCollection).CountDocuments(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var conditions bson.A
+	if page.After != nil {
+		conditions = append(conditions, afterCursor(page.After))
+	}
+	if page.Before != nil {
+		conditions = append(conditions, beforeCursor(page.Before))
+	}
+	pageQuery := bson.M{"$and": append(bson.A{query}, conditions...)}
+
+	backward := page.Last > 0 && page.First == 0
+	limit, order := page.First, -1
+	if backward {
+		limit, order = page.Last, 1
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "ts", Value: order}, {Key: "id", Value: order}}).
+		SetLimit(int64(limit + 1))
+	cursor, err := m.col(sessionCollection).Find(ctx, pageQuery, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []*models.Session{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+
+	hasMore := len(sessions) > limit
+	if hasMore {
+		sessions = sessions[:limit]
+	}
+
+	result := &models.SessionPage{TotalCount: total}
+	if backward {
+		// restore the most recent first order
+		for i, j := 0, len(sessions)-1; i < j; i, j = i+1, j-1 {
+			sessions[i], sessions[j] = sessions[j], sessions[i]
+		}
+		result.HasPreviousPage = hasMore
+		if page.Before != nil {
+			result.HasNextPage, err = m.sessionsExist(ctx, query, bson.M{"$nor": bson.A{beforeCursor(page.Before)}})
+		}
+	} else {
+		result.HasNextPage = hasMore
+		if page.After != nil {
+			result.HasPreviousPage, err = m.sessionsExist(ctx, query, bson.M{"$nor": bson.A{afterCursor(page.After)}})
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result.Sessions = sessions
+	return result, nil
+}
+
+// afterCursor matches the sessions that come after the cursor in the most recent first order
+func afterCursor(c *models.Cursor) bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{"ts": bson.M{"$lt": c.Ts}},
+		bson.M{"ts": c.Ts, "id": bson.M{"$lt": c.ID}},
+	}}
+}
+
+// beforeCursor matches the sessions that come before the cursor in the most recent first order
+func beforeCursor(c *models.Cursor) bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{"ts": bson.M{"$gt": c.Ts}},
+		bson.M{"ts": c.Ts, "id": bson.M{"$gt": c.ID}},
+	}}
+}
+
+func (m mongoStore) sessionsExist(ctx context.Context, query, condition bson.M) (bool, error) {
+	count, err := m.col(sessionCollection).CountDocuments(ctx, bson.M{"$and": bson.A{query, condition}},
+		options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,102 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (m mongoStore) CreateProject(ctx context.Context, project *models.Project) (*models.Project, error) {
+	_, err := m.col(projectsCollection).
+		InsertOne(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+func (m mongoStore) GetProject(ctx context.Context, id, owner string) (*models.Project, error) {
+	project := &models.Project{}
+	query := bson.M{
+		"id":    id,
+		"owner": owner,
+	}
+	err := m.col(projectsCollection).FindOne(ctx, query).Decode(project)
+	if err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+func (m mongoStore) GetProjects(ctx context.Context, owner string, includeArchived bool) ([]*models.Project, error) {
+	query := bson.M{"owner": owner}
+	if !includeArchived {
+		query["archived"] = false
+	}
+
+	findOptions := options.Find().SetSort(bson.M{"name": 1})
+	cursor, err := m.col(projectsCollection).Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	projects := []*models.Project{}
+	if err := cursor.All(ctx, &projects); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+func (m mongoStore) UpdateProject(ctx context.Context, id string, info models.ProjectInfo) error {
+	filter := bson.M{
+		"id": id,
+	}
+	setQuery := bson.M{}
+
+	if info.ClientID != nil {
+		setQuery["clientid"] = *info.ClientID
+	}
+	if info.ClientHistory != nil {
+		setQuery["clienthistory"] = *info.ClientHistory
+	}
+	if info.Name != nil {
+		setQuery["name"] = *info.Name
+	}
+	if info.Description != nil {
+		setQuery["description"] = *info.Description
+	}
+	if info.Archived != nil {
+		setQuery["archived"] = *info.Archived
+	}
+
+	query := bson.M{
+		"$set": setQuery,
+	}
+
+	_, err := m.col(projectsCollection).UpdateOne(ctx, filter, query)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteProject only deletes the project once no session references it. Standalone servers have no
+// transactions, so a session assigned between the count and the delete can still miss its project,
+// but a failed check never removes the project
+func (m mongoStore) DeleteProject(ctx context.Context, id string) error {
+	count, err := m.col(sessionCollection).CountDocuments(ctx, bson.M{"projectid": id}, options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return db.ErrProjectInUse
+	}
+
+	if _, err := m.col(projectsCollection).DeleteOne(ctx, bson.M{"id": id}); err != nil {
+		return err
+	}
+	return nil
+}
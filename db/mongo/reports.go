@@ -0,0 +1,186 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/victor-nach/time-tracker/lib/billing"
+	"github.com/victor-nach/time-tracker/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GetReport aggregates the sessions that start in the range of the query. A facet is computed
+// for every prefix of the groups so each level of the report has exact totals, even when
+// sessions with several tags are counted in more than one tag bucket
+func (m mongoStore) GetReport(ctx context.Context, owner string, query models.ReportQuery) ([]*models.ReportRow, error) {
+	rates, err := m.GetRates(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	projects, err := m.GetProjects(ctx, owner, true)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := query.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	timezone := loc.String()
+
+	match := bson.M{
+		"owner":   owner,
+		"running": bson.M{"$ne": true},
+		"draft":   bson.M{"$ne": true},
+		"start":   bson.M{"$gte": query.From, "$lt": query.To},
+	}
+	// amounts are rounded per session half away from zero, the same way billing.Round does
+	hourly := bson.M{"$multiply": bson.A{bson.M{"$divide": bson.A{"$duration", 3600}}, rateExpr(billing.Rules(rates, projects))}}
+	amount := bson.M{"$cond": bson.A{
+		"$billable",
+		bson.M{"$divide": bson.A{bson.M{"$floor": bson.M{"$add": bson.A{bson.M{"$multiply": bson.A{hourly, 100}}, 0.5}}}, 100}},
+		0,
+	}}
+
+	facets := bson.M{}
+	for level := 0; level <= len(query.GroupBy); level++ {
+		var stages bson.A
+		keys := bson.M{}
+		for i, group := range query.GroupBy[:level] {
+			if group == models.ReportGroupTag {
+				stages = append(stages, bson.M{"$unwind": bson.M{"path": "$tags", "preserveNullAndEmptyArrays": true}})
+			}
+			expr, err := groupExpr(group, timezone, query.WeekStart, projects)
+			if err != nil {
+				return nil, err
+			}
+			keys["k"+strconv.Itoa(i)] = expr
+		}
+		stages = append(stages, bson.M{"$group": bson.M{
+			"_id":      keys,
+			"duration": bson.M{"$sum": "$duration"},
+			"count":    bson.M{"$sum": 1},
+			"amount":   bson.M{"$sum": "$amount"},
+		}})
+		facets[strconv.Itoa(level)] = stages
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$addFields", Value: bson.M{
+			"date":   bson.M{"$toDate": bson.M{"$multiply": bson.A{"$start", 1000}}},
+			"amount": amount,
+		}}},
+		{{Key: "$facet", Value: facets}},
+	}
+	cursor, err := m.col(sessionCollection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []map[string][]struct {
+		Keys     map[string]string `bson:"_id"`
+		Duration int64             `bson:"duration"`
+		Count    int64             `bson:"count"`
+		Amount   float64           `bson:"amount"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	var rows []*models.ReportRow
+	for _, result := range results {
+		for level := 0; level <= len(query.GroupBy); level++ {
+			for _, r := range result[strconv.Itoa(level)] {
+				keys := make([]string, level)
+				for i := range keys {
+					keys[i] = r.Keys["k"+strconv.Itoa(i)]
+				}
+				rows = append(rows, &models.ReportRow{
+					Keys:         keys,
+					Duration:     r.Duration,
+					SessionCount: r.Count,
+					Amount:       billing.Round(r.Amount),
+				})
+			}
+		}
+	}
+	return rows, nil
+}
+
+// groupExpr returns the expression for the bucket key of a session
+func groupExpr(group, timezone string, weekStart time.Weekday, projects []*models.Project) (interface{}, error) {
+	switch group {
+	case models.ReportGroupDay:
+		return bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$date", "timezone": timezone}}, nil
+	case models.ReportGroupMonth:
+		return bson.M{"$dateToString": bson.M{"format": "%Y-%m", "date": "$date", "timezone": timezone}}, nil
+	case models.ReportGroupWeek:
+		// the key is the first day of the week, worked out from the local date so it is safe across DST changes
+		daysSinceWeekStart := bson.M{"$mod": bson.A{
+			bson.M{"$add": bson.A{
+				bson.M{"$subtract": bson.A{bson.M{"$dayOfWeek": bson.M{"date": "$date", "timezone": timezone}}, 1}},
+				7 - int(weekStart),
+			}},
+			7,
+		}}
+		weekStartDate := bson.M{"$dateFromParts": bson.M{
+			"year":  bson.M{"$year": bson.M{"date": "$date", "timezone": timezone}},
+			"month": bson.M{"$month": bson.M{"date": "$date", "timezone": timezone}},
+			"day": bson.M{"$subtract": bson.A{
+				bson.M{"$dayOfMonth": bson.M{"date": "$date", "timezone": timezone}},
+				daysSinceWeekStart,
+			}},
+			"timezone": timezone,
+		}}
+		return bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": weekStartDate, "timezone": timezone}}, nil
+	case models.ReportGroupProject:
+		return bson.M{"$ifNull": bson.A{"$projectid", ""}}, nil
+	case models.ReportGroupTag:
+		return bson.M{"$ifNull": bson.A{"$tags", ""}}, nil
+	case models.ReportGroupClient:
+		// the client a session is billed to follows the client history of its project like billing.ClientAt
+		branches := bson.A{}
+		for _, p := range projects {
+			for _, span := range billing.ClientSpans(p) {
+				if span.ClientID == "" {
+					continue
+				}
+				cond := bson.A{bson.M{"$eq": bson.A{"$projectid", p.ID}}, bson.M{"$gte": bson.A{"$start", span.From}}}
+				if span.Until != 0 {
+					cond = append(cond, bson.M{"$lt": bson.A{"$start", span.Until}})
+				}
+				branches = append(branches, bson.M{"case": bson.M{"$and": cond}, "then": span.ClientID})
+			}
+		}
+		if len(branches) == 0 {
+			return "", nil
+		}
+		return bson.M{"$switch": bson.M{"branches": branches, "default": ""}}, nil
+	}
+	return nil, fmt.Errorf("unknown report group %q", group)
+}
+
+// rateExpr returns the expression for the hourly rate of a session, the branches follow
+// the precedence of the rules so the first matching branch holds the rate in effect
+func rateExpr(rules []billing.Rule) interface{} {
+	if len(rules) == 0 {
+		return 0
+	}
+
+	branches := bson.A{}
+	for _, rule := range rules {
+		cond := bson.A{bson.M{"$gte": bson.A{"$start", rule.From}}}
+		if rule.Until != 0 {
+			cond = append(cond, bson.M{"$lt": bson.A{"$start", rule.Until}})
+		}
+		if rule.ProjectIDs != nil {
+			cond = append(cond, bson.M{"$in": bson.A{"$projectid", rule.ProjectIDs}})
+		}
+		branches = append(branches, bson.M{"case": bson.M{"$and": cond}, "then": rule.HourlyRate})
+	}
+	return bson.M{"$switch": bson.M{"branches": branches, "default": 0}}
+}
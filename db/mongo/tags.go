@@ -0,0 +1,67 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/victor-nach/time-tracker/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (m mongoStore) GetTags(ctx context.Context, owner string) ([]*models.TagCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"owner": owner, "draft": bson.M{"$ne": true}}}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+	cursor, err := m.col(sessionCollection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []struct {
+		Name  string `bson:"_id"`
+		Count int64  `bson:"count"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	tags := make([]*models.TagCount, len(results))
+	for i, r := range results {
+		tags[i] = &models.TagCount{Name: r.Name, Count: r.Count}
+	}
+	return tags, nil
+}
+
+// MergeTags replaces the given tags with a single tag on every session of the owner
+// in one update, renaming a tag is a merge of a single tag
+func (m mongoStore) MergeTags(ctx context.Context, owner string, tags []string, into string) (int64, error) {
+	filter := bson.M{
+		"owner": owner,
+		"tags":  bson.M{"$in": tags},
+	}
+
+	// remove the merged tags and the target, then append the target once
+	removed := append(append([]string{}, tags...), into)
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"tags": bson.M{
+				"$concatArrays": bson.A{
+					bson.M{"$filter": bson.M{
+						"input": "$tags",
+						"cond":  bson.M{"$not": bson.A{bson.M{"$in": bson.A{"$$this", removed}}}},
+					}},
+					bson.A{into},
+				},
+			},
+		}}},
+	}
+
+	res, err := m.col(sessionCollection).UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
@@ -0,0 +1,77 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (m mongoStore) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	_, err := m.col(tokensCollection).InsertOne(ctx, token)
+	return err
+}
+
+func (m mongoStore) GetRefreshToken(ctx context.Context, id string) (*models.RefreshToken, error) {
+	token := &models.RefreshToken{}
+	err := m.col(tokensCollection).FindOne(ctx, bson.M{"id": id}).Decode(token)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// RotateRefreshToken marks the token used with a conditional update, so of two concurrent
+// rotations only one succeeds and the other is treated as reuse, which revokes the family
+func (m mongoStore) RotateRefreshToken(ctx context.Context, id string, next *models.RefreshToken) error {
+	current := &models.RefreshToken{}
+	err := m.col(tokensCollection).FindOneAndUpdate(ctx,
+		bson.M{"id": id, "owner": next.Owner, "used": false, "revoked": false},
+		bson.M{"$set": bson.M{"used": true}},
+	).Decode(current)
+	if err == mongo.ErrNoDocuments {
+		return m.rotationErr(ctx, id, next.Owner)
+	}
+	if err != nil {
+		return err
+	}
+
+	db.NextRefreshToken(current, next)
+	return m.CreateRefreshToken(ctx, next)
+}
+
+// rotationErr returns why the token could not be rotated and revokes its family when it was reused
+func (m mongoStore) rotationErr(ctx context.Context, id, owner string) error {
+	current := &models.RefreshToken{}
+	err := m.col(tokensCollection).FindOne(ctx, bson.M{"id": id}).Decode(current)
+	if err == mongo.ErrNoDocuments {
+		return db.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	switch err := db.CheckRotation(current, owner); err {
+	case nil:
+		// tokens only ever become used or revoked, so the update can not have missed a valid token
+		return db.ErrNotFound
+	case db.ErrTokenReused:
+		if err := m.RevokeRefreshFamily(ctx, current.Family); err != nil {
+			return err
+		}
+		return err
+	default:
+		return err
+	}
+}
+
+func (m mongoStore) RevokeRefreshFamily(ctx context.Context, family string) error {
+	_, err := m.col(tokensCollection).UpdateMany(ctx, bson.M{"family": family}, bson.M{"$set": bson.M{"revoked": true}})
+	return err
+}
+
+func (m mongoStore) RevokeUserRefreshTokens(ctx context.Context, owner string) error {
+	_, err := m.col(tokensCollection).UpdateMany(ctx, bson.M{"owner": owner}, bson.M{"$set": bson.M{"revoked": true}})
+	return err
+}
@@ -0,0 +1,18 @@
+package db
+
+import "github.com/victor-nach/time-tracker/models"
+
+// CheckOneTimeToken returns why the token can not be redeemed for purpose at now, a token
+// of another purpose is not found so a reset token can not verify an email
+func CheckOneTimeToken(token *models.OneTimeToken, purpose string, now int64) error {
+	if token.Purpose != purpose {
+		return ErrNotFound
+	}
+	if token.Used {
+		return ErrTokenUsed
+	}
+	if token.ExpiresAt <= now {
+		return ErrTokenExpired
+	}
+	return nil
+}
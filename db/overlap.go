@@ -0,0 +1,123 @@
+package db
+
+import "github.com/victor-nach/time-tracker/models"
+
+// OverlapRange returns the filter matching the saved sessions that can overlap the session,
+// stores pass them to ApplyOverlapPolicy as candidates
+func OverlapRange(session *models.Session) models.SessionFilter {
+	return models.SessionFilter{From: session.Start, To: session.End}
+}
+
+// ApplyOverlapPolicy checks the session against candidates, saved sessions of the same owner,
+// and returns the ones it tracked time with. The reject policy fails with ErrSessionOverlap,
+// trim removes the shared time from the session and fails when none is left, any other policy
+// only reports the overlaps
+func ApplyOverlapPolicy(session *models.Session, candidates []*models.Session, policy string) ([]*models.Session, error) {
+	conflicts := []*models.Session{}
+	for _, c := range candidates {
+		if c.ID != session.ID && sharedTime(session, c).Duration > 0 {
+			conflicts = append(conflicts, c)
+		}
+	}
+	if len(conflicts) == 0 {
+		return conflicts, nil
+	}
+
+	switch policy {
+	case models.OverlapReject:
+		return conflicts, ErrSessionOverlap
+	case models.OverlapTrim:
+		if !trimSession(session, conflicts) {
+			return conflicts, ErrSessionOverlap
+		}
+	}
+	return conflicts, nil
+}
+
+// FindOverlaps returns every pair of sessions that tracked the same time, ordered by the start
+// of the later session. The sessions must be sorted by start
+func FindOverlaps(sessions []*models.Session) []*models.Overlap {
+	overlaps := []*models.Overlap{}
+	var open []*models.Session
+	for _, s := range sessions {
+		// a session that ended before this one started can not overlap it or any later one
+		stillOpen := open[:0]
+		for _, o := range open {
+			if o.End > s.Start {
+				stillOpen = append(stillOpen, o)
+			}
+		}
+		open = stillOpen
+
+		for _, o := range open {
+			if overlap := sharedTime(o, s); overlap.Duration > 0 {
+				overlaps = append(overlaps, overlap)
+			}
+		}
+		open = append(open, s)
+	}
+	return overlaps
+}
+
+// sharedTime returns the time both sessions were tracking, breaks between segments excluded
+func sharedTime(first, second *models.Session) *models.Overlap {
+	overlap := &models.Overlap{First: first, Second: second}
+	for _, a := range first.Intervals() {
+		for _, b := range second.Intervals() {
+			start, end := a.Start, a.End
+			if b.Start > start {
+				start = b.Start
+			}
+			if b.End < end {
+				end = b.End
+			}
+			if end <= start {
+				continue
+			}
+			if overlap.Duration == 0 || start < overlap.Start {
+				overlap.Start = start
+			}
+			if end > overlap.End {
+				overlap.End = end
+			}
+			overlap.Duration += end - start
+		}
+	}
+	return overlap
+}
+
+// trimSession cuts the time tracked by the conflicts out of the segments of the session,
+// it returns false when nothing is left
+func trimSession(session *models.Session, conflicts []*models.Session) bool {
+	pieces := session.Intervals()
+	for _, c := range conflicts {
+		for _, taken := range c.Intervals() {
+			if taken.End <= taken.Start {
+				continue
+			}
+			var left []models.Segment
+			for _, p := range pieces {
+				if taken.End <= p.Start || taken.Start >= p.End {
+					left = append(left, p)
+					continue
+				}
+				if p.Start < taken.Start {
+					left = append(left, models.Segment{Start: p.Start, End: taken.Start})
+				}
+				if taken.End < p.End {
+					left = append(left, models.Segment{Start: taken.End, End: p.End})
+				}
+			}
+			pieces = left
+		}
+	}
+	if len(pieces) == 0 {
+		return false
+	}
+
+	session.Segments = pieces
+	session.Start = pieces[0].Start
+	session.End = pieces[len(pieces)-1].End
+	session.Duration = session.TotalDuration()
+	return true
+}
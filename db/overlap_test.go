@@ -0,0 +1,108 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+func TestFindOverlaps(t *testing.T) {
+	long := &models.Session{ID: "long", Start: 0, End: 1000}
+	first := &models.Session{ID: "first", Start: 100, End: 200}
+	second := &models.Session{ID: "second", Start: 300, End: 400}
+	// paused from 1100 to 1900, the next session is logged during the break
+	paused := &models.Session{ID: "paused", Start: 900, End: 2000, Segments: []models.Segment{{Start: 900, End: 1100}, {Start: 1900, End: 2000}}}
+	inBreak := &models.Session{ID: "inBreak", Start: 1200, End: 1800}
+
+	overlaps := FindOverlaps([]*models.Session{long, first, second, paused, inBreak})
+	assert.Equal(t, []*models.Overlap{
+		{First: long, Second: first, Start: 100, End: 200, Duration: 100},
+		{First: long, Second: second, Start: 300, End: 400, Duration: 100},
+		{First: long, Second: paused, Start: 900, End: 1000, Duration: 100},
+	}, overlaps)
+
+	assert.Empty(t, FindOverlaps(nil))
+}
+
+func TestApplyOverlapPolicy(t *testing.T) {
+	saved := []*models.Session{
+		{ID: "morning", Start: 100, End: 200},
+		{ID: "afternoon", Start: 300, End: 400},
+	}
+
+	var tests = []struct {
+		name      string
+		policy    string
+		session   models.Session
+		conflicts int
+		err       error
+		segments  []models.Segment
+	}{
+		{
+			name:    "Session without overlaps is unchanged",
+			policy:  models.OverlapReject,
+			session: models.Session{ID: "new", Start: 200, End: 300},
+		},
+		{
+			name:      "Reject policy fails",
+			policy:    models.OverlapReject,
+			session:   models.Session{ID: "new", Start: 150, End: 250},
+			conflicts: 1,
+			err:       ErrSessionOverlap,
+		},
+		{
+			name:      "Warn policy reports the overlaps",
+			policy:    models.OverlapWarn,
+			session:   models.Session{ID: "new", Start: 150, End: 350},
+			conflicts: 2,
+		},
+		{
+			name:      "Empty policy warns",
+			policy:    "",
+			session:   models.Session{ID: "new", Start: 150, End: 350},
+			conflicts: 2,
+		},
+		{
+			name:      "Trim policy splits the session around saved ones",
+			policy:    models.OverlapTrim,
+			session:   models.Session{ID: "new", Start: 50, End: 450},
+			conflicts: 2,
+			segments:  []models.Segment{{Start: 50, End: 100}, {Start: 200, End: 300}, {Start: 400, End: 450}},
+		},
+		{
+			name:      "Trim policy keeps the breaks of the session",
+			policy:    models.OverlapTrim,
+			session:   models.Session{ID: "new", Start: 150, End: 350, Segments: []models.Segment{{Start: 150, End: 250}, {Start: 280, End: 350}}},
+			conflicts: 2,
+			segments:  []models.Segment{{Start: 200, End: 250}, {Start: 280, End: 300}},
+		},
+		{
+			name:      "Trim policy fails when nothing is left",
+			policy:    models.OverlapTrim,
+			session:   models.Session{ID: "new", Start: 120, End: 180},
+			conflicts: 1,
+			err:       ErrSessionOverlap,
+		},
+		{
+			name:    "The session does not overlap itself",
+			policy:  models.OverlapReject,
+			session: models.Session{ID: "morning", Start: 100, End: 200},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			session := testCase.session
+			conflicts, err := ApplyOverlapPolicy(&session, saved, testCase.policy)
+			assert.Equal(t, testCase.err, err)
+			assert.Len(t, conflicts, testCase.conflicts)
+			assert.Equal(t, testCase.segments, session.Segments)
+			if testCase.segments != nil {
+				assert.Equal(t, testCase.segments[0].Start, session.Start)
+				assert.Equal(t, testCase.segments[len(testCase.segments)-1].End, session.End)
+				assert.Equal(t, session.TotalDuration(), session.Duration)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"sort"
+
+	"github.com/victor-nach/time-tracker/models"
+)
+
+// PageSessions returns the window of sessions selected by the page,
+// the sessions must be sorted with SortMostRecent
+func PageSessions(sessions []*models.Session, page models.Page) *models.SessionPage {
+	// the window lies between the first session after the cursor and the last one before the other cursor
+	from, to := 0, len(sessions)
+	if page.After != nil {
+		from = sort.Search(len(sessions), func(i int) bool { return comesAfter(sessions[i], page.After) })
+	}
+	if page.Before != nil {
+		to = sort.Search(len(sessions), func(i int) bool { return !comesBefore(sessions[i], page.Before) })
+	}
+	if to < from {
+		to = from
+	}
+
+	result := &models.SessionPage{TotalCount: int64(len(sessions))}
+	window := sessions[from:to]
+	if page.Last > 0 && page.First == 0 {
+		if len(window) > page.Last {
+			window = window[len(window)-page.Last:]
+			result.HasPreviousPage = true
+		}
+		result.HasNextPage = page.Before != nil && to < len(sessions)
+	} else {
+		if len(window) > page.First {
+			window = window[:page.First]
+			result.HasNextPage = true
+		}
+		result.HasPreviousPage = page.After != nil && from > 0
+	}
+	result.Sessions = append([]*models.Session{}, window...)
+	return result
+}
+
+// comesAfter reports whether the session comes after the cursor in the most recent first order
+func comesAfter(s *models.Session, c *models.Cursor) bool {
+	return s.Ts < c.Ts || (s.Ts == c.Ts && s.ID < c.ID)
+}
+
+// comesBefore reports whether the session comes before the cursor in the most recent first order
+func comesBefore(s *models.Session, c *models.Cursor) bool {
+	return s.Ts > c.Ts || (s.Ts == c.Ts && s.ID > c.ID)
+}
@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/victor-nach/time-tracker/models"
+)
+
+const loginColumns = `id, owner, user_agent, ip, created_at, last_used_at`
+
+func (p *postgresStore) CreateLoginSession(ctx context.Context, session *models.LoginSession) error {
+	_, err := p.conn.ExecContext(ctx, `INSERT INTO login_sessions (`+loginColumns+`) VALUES ($1, $2, $3, $4, $5, $6)`,
+		session.ID, session.Owner, session.UserAgent, session.IP, session.CreatedAt, session.LastUsedAt)
+	return err
+}
+
+func (p *postgresStore) GetLoginSession(ctx context.Context, id, owner string) (*models.LoginSession, error) {
+	session := &models.LoginSession{}
+	err := p.conn.QueryRowContext(ctx, `SELECT `+loginColumns+` FROM login_sessions WHERE id = $1 AND owner = $2`, id, owner).
+		Scan(&session.ID, &session.Owner, &session.UserAgent, &session.IP, &session.CreatedAt, &session.LastUsedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return session, nil
+}
+
+// GetLoginSessions returns the login sessions of the owner, most recently used first
+func (p *postgresStore) GetLoginSessions(ctx context.Context, owner string) ([]*models.LoginSession, error) {
+	rows, err := p.conn.QueryContext(ctx, `SELECT `+loginColumns+` FROM login_sessions WHERE owner = $1
+		ORDER BY last_used_at DESC, id DESC`, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*models.LoginSession{}
+	for rows.Next() {
+		s := &models.LoginSession{}
+		if err := rows.Scan(&s.ID, &s.Owner, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastUsedAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+func (p *postgresStore) UpdateLoginSession(ctx context.Context, id string, info models.LoginSessionInfo) error {
+	u := update{}
+	if info.UserAgent != nil {
+		u.set("user_agent", *info.UserAgent)
+	}
+	if info.IP != nil {
+		u.set("ip", *info.IP)
+	}
+	if info.LastUsedAt != nil {
+		u.set("last_used_at", *info.LastUsedAt)
+	}
+	return u.exec(ctx, p.conn, "login_sessions", id)
+}
+
+func (p *postgresStore) DeleteLoginSession(ctx context.Context, id string) error {
+	_, err := p.conn.ExecContext(ctx, `DELETE FROM login_sessions WHERE id = $1`, id)
+	return err
+}
+
+func (p *postgresStore) DeleteLoginSessions(ctx context.Context, owner string) error {
+	_, err := p.conn.ExecContext(ctx, `DELETE FROM login_sessions WHERE owner = $1`, owner)
+	return err
+}
@@ -0,0 +1,224 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+)
+
+// migration is a versioned change of the schema, applied migrations are recorded in schema_migrations.
+// Migrations are append only, a released migration must never be edited
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create tables",
+		sql: `
+-- ids and names use the C collation so paging and sorting compare bytes like the other stores
+CREATE TABLE users (
+	id             TEXT PRIMARY KEY,
+	name           TEXT NOT NULL DEFAULT '',
+	email          TEXT NOT NULL,
+	password       TEXT NOT NULL DEFAULT '',
+	time_zone      TEXT NOT NULL DEFAULT '',
+	week_start     INTEGER NOT NULL DEFAULT 0,
+	calendar_token TEXT NOT NULL DEFAULT '',
+	ts             BIGINT NOT NULL DEFAULT 0
+);
+CREATE INDEX users_email_idx ON users (email);
+CREATE INDEX users_calendar_token_idx ON users (calendar_token) WHERE calendar_token <> '';
+
+CREATE TABLE clients (
+	id       TEXT PRIMARY KEY,
+	owner    TEXT NOT NULL,
+	name     TEXT COLLATE "C" NOT NULL DEFAULT '',
+	archived BOOLEAN NOT NULL DEFAULT FALSE,
+	ts       BIGINT NOT NULL DEFAULT 0
+);
+CREATE INDEX clients_owner_idx ON clients (owner);
+
+CREATE TABLE projects (
+	id          TEXT PRIMARY KEY,
+	owner       TEXT NOT NULL,
+	client_id   TEXT NOT NULL DEFAULT '',
+	name        TEXT COLLATE "C" NOT NULL DEFAULT '',
+	description TEXT NOT NULL DEFAULT '',
+	archived    BOOLEAN NOT NULL DEFAULT FALSE,
+	ts          BIGINT NOT NULL DEFAULT 0
+);
+CREATE INDEX projects_owner_idx ON projects (owner);
+CREATE INDEX projects_client_id_idx ON projects (client_id);
+
+CREATE TABLE rates (
+	id             TEXT PRIMARY KEY,
+	owner          TEXT NOT NULL,
+	scope          TEXT NOT NULL,
+	scope_id       TEXT NOT NULL DEFAULT '',
+	hourly_rate    DOUBLE PRECISION NOT NULL,
+	effective_from BIGINT NOT NULL DEFAULT 0,
+	ts             BIGINT NOT NULL DEFAULT 0
+);
+CREATE INDEX rates_owner_idx ON rates (owner, effective_from DESC);
+
+CREATE TABLE sessions (
+	id          TEXT COLLATE "C" PRIMARY KEY,
+	owner       TEXT NOT NULL,
+	title       TEXT NOT NULL DEFAULT '',
+	description TEXT NOT NULL DEFAULT '',
+	project_id  TEXT NOT NULL DEFAULT '',
+	tags        TEXT[],
+	billable    BOOLEAN NOT NULL DEFAULT FALSE,
+	start_ts    BIGINT NOT NULL DEFAULT 0,
+	end_ts      BIGINT NOT NULL DEFAULT 0,
+	duration    BIGINT NOT NULL DEFAULT 0,
+	segments    JSONB,
+	running     BOOLEAN NOT NULL DEFAULT FALSE,
+	paused      BOOLEAN NOT NULL DEFAULT FALSE,
+	draft       BOOLEAN NOT NULL DEFAULT FALSE,
+	import_hash TEXT NOT NULL DEFAULT '',
+	ts          BIGINT NOT NULL DEFAULT 0
+);
+CREATE INDEX sessions_owner_start_idx ON sessions (owner, start_ts);
+CREATE INDEX sessions_owner_ts_idx ON sessions (owner, ts DESC, id DESC);
+CREATE INDEX sessions_project_id_idx ON sessions (project_id) WHERE project_id <> '';
+CREATE INDEX sessions_tags_idx ON sessions USING GIN (tags);
+CREATE INDEX sessions_import_hash_idx ON sessions (owner, import_hash) WHERE import_hash <> '';
+-- a user can only have one running timer
+CREATE UNIQUE INDEX sessions_running_idx ON sessions (owner) WHERE running;
+`,
+	},
+	{
+		version: 2,
+		name:    "unique user emails",
+		sql: `
+-- sign up checks the email before inserting, the unique index closes the race between two sign ups
+DROP INDEX users_email_idx;
+CREATE UNIQUE INDEX users_email_key ON users (email);
+`,
+	},
+	{
+		version: 3,
+		name:    "user overlap policy",
+		sql:     `ALTER TABLE users ADD COLUMN overlap_policy TEXT NOT NULL DEFAULT ''`,
+	},
+	{
+		version: 4,
+		name:    "refresh tokens",
+		sql: `
+CREATE TABLE refresh_tokens (
+	id         TEXT PRIMARY KEY,
+	family     TEXT NOT NULL,
+	owner      TEXT NOT NULL,
+	expires_at BIGINT NOT NULL DEFAULT 0,
+	used       BOOLEAN NOT NULL DEFAULT FALSE,
+	revoked    BOOLEAN NOT NULL DEFAULT FALSE,
+	ts         BIGINT NOT NULL DEFAULT 0
+);
+CREATE INDEX refresh_tokens_family_idx ON refresh_tokens (family);
+`,
+	},
+	{
+		version: 5,
+		name:    "user token generation",
+		sql: `
+ALTER TABLE users ADD COLUMN token_generation BIGINT NOT NULL DEFAULT 0;
+CREATE INDEX refresh_tokens_owner_idx ON refresh_tokens (owner);
+`,
+	},
+	{
+		version: 6,
+		name:    "login sessions",
+		sql: `
+CREATE TABLE login_sessions (
+	id           TEXT COLLATE "C" PRIMARY KEY,
+	owner        TEXT NOT NULL,
+	user_agent   TEXT NOT NULL DEFAULT '',
+	ip           TEXT NOT NULL DEFAULT '',
+	created_at   BIGINT NOT NULL DEFAULT 0,
+	last_used_at BIGINT NOT NULL DEFAULT 0
+);
+CREATE INDEX login_sessions_owner_idx ON login_sessions (owner);
+`,
+	},
+	{
+		version: 7,
+		name:    "one time tokens",
+		sql: `
+CREATE TABLE one_time_tokens (
+	id         TEXT PRIMARY KEY,
+	owner      TEXT NOT NULL,
+	purpose    TEXT NOT NULL,
+	expires_at BIGINT NOT NULL DEFAULT 0,
+	used       BOOLEAN NOT NULL DEFAULT FALSE,
+	ts         BIGINT NOT NULL DEFAULT 0
+);
+CREATE INDEX one_time_tokens_owner_idx ON one_time_tokens (owner, purpose);
+`,
+	},
+	{
+		version: 8,
+		name:    "user email verified",
+		sql:     `ALTER TABLE users ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT FALSE`,
+	},
+	{
+		version: 9,
+		name:    "verify existing users",
+		// users who signed up before verification existed never got a link, users signing up after
+		// migration 8 was applied keep the value they have
+		sql: `
+UPDATE users SET email_verified = TRUE
+WHERE ts < (SELECT EXTRACT(EPOCH FROM applied_at) FROM schema_migrations WHERE version = 8)
+`,
+	},
+	{
+		version: 10,
+		name:    "project client history",
+		sql:     `ALTER TABLE projects ADD COLUMN client_history JSONB`,
+	},
+}
+
+// migrate applies the migrations that are not recorded yet, each in its own transaction.
+// An advisory lock keeps instances starting at the same time from migrating concurrently
+func migrate(ctx context.Context, conn *sql.DB) error {
+	for _, m := range migrations {
+		err := withTx(ctx, conn, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLock); err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, createMigrationsTable); err != nil {
+				return err
+			}
+
+			var applied bool
+			err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, m.version).
+				Scan(&applied)
+			if err != nil || applied {
+				return err
+			}
+
+			if _, err := tx.ExecContext(ctx, m.sql); err != nil {
+				return err
+			}
+			_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.version, m.name)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const createMigrationsTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	version    INTEGER PRIMARY KEY,
+	name       TEXT NOT NULL,
+	applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`
+
+// migrationLock is the advisory lock key held while migrating
+const migrationLock = 7260425
@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+const onetimeColumns = `id, owner, purpose, expires_at, used, ts`
+
+func (p *postgresStore) CreateOneTimeToken(ctx context.Context, token *models.OneTimeToken) error {
+	_, err := p.conn.ExecContext(ctx, `INSERT INTO one_time_tokens (`+onetimeColumns+`) VALUES ($1, $2, $3, $4, $5, $6)`,
+		token.ID, token.Owner, token.Purpose, token.ExpiresAt, token.Used, token.Ts)
+	return err
+}
+
+func (p *postgresStore) GetOneTimeToken(ctx context.Context, id string) (*models.OneTimeToken, error) {
+	token := &models.OneTimeToken{}
+	err := p.conn.QueryRowContext(ctx, `SELECT `+onetimeColumns+` FROM one_time_tokens WHERE id = $1`, id).
+		Scan(&token.ID, &token.Owner, &token.Purpose, &token.ExpiresAt, &token.Used, &token.Ts)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return token, nil
+}
+
+// UseOneTimeToken checks and marks the token used in a transaction holding the row lock of the token
+func (p *postgresStore) UseOneTimeToken(ctx context.Context, id, purpose string, now int64) (*models.OneTimeToken, error) {
+	token := &models.OneTimeToken{}
+	err := withTx(ctx, p.conn, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `SELECT `+onetimeColumns+` FROM one_time_tokens WHERE id = $1 FOR UPDATE`, id).
+			Scan(&token.ID, &token.Owner, &token.Purpose, &token.ExpiresAt, &token.Used, &token.Ts)
+		if err != nil {
+			return notFound(err)
+		}
+		if err := db.CheckOneTimeToken(token, purpose, now); err != nil {
+			return err
+		}
+		token.Used = true
+		_, err = tx.ExecContext(ctx, `UPDATE one_time_tokens SET used = TRUE WHERE id = $1`, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (p *postgresStore) DeleteOneTimeTokens(ctx context.Context, owner, purpose string) error {
+	_, err := p.conn.ExecContext(ctx, `DELETE FROM one_time_tokens WHERE owner = $1 AND purpose = $2`, owner, purpose)
+	return err
+}
@@ -0,0 +1,209 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/lib/clock"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+type postgresStore struct {
+	conn  *sql.DB
+	clock clock.Clock
+}
+
+// ensure postgresStore implements the datastore interface
+var _ db.Datastore = &postgresStore{}
+
+//New returns an instance of postgres store, pending migrations are applied before it is returned
+func New(dbUrl string) (db.Datastore, *sql.DB, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := sql.Open("postgres", dbUrl)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := conn.PingContext(ctx); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	if err := migrate(ctx, conn); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	return &postgresStore{conn: conn, clock: clock.New()}, conn, nil
+}
+
+const userColumns = `id, name, email, password, time_zone, week_start, calendar_token, overlap_policy, token_generation, email_verified, ts`
+
+func (p *postgresStore) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	_, err := p.conn.ExecContext(ctx, `INSERT INTO users (`+userColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		user.ID, user.Name, user.Email, user.Password, user.TimeZone, user.WeekStart, user.CalendarToken, user.OverlapPolicy, user.TokenGeneration, user.EmailVerified, user.Ts)
+	if isUniqueViolation(err, "users_email_key") {
+		return nil, db.ErrEmailExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (p *postgresStore) GetUser(ctx context.Context, id string) (*models.User, error) {
+	return p.getUser(ctx, `id = $1`, id)
+}
+
+func (p *postgresStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	return p.getUser(ctx, `email = $1`, email)
+}
+
+// GetUserByCalendarToken returns the user owning the hashed calendar feed token
+func (p *postgresStore) GetUserByCalendarToken(ctx context.Context, token string) (*models.User, error) {
+	if token == "" {
+		return nil, db.ErrNotFound
+	}
+	return p.getUser(ctx, `calendar_token = $1`, token)
+}
+
+func (p *postgresStore) getUser(ctx context.Context, where string, arg interface{}) (*models.User, error) {
+	user := &models.User{}
+	err := p.conn.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE `+where+` LIMIT 1`, arg).
+		Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.TimeZone, &user.WeekStart, &user.CalendarToken, &user.OverlapPolicy, &user.TokenGeneration, &user.EmailVerified, &user.Ts)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return user, nil
+}
+
+func (p *postgresStore) UpdateUser(ctx context.Context, id string, info models.UserInfo) error {
+	u := update{}
+	if info.Name != nil {
+		u.set("name", *info.Name)
+	}
+	if info.TimeZone != nil {
+		u.set("time_zone", *info.TimeZone)
+	}
+	if info.WeekStart != nil {
+		u.set("week_start", *info.WeekStart)
+	}
+	if info.CalendarToken != nil {
+		u.set("calendar_token", *info.CalendarToken)
+	}
+	if info.OverlapPolicy != nil {
+		u.set("overlap_policy", *info.OverlapPolicy)
+	}
+	if info.Password != nil {
+		u.set("password", *info.Password)
+	}
+	if info.EmailVerified != nil {
+		u.set("email_verified", *info.EmailVerified)
+	}
+	return u.exec(ctx, p.conn, "users", id)
+}
+
+func (p *postgresStore) GetTokenGeneration(ctx context.Context, id string) (int64, error) {
+	var generation int64
+	err := p.conn.QueryRowContext(ctx, `SELECT token_generation FROM users WHERE id = $1`, id).Scan(&generation)
+	return generation, notFound(err)
+}
+
+func (p *postgresStore) IncrementTokenGeneration(ctx context.Context, id string) (int64, error) {
+	var generation int64
+	err := p.conn.QueryRowContext(ctx, `UPDATE users SET token_generation = token_generation + 1 WHERE id = $1 RETURNING token_generation`, id).
+		Scan(&generation)
+	return generation, notFound(err)
+}
+
+// notFound maps a missing row to db.ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.ErrNotFound
+	}
+	return err
+}
+
+// isUniqueViolation reports whether err was caused by a duplicate key in the named unique index
+func isUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == constraint
+}
+
+// withTx runs fn in a transaction that is committed when fn succeeds and rolled back otherwise
+func withTx(ctx context.Context, conn *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// update collects the columns changed by a partial update
+type update struct {
+	sets []string
+	args []interface{}
+}
+
+func (u *update) set(column string, value interface{}) {
+	u.args = append(u.args, value)
+	u.sets = append(u.sets, column+" = $"+strconv.Itoa(len(u.args)))
+}
+
+// exec updates the row with the given id, nothing is done when no column changed
+func (u *update) exec(ctx context.Context, conn execer, table, id string) error {
+	if len(u.sets) == 0 {
+		return nil
+	}
+	args := append(u.args, id)
+	_, err := conn.ExecContext(ctx, `UPDATE `+table+` SET `+strings.Join(u.sets, ", ")+` WHERE id = $`+strconv.Itoa(len(args)), args...)
+	return err
+}
+
+// where builds a conjunction of conditions, ? in a condition is replaced by the next positional parameter
+type where struct {
+	conds []string
+	args  []interface{}
+}
+
+func (w *where) add(cond string, args ...interface{}) {
+	for _, arg := range args {
+		w.args = append(w.args, arg)
+		cond = strings.Replace(cond, "?", "$"+strconv.Itoa(len(w.args)), 1)
+	}
+	w.conds = append(w.conds, cond)
+}
+
+// clone returns a copy that more conditions can be added to without changing w
+func (w *where) clone() *where {
+	return &where{
+		conds: append([]string{}, w.conds...),
+		args:  append([]interface{}{}, w.args...),
+	}
+}
+
+func (w *where) String() string {
+	return strings.Join(w.conds, " AND ")
+}
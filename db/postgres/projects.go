@@ -0,0 +1,233 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+const projectColumns = `id, owner, client_id, name, description, archived, ts, client_history`
+
+func scanProject(row scanner) (*models.Project, error) {
+	p := &models.Project{}
+	var history []byte
+	err := row.Scan(&p.ID, &p.Owner, &p.ClientID, &p.Name, &p.Description, &p.Archived, &p.Ts, &history)
+	if err != nil {
+		return nil, err
+	}
+	if history != nil {
+		if err := json.Unmarshal(history, &p.ClientHistory); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// marshalClientHistory stores projects that never changed client as NULL so they read back as nil
+func marshalClientHistory(history []models.ClientLink) (interface{}, error) {
+	if history == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(history)
+	if err != nil {
+		return nil, err
+	}
+	// lib/pq sends []byte as bytea, jsonb needs text
+	return string(b), nil
+}
+
+func (p *postgresStore) CreateProject(ctx context.Context, project *models.Project) (*models.Project, error) {
+	history, err := marshalClientHistory(project.ClientHistory)
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.conn.ExecContext(ctx, `INSERT INTO projects (`+projectColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		project.ID, project.Owner, project.ClientID, project.Name, project.Description, project.Archived, project.Ts, history)
+	if err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+func (p *postgresStore) GetProject(ctx context.Context, id, owner string) (*models.Project, error) {
+	row := p.conn.QueryRowContext(ctx, `SELECT `+projectColumns+` FROM projects WHERE id = $1 AND owner = $2`, id, owner)
+	project, err := scanProject(row)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return project, nil
+}
+
+func (p *postgresStore) GetProjects(ctx context.Context, owner string, includeArchived bool) ([]*models.Project, error) {
+	rows, err := p.conn.QueryContext(ctx, `SELECT `+projectColumns+` FROM projects
+		WHERE owner = $1 AND ($2 OR NOT archived) ORDER BY name`, owner, includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := []*models.Project{}
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+	return projects, rows.Err()
+}
+
+func (p *postgresStore) UpdateProject(ctx context.Context, id string, info models.ProjectInfo) error {
+	u := update{}
+	if info.ClientID != nil {
+		u.set("client_id", *info.ClientID)
+	}
+	if info.ClientHistory != nil {
+		history, err := marshalClientHistory(*info.ClientHistory)
+		if err != nil {
+			return err
+		}
+		u.set("client_history", history)
+	}
+	if info.Name != nil {
+		u.set("name", *info.Name)
+	}
+	if info.Description != nil {
+		u.set("description", *info.Description)
+	}
+	if info.Archived != nil {
+		u.set("archived", *info.Archived)
+	}
+	return u.exec(ctx, p.conn, "projects", id)
+}
+
+// DeleteProject locks the project while checking for sessions so a session
+// cannot be moved to it between the check and the delete
+func (p *postgresStore) DeleteProject(ctx context.Context, id string) error {
+	return withTx(ctx, p.conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `SELECT 1 FROM projects WHERE id = $1 FOR UPDATE`, id); err != nil {
+			return err
+		}
+		var inUse bool
+		err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM sessions WHERE project_id = $1)`, id).Scan(&inUse)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return db.ErrProjectInUse
+		}
+		_, err = tx.ExecContext(ctx, `DELETE FROM projects WHERE id = $1`, id)
+		return err
+	})
+}
+
+const clientColumns = `id, owner, name, archived, ts`
+
+func scanClient(row scanner) (*models.Client, error) {
+	c := &models.Client{}
+	err := row.Scan(&c.ID, &c.Owner, &c.Name, &c.Archived, &c.Ts)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (p *postgresStore) CreateClient(ctx context.Context, client *models.Client) (*models.Client, error) {
+	_, err := p.conn.ExecContext(ctx, `INSERT INTO clients (`+clientColumns+`) VALUES ($1, $2, $3, $4, $5)`,
+		client.ID, client.Owner, client.Name, client.Archived, client.Ts)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+func (p *postgresStore) GetClient(ctx context.Context, id, owner string) (*models.Client, error) {
+	row := p.conn.QueryRowContext(ctx, `SELECT `+clientColumns+` FROM clients WHERE id = $1 AND owner = $2`, id, owner)
+	client, err := scanClient(row)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return client, nil
+}
+
+func (p *postgresStore) GetClients(ctx context.Context, owner string, includeArchived bool) ([]*models.Client, error) {
+	rows, err := p.conn.QueryContext(ctx, `SELECT `+clientColumns+` FROM clients
+		WHERE owner = $1 AND ($2 OR NOT archived) ORDER BY name`, owner, includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clients := []*models.Client{}
+	for rows.Next() {
+		client, err := scanClient(rows)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+	return clients, rows.Err()
+}
+
+func (p *postgresStore) UpdateClient(ctx context.Context, id string, info models.ClientInfo) error {
+	u := update{}
+	if info.Name != nil {
+		u.set("name", *info.Name)
+	}
+	if info.Archived != nil {
+		u.set("archived", *info.Archived)
+	}
+	return u.exec(ctx, p.conn, "clients", id)
+}
+
+// DeleteClient locks the client while checking for projects so a project
+// cannot be added to it between the check and the delete
+func (p *postgresStore) DeleteClient(ctx context.Context, id string) error {
+	return withTx(ctx, p.conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `SELECT 1 FROM clients WHERE id = $1 FOR UPDATE`, id); err != nil {
+			return err
+		}
+		var inUse bool
+		err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM projects WHERE client_id = $1)`, id).Scan(&inUse)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return db.ErrClientInUse
+		}
+		_, err = tx.ExecContext(ctx, `DELETE FROM clients WHERE id = $1`, id)
+		return err
+	})
+}
+
+const rateColumns = `id, owner, scope, scope_id, hourly_rate, effective_from, ts`
+
+func (p *postgresStore) CreateRate(ctx context.Context, rate *models.Rate) (*models.Rate, error) {
+	_, err := p.conn.ExecContext(ctx, `INSERT INTO rates (`+rateColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		rate.ID, rate.Owner, rate.Scope, rate.ScopeID, rate.HourlyRate, rate.EffectiveFrom, rate.Ts)
+	if err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+func (p *postgresStore) GetRates(ctx context.Context, owner string) ([]*models.Rate, error) {
+	rows, err := p.conn.QueryContext(ctx, `SELECT `+rateColumns+` FROM rates WHERE owner = $1 ORDER BY effective_from DESC`, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := []*models.Rate{}
+	for rows.Next() {
+		r := &models.Rate{}
+		if err := rows.Scan(&r.ID, &r.Owner, &r.Scope, &r.ScopeID, &r.HourlyRate, &r.EffectiveFrom, &r.Ts); err != nil {
+			return nil, err
+		}
+		rates = append(rates, r)
+	}
+	return rates, rows.Err()
+}
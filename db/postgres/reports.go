@@ -0,0 +1,29 @@
+package postgres
+
+import (
+	"context"
+	"github.com/victor-nach/time-tracker/lib/billing"
+	"github.com/victor-nach/time-tracker/lib/report"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+// GetReport aggregates the sessions that start in the range of the query, the (owner, start_ts)
+// index limits the read to the range and the buckets are worked out by report.Rows
+func (p *postgresStore) GetReport(ctx context.Context, owner string, query models.ReportQuery) ([]*models.ReportRow, error) {
+	rates, err := p.GetRates(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	projects, err := p.GetProjects(ctx, owner, true)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := querySessions(ctx, p.conn, `SELECT `+sessionColumns+` FROM sessions
+		WHERE owner = $1 AND start_ts >= $2 AND start_ts < $3 AND NOT running AND NOT draft`,
+		owner, query.From, query.To)
+	if err != nil {
+		return nil, err
+	}
+	return report.Rows(sessions, query, billing.NewCalculator(rates, projects), projects), nil
+}
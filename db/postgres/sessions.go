@@ -0,0 +1,414 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+
+	"github.com/lib/pq"
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+const sessionColumns = `id, owner, title, description, project_id, tags, billable, start_ts, end_ts, duration,
+	segments, running, paused, draft, import_hash, ts`
+
+// insertSession is the statement used for every new session, it takes sessionArgs
+const insertSession = `INSERT INTO sessions (` + sessionColumns + `)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`
+
+// sessionArgs returns the values of the session in the order of sessionColumns
+func sessionArgs(s *models.Session) ([]interface{}, error) {
+	segments, err := marshalSegments(s.Segments)
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{s.ID, s.Owner, s.Title, s.Description, s.ProjectID, pq.Array(s.Tags), s.Billable,
+		s.Start, s.End, s.Duration, segments, s.Running, s.Paused, s.Draft, s.ImportHash, s.Ts}, nil
+}
+
+// scanner is satisfied by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSession(row scanner) (*models.Session, error) {
+	s := &models.Session{}
+	var segments []byte
+	err := row.Scan(&s.ID, &s.Owner, &s.Title, &s.Description, &s.ProjectID, pq.Array(&s.Tags), &s.Billable,
+		&s.Start, &s.End, &s.Duration, &segments, &s.Running, &s.Paused, &s.Draft, &s.ImportHash, &s.Ts)
+	if err != nil {
+		return nil, err
+	}
+	if segments != nil {
+		if err := json.Unmarshal(segments, &s.Segments); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// marshalSegments stores sessions without segments as NULL so they read back as nil
+func marshalSegments(segments []models.Segment) (interface{}, error) {
+	if segments == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(segments)
+	if err != nil {
+		return nil, err
+	}
+	// lib/pq sends []byte as bytea, jsonb needs text
+	return string(b), nil
+}
+
+// querySessions runs a query selecting sessionColumns and returns every session
+func querySessions(ctx context.Context, q querier, query string, args ...interface{}) ([]*models.Session, error) {
+	sessions := []*models.Session{}
+	err := eachSession(ctx, q, query, args, func(s *models.Session) error {
+		sessions = append(sessions, s)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// eachSession runs a query selecting sessionColumns and calls fn for every session
+func eachSession(ctx context.Context, q querier, query string, args []interface{}, fn func(*models.Session) error) error {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(s); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (p *postgresStore) GetSession(ctx context.Context, id, owner string) (*models.Session, error) {
+	row := p.conn.QueryRowContext(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE id = $1 AND owner = $2`, id, owner)
+	session, err := scanSession(row)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return session, nil
+}
+
+// sessionsQuery returns the conditions matching the sessions of the owner that pass the filter
+func (p *postgresStore) sessionsQuery(owner string, filter models.SessionFilter) (*where, error) {
+	filter, err := db.ApplyPeriod(filter, p.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	w := &where{}
+	// running timers are only visible through GetRunningTimer and drafts through GetDraftSessions
+	w.add(`owner = ?`, owner)
+	w.add(`NOT running`)
+	w.add(`NOT draft`)
+	if filter.ProjectID != "" {
+		w.add(`project_id = ?`, filter.ProjectID)
+	}
+	if len(filter.AnyTags) > 0 {
+		w.add(`tags && ?`, pq.Array(filter.AnyTags))
+	}
+	if len(filter.AllTags) > 0 {
+		w.add(`tags @> ?`, pq.Array(filter.AllTags))
+	}
+	// sessions overlapping the range boundaries are included
+	if filter.To != 0 {
+		w.add(`start_ts < ?`, filter.To)
+	}
+	if filter.From != 0 {
+		w.add(`end_ts > ?`, filter.From)
+	}
+	return w, nil
+}
+
+func (p *postgresStore) GetSessions(ctx context.Context, owner string, filter models.SessionFilter) ([]*models.Session, error) {
+	w, err := p.sessionsQuery(owner, filter)
+	if err != nil {
+		return nil, err
+	}
+	return querySessions(ctx, p.conn, `SELECT `+sessionColumns+` FROM sessions WHERE `+w.String()+` ORDER BY ts DESC, id DESC`, w.args...)
+}
+
+// GetSessionsPage returns a window of the most recent first sessions, ordered by ts and id
+func (p *postgresStore) GetSessionsPage(ctx context.Context, owner string, filter models.SessionFilter, page models.Page) (*models.SessionPage, error) {
+	w, err := p.sessionsQuery(owner, filter)
+	if err != nil {
+		return nil, err
+	}
+	result := &models.SessionPage{}
+	err = p.conn.QueryRowContext(ctx, `SELECT count(*) FROM sessions WHERE `+w.String(), w.args...).Scan(&result.TotalCount)
+	if err != nil {
+		return nil, err
+	}
+
+	window := w.clone()
+	if page.After != nil {
+		window.add(`(ts, id) < (?, ?)`, page.After.Ts, page.After.ID)
+	}
+	if page.Before != nil {
+		window.add(`(ts, id) > (?, ?)`, page.Before.Ts, page.Before.ID)
+	}
+
+	// one more session than asked for is read to tell whether the window was cut short
+	backward := page.Last > 0 && page.First == 0
+	order, limit := `ts DESC, id DESC`, page.First
+	if backward {
+		order, limit = `ts ASC, id ASC`, page.Last
+	}
+	sessions, err := querySessions(ctx, p.conn, `SELECT `+sessionColumns+` FROM sessions WHERE `+window.String()+
+		` ORDER BY `+order+` LIMIT `+strconv.Itoa(limit+1), window.args...)
+	if err != nil {
+		return nil, err
+	}
+
+	cut := len(sessions) > limit
+	if cut {
+		sessions = sessions[:limit]
+	}
+	if backward {
+		for i, j := 0, len(sessions)-1; i < j; i, j = i+1, j-1 {
+			sessions[i], sessions[j] = sessions[j], sessions[i]
+		}
+		result.HasPreviousPage = cut
+		if page.Before != nil {
+			result.HasNextPage, err = p.sessionsExist(ctx, w, `(ts, id) <= (?, ?)`, page.Before)
+		}
+	} else {
+		result.HasNextPage = cut
+		if page.After != nil {
+			result.HasPreviousPage, err = p.sessionsExist(ctx, w, `(ts, id) >= (?, ?)`, page.After)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.Sessions = sessions
+	return result, nil
+}
+
+// sessionsExist reports whether a session matching w lies on the given side of the cursor
+func (p *postgresStore) sessionsExist(ctx context.Context, w *where, cond string, cursor *models.Cursor) (bool, error) {
+	side := w.clone()
+	side.add(cond, cursor.Ts, cursor.ID)
+	var exists bool
+	err := p.conn.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM sessions WHERE `+side.String()+`)`, side.args...).Scan(&exists)
+	return exists, err
+}
+
+// StreamSessions calls fn for every session of the owner that passes the filter in order of start,
+// iteration stops at the first error of fn
+func (p *postgresStore) StreamSessions(ctx context.Context, owner string, filter models.SessionFilter, fn func(*models.Session) error) error {
+	w, err := p.sessionsQuery(owner, filter)
+	if err != nil {
+		return err
+	}
+	return eachSession(ctx, p.conn, `SELECT `+sessionColumns+` FROM sessions WHERE `+w.String()+` ORDER BY start_ts, id`, w.args, fn)
+}
+
+func (p *postgresStore) CreateSession(ctx context.Context, session *models.Session) (*models.Session, error) {
+	args, err := sessionArgs(session)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.conn.ExecContext(ctx, insertSession, args...); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// CreateSessionWithPolicy checks for overlaps and saves the session in one transaction. An advisory lock
+// on the owner keeps two sessions of the same user from being checked against each other's absence
+func (p *postgresStore) CreateSessionWithPolicy(ctx context.Context, session *models.Session, policy string) ([]*models.Session, error) {
+	w, err := p.sessionsQuery(session.Owner, db.OverlapRange(session))
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []*models.Session
+	err = withTx(ctx, p.conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1, hashtext($2))`, overlapLock, session.Owner); err != nil {
+			return err
+		}
+		candidates, err := querySessions(ctx, tx, `SELECT `+sessionColumns+` FROM sessions WHERE `+w.String(), w.args...)
+		if err != nil {
+			return err
+		}
+		conflicts, err = db.ApplyOverlapPolicy(session, candidates, policy)
+		if err != nil {
+			return err
+		}
+
+		args, err := sessionArgs(session)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, insertSession, args...)
+		return err
+	})
+	return conflicts, err
+}
+
+// overlapLock is the class of the per owner advisory locks held while checking for overlaps
+const overlapLock = 7260426
+
+// GetOverlaps returns every pair of saved sessions of the owner that tracked the same time
+func (p *postgresStore) GetOverlaps(ctx context.Context, owner string) ([]*models.Overlap, error) {
+	w, err := p.sessionsQuery(owner, models.SessionFilter{})
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := querySessions(ctx, p.conn, `SELECT `+sessionColumns+` FROM sessions WHERE `+w.String()+` ORDER BY start_ts, id`, w.args...)
+	if err != nil {
+		return nil, err
+	}
+	return db.FindOverlaps(sessions), nil
+}
+
+// CreateSessions inserts a batch of sessions, either all of them are saved or none
+func (p *postgresStore) CreateSessions(ctx context.Context, sessions []*models.Session) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+	return withTx(ctx, p.conn, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, insertSession)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for _, s := range sessions {
+			args, err := sessionArgs(s)
+			if err != nil {
+				return err
+			}
+			if _, err := stmt.ExecContext(ctx, args...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetImportedHashes returns the hashes that already belong to imported sessions of the owner
+func (p *postgresStore) GetImportedHashes(ctx context.Context, owner string, hashes []string) ([]string, error) {
+	rows, err := p.conn.QueryContext(ctx, `SELECT DISTINCT import_hash FROM sessions
+		WHERE owner = $1 AND import_hash = ANY($2) ORDER BY import_hash`, owner, pq.Array(hashes))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	imported := []string{}
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		imported = append(imported, hash)
+	}
+	return imported, rows.Err()
+}
+
+// GetDraftSessions returns the unconfirmed sessions of the owner in order of start
+func (p *postgresStore) GetDraftSessions(ctx context.Context, owner string) ([]*models.Session, error) {
+	return querySessions(ctx, p.conn, `SELECT `+sessionColumns+` FROM sessions WHERE owner = $1 AND draft ORDER BY start_ts, id`, owner)
+}
+
+// ConfirmDrafts checks every draft for overlaps and confirms it in one transaction, holding the
+// advisory lock of the owner like CreateSessionWithPolicy
+func (p *postgresStore) ConfirmDrafts(ctx context.Context, owner string, ids []string, policy string) (int64, []*models.Session, error) {
+	var confirmed int64
+	conflicts := []*models.Session{}
+	err := withTx(ctx, p.conn, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1, hashtext($2))`, overlapLock, owner); err != nil {
+			return err
+		}
+		found, err := querySessions(ctx, tx, `SELECT `+sessionColumns+` FROM sessions WHERE owner = $1 AND draft AND id = ANY($2) FOR UPDATE`,
+			owner, pq.Array(ids))
+		if err != nil {
+			return err
+		}
+		drafts := map[string]*models.Session{}
+		for _, s := range found {
+			drafts[s.ID] = s
+		}
+
+		// drafts are confirmed in the order of ids, so a draft is checked against the ones confirmed before it
+		for _, id := range ids {
+			draft, ok := drafts[id]
+			if !ok {
+				continue
+			}
+			delete(drafts, id)
+
+			w, err := p.sessionsQuery(owner, db.OverlapRange(draft))
+			if err != nil {
+				return err
+			}
+			candidates, err := querySessions(ctx, tx, `SELECT `+sessionColumns+` FROM sessions WHERE `+w.String(), w.args...)
+			if err != nil {
+				return err
+			}
+			overlaps, err := db.ApplyOverlapPolicy(draft, candidates, policy)
+			conflicts = append(conflicts, overlaps...)
+			if err == db.ErrSessionOverlap {
+				continue
+			}
+
+			segments, err := marshalSegments(draft.Segments)
+			if err != nil {
+				return err
+			}
+			_, err = tx.ExecContext(ctx, `UPDATE sessions SET draft = FALSE, segments = $1, duration = $2, start_ts = $3, end_ts = $4
+				WHERE id = $5`, segments, draft.Duration, draft.Start, draft.End, draft.ID)
+			if err != nil {
+				return err
+			}
+			confirmed++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return confirmed, conflicts, nil
+}
+
+func (p *postgresStore) UpdateSession(ctx context.Context, id string, info models.SessionInfo) error {
+	u := update{}
+	if info.Title != nil {
+		u.set("title", *info.Title)
+	}
+	if info.Description != nil {
+		u.set("description", *info.Description)
+	}
+	if info.ProjectID != nil {
+		u.set("project_id", *info.ProjectID)
+	}
+	if info.Billable != nil {
+		u.set("billable", *info.Billable)
+	}
+	if info.Tags != nil {
+		u.set("tags", pq.Array(info.Tags))
+	}
+	return u.exec(ctx, p.conn, "sessions", id)
+}
+
+func (p *postgresStore) DeleteSession(ctx context.Context, id string) error {
+	_, err := p.conn.ExecContext(ctx, `DELETE FROM sessions WHERE id = $1`, id)
+	return err
+}
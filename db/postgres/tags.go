@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"context"
+	"github.com/lib/pq"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+func (p *postgresStore) GetTags(ctx context.Context, owner string) ([]*models.TagCount, error) {
+	rows, err := p.conn.QueryContext(ctx, `SELECT tag, count(*) FROM sessions, unnest(tags) AS tag
+		WHERE owner = $1 AND NOT draft
+		GROUP BY tag ORDER BY count(*) DESC, tag COLLATE "C"`, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []*models.TagCount{}
+	for rows.Next() {
+		tag := &models.TagCount{}
+		if err := rows.Scan(&tag.Name, &tag.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// MergeTags replaces the given tags with a single tag on every session of the owner,
+// renaming a tag is a merge of a single tag. The other tags keep their order and the
+// target is appended once, sessions already in that state are not counted as modified
+func (p *postgresStore) MergeTags(ctx context.Context, owner string, tags []string, into string) (int64, error) {
+	result, err := p.conn.ExecContext(ctx, `UPDATE sessions s SET tags = m.merged
+		FROM (
+			SELECT id, array_append(
+				array(SELECT tag FROM unnest(tags) WITH ORDINALITY AS t(tag, i) WHERE tag <> ALL($3) ORDER BY i),
+				$4) AS merged
+			FROM sessions WHERE owner = $1 AND tags && $2
+		) m
+		WHERE s.id = m.id AND s.tags IS DISTINCT FROM m.merged`,
+		owner, pq.Array(tags), pq.Array(append(append([]string{}, tags...), into)), into)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
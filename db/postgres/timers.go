@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+// StartTimer relies on the partial unique index on running sessions, so two timers
+// started at the same time cannot both be saved
+func (p *postgresStore) StartTimer(ctx context.Context, session *models.Session) (*models.Session, error) {
+	args, err := sessionArgs(session)
+	if err != nil {
+		return nil, err
+	}
+	result, err := p.conn.ExecContext(ctx, insertSession+` ON CONFLICT (owner) WHERE running DO NOTHING`, args...)
+	if err != nil {
+		return nil, err
+	}
+	if inserted, err := result.RowsAffected(); err != nil || inserted == 0 {
+		if err == nil {
+			err = db.ErrTimerRunning
+		}
+		return nil, err
+	}
+	return session, nil
+}
+
+func (p *postgresStore) GetRunningTimer(ctx context.Context, owner string) (*models.Session, error) {
+	row := p.conn.QueryRowContext(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE owner = $1 AND running`, owner)
+	session, err := scanSession(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, db.ErrNoRunningTimer
+	}
+	return session, err
+}
+
+func (p *postgresStore) PauseTimer(ctx context.Context, owner string, at int64) (*models.Session, error) {
+	return p.updateTimer(ctx, owner, func(session *models.Session) error {
+		return db.PauseTimer(session, at)
+	})
+}
+
+func (p *postgresStore) ResumeTimer(ctx context.Context, owner string, at int64) (*models.Session, error) {
+	return p.updateTimer(ctx, owner, func(session *models.Session) error {
+		return db.ResumeTimer(session, at)
+	})
+}
+
+func (p *postgresStore) StopTimer(ctx context.Context, owner string, end int64) (*models.Session, error) {
+	return p.updateTimer(ctx, owner, func(session *models.Session) error {
+		return db.StopTimer(session, end)
+	})
+}
+
+// updateTimer applies change to the running timer of the owner, the row is locked
+// until the change is saved so concurrent updates of the timer are applied one at a time
+func (p *postgresStore) updateTimer(ctx context.Context, owner string, change func(*models.Session) error) (*models.Session, error) {
+	var session *models.Session
+	err := withTx(ctx, p.conn, func(tx *sql.Tx) error {
+		row := tx.QueryRowContext(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE owner = $1 AND running FOR UPDATE`, owner)
+		var err error
+		session, err = scanSession(row)
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.ErrNoRunningTimer
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := change(session); err != nil {
+			return err
+		}
+		segments, err := marshalSegments(session.Segments)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE sessions SET segments = $1, duration = $2, end_ts = $3, running = $4, paused = $5
+			WHERE id = $6`, segments, session.Duration, session.End, session.Running, session.Paused, session.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+const tokenColumns = `id, family, owner, expires_at, used, revoked, ts`
+
+func (p *postgresStore) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	return insertToken(ctx, p.conn, token)
+}
+
+func (p *postgresStore) GetRefreshToken(ctx context.Context, id string) (*models.RefreshToken, error) {
+	token := &models.RefreshToken{}
+	err := p.conn.QueryRowContext(ctx, `SELECT `+tokenColumns+` FROM refresh_tokens WHERE id = $1`, id).
+		Scan(&token.ID, &token.Family, &token.Owner, &token.ExpiresAt, &token.Used, &token.Revoked, &token.Ts)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return token, nil
+}
+
+// RotateRefreshToken exchanges the token for next in a transaction holding the row lock of the token,
+// reuse revokes the family
+func (p *postgresStore) RotateRefreshToken(ctx context.Context, id string, next *models.RefreshToken) error {
+	var rotateErr error
+	err := withTx(ctx, p.conn, func(tx *sql.Tx) error {
+		current := &models.RefreshToken{}
+		err := tx.QueryRowContext(ctx, `SELECT `+tokenColumns+` FROM refresh_tokens WHERE id = $1 FOR UPDATE`, id).
+			Scan(&current.ID, &current.Family, &current.Owner, &current.ExpiresAt, &current.Used, &current.Revoked, &current.Ts)
+		if err == sql.ErrNoRows {
+			return db.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		// the revocation of a reused family must be committed, so the error is returned after the transaction
+		if rotateErr = db.CheckRotation(current, next.Owner); rotateErr != nil {
+			if rotateErr == db.ErrTokenReused {
+				return revokeFamily(ctx, tx, current.Family)
+			}
+			return nil
+		}
+
+		db.NextRefreshToken(current, next)
+		if _, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET used = TRUE WHERE id = $1`, id); err != nil {
+			return err
+		}
+		return insertToken(ctx, tx, next)
+	})
+	if err != nil {
+		return err
+	}
+	return rotateErr
+}
+
+func (p *postgresStore) RevokeRefreshFamily(ctx context.Context, family string) error {
+	return revokeFamily(ctx, p.conn, family)
+}
+
+func (p *postgresStore) RevokeUserRefreshTokens(ctx context.Context, owner string) error {
+	_, err := p.conn.ExecContext(ctx, `UPDATE refresh_tokens SET revoked = TRUE WHERE owner = $1`, owner)
+	return err
+}
+
+func insertToken(ctx context.Context, e execer, token *models.RefreshToken) error {
+	_, err := e.ExecContext(ctx, `INSERT INTO refresh_tokens (`+tokenColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		token.ID, token.Family, token.Owner, token.ExpiresAt, token.Used, token.Revoked, token.Ts)
+	return err
+}
+
+func revokeFamily(ctx context.Context, e execer, family string) error {
+	_, err := e.ExecContext(ctx, `UPDATE refresh_tokens SET revoked = TRUE WHERE family = $1`, family)
+	return err
+}
@@ -0,0 +1,23 @@
+package db
+
+import "github.com/victor-nach/time-tracker/models"
+
+// CheckRotation returns why the current refresh token can not be exchanged by owner,
+// ErrTokenReused tells the store to revoke the family of the token
+func CheckRotation(current *models.RefreshToken, owner string) error {
+	switch {
+	case current.Owner != owner:
+		return ErrNotFound
+	case current.Revoked:
+		return ErrTokenRevoked
+	case current.Used:
+		return ErrTokenReused
+	}
+	return nil
+}
+
+// NextRefreshToken marks current used and moves next into its family
+func NextRefreshToken(current, next *models.RefreshToken) {
+	current.Used = true
+	next.Family = current.Family
+}
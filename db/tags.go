@@ -0,0 +1,62 @@
+package db
+
+import (
+	"sort"
+
+	"github.com/victor-nach/time-tracker/models"
+)
+
+// CountTags returns how many of the sessions use each tag, the most used tags come first
+func CountTags(sessions []*models.Session) []*models.TagCount {
+	counts := map[string]int64{}
+	for _, s := range sessions {
+		for _, tag := range s.Tags {
+			counts[tag]++
+		}
+	}
+
+	tags := make([]*models.TagCount, 0, len(counts))
+	for name, count := range counts {
+		tags = append(tags, &models.TagCount{Name: name, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Name < tags[j].Name
+	})
+	return tags
+}
+
+// MergeTags returns the tags of a session with the merged tags replaced by into and whether they changed.
+// The other tags keep their order and into is appended once, tags without any merged tag are left alone
+func MergeTags(sessionTags, tags []string, into string) ([]string, bool) {
+	if !hasAnyTag(sessionTags, tags) {
+		return sessionTags, false
+	}
+
+	removed := map[string]bool{into: true}
+	for _, tag := range tags {
+		removed[tag] = true
+	}
+	merged := []string{}
+	for _, tag := range sessionTags {
+		if !removed[tag] {
+			merged = append(merged, tag)
+		}
+	}
+	merged = append(merged, into)
+	return merged, !equalTags(merged, sessionTags)
+}
+
+func equalTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,408 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/victor-nach/time-tracker/models"
+)
+
+// Timeouts holds the deadline of each store operation, operations missing from Operations use Default.
+// A zero duration leaves the operation with only the deadline of the caller's context
+type Timeouts struct {
+	Default    time.Duration
+	Operations map[string]time.Duration
+}
+
+// ParseTimeouts parses the default timeout and a comma separated list of overrides
+// named after the Datastore methods, e.g "GetReport=30s,StreamSessions=5m"
+func ParseTimeouts(defaultTimeout, overrides string) (Timeouts, error) {
+	timeouts := Timeouts{Operations: map[string]time.Duration{}}
+	var err error
+	if defaultTimeout != "" {
+		if timeouts.Default, err = time.ParseDuration(defaultTimeout); err != nil {
+			return Timeouts{}, err
+		}
+	}
+
+	datastore := reflect.TypeOf((*Datastore)(nil)).Elem()
+	for _, override := range strings.Split(overrides, ",") {
+		if strings.TrimSpace(override) == "" {
+			continue
+		}
+		parts := strings.SplitN(override, "=", 2)
+		name := strings.TrimSpace(parts[0])
+		if _, ok := datastore.MethodByName(name); !ok || len(parts) != 2 {
+			return Timeouts{}, fmt.Errorf("invalid operation timeout %q", override)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return Timeouts{}, err
+		}
+		timeouts.Operations[name] = timeout
+	}
+	return timeouts, nil
+}
+
+// For returns the timeout of the operation
+func (t Timeouts) For(operation string) time.Duration {
+	if timeout, ok := t.Operations[operation]; ok {
+		return timeout
+	}
+	return t.Default
+}
+
+// timeoutStore bounds every call to the wrapped store with the deadline of its operation
+type timeoutStore struct {
+	store    Datastore
+	timeouts Timeouts
+}
+
+// ensure timeoutStore implements the datastore interface
+var _ Datastore = &timeoutStore{}
+
+// WithTimeouts returns a store that gives up on an operation once its timeout passes,
+// the caller's context still cancels the operation earlier
+func WithTimeouts(store Datastore, timeouts Timeouts) Datastore {
+	return &timeoutStore{store: store, timeouts: timeouts}
+}
+
+func (t *timeoutStore) context(ctx context.Context, operation string) (context.Context, context.CancelFunc) {
+	if timeout := t.timeouts.For(operation); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+func (t *timeoutStore) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	ctx, cancel := t.context(ctx, "CreateUser")
+	defer cancel()
+	return t.store.CreateUser(ctx, user)
+}
+
+func (t *timeoutStore) GetUser(ctx context.Context, id string) (*models.User, error) {
+	ctx, cancel := t.context(ctx, "GetUser")
+	defer cancel()
+	return t.store.GetUser(ctx, id)
+}
+
+func (t *timeoutStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, cancel := t.context(ctx, "GetUserByEmail")
+	defer cancel()
+	return t.store.GetUserByEmail(ctx, email)
+}
+
+func (t *timeoutStore) GetUserByCalendarToken(ctx context.Context, token string) (*models.User, error) {
+	ctx, cancel := t.context(ctx, "GetUserByCalendarToken")
+	defer cancel()
+	return t.store.GetUserByCalendarToken(ctx, token)
+}
+
+func (t *timeoutStore) UpdateUser(ctx context.Context, id string, info models.UserInfo) error {
+	ctx, cancel := t.context(ctx, "UpdateUser")
+	defer cancel()
+	return t.store.UpdateUser(ctx, id, info)
+}
+
+func (t *timeoutStore) GetTokenGeneration(ctx context.Context, id string) (int64, error) {
+	ctx, cancel := t.context(ctx, "GetTokenGeneration")
+	defer cancel()
+	return t.store.GetTokenGeneration(ctx, id)
+}
+
+func (t *timeoutStore) IncrementTokenGeneration(ctx context.Context, id string) (int64, error) {
+	ctx, cancel := t.context(ctx, "IncrementTokenGeneration")
+	defer cancel()
+	return t.store.IncrementTokenGeneration(ctx, id)
+}
+
+func (t *timeoutStore) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	ctx, cancel := t.context(ctx, "CreateRefreshToken")
+	defer cancel()
+	return t.store.CreateRefreshToken(ctx, token)
+}
+
+func (t *timeoutStore) GetRefreshToken(ctx context.Context, id string) (*models.RefreshToken, error) {
+	ctx, cancel := t.context(ctx, "GetRefreshToken")
+	defer cancel()
+	return t.store.GetRefreshToken(ctx, id)
+}
+
+func (t *timeoutStore) RotateRefreshToken(ctx context.Context, id string, next *models.RefreshToken) error {
+	ctx, cancel := t.context(ctx, "RotateRefreshToken")
+	defer cancel()
+	return t.store.RotateRefreshToken(ctx, id, next)
+}
+
+func (t *timeoutStore) RevokeRefreshFamily(ctx context.Context, family string) error {
+	ctx, cancel := t.context(ctx, "RevokeRefreshFamily")
+	defer cancel()
+	return t.store.RevokeRefreshFamily(ctx, family)
+}
+
+func (t *timeoutStore) RevokeUserRefreshTokens(ctx context.Context, owner string) error {
+	ctx, cancel := t.context(ctx, "RevokeUserRefreshTokens")
+	defer cancel()
+	return t.store.RevokeUserRefreshTokens(ctx, owner)
+}
+
+func (t *timeoutStore) CreateLoginSession(ctx context.Context, session *models.LoginSession) error {
+	ctx, cancel := t.context(ctx, "CreateLoginSession")
+	defer cancel()
+	return t.store.CreateLoginSession(ctx, session)
+}
+
+func (t *timeoutStore) GetLoginSession(ctx context.Context, id, owner string) (*models.LoginSession, error) {
+	ctx, cancel := t.context(ctx, "GetLoginSession")
+	defer cancel()
+	return t.store.GetLoginSession(ctx, id, owner)
+}
+
+func (t *timeoutStore) GetLoginSessions(ctx context.Context, owner string) ([]*models.LoginSession, error) {
+	ctx, cancel := t.context(ctx, "GetLoginSessions")
+	defer cancel()
+	return t.store.GetLoginSessions(ctx, owner)
+}
+
+func (t *timeoutStore) UpdateLoginSession(ctx context.Context, id string, info models.LoginSessionInfo) error {
+	ctx, cancel := t.context(ctx, "UpdateLoginSession")
+	defer cancel()
+	return t.store.UpdateLoginSession(ctx, id, info)
+}
+
+func (t *timeoutStore) DeleteLoginSession(ctx context.Context, id string) error {
+	ctx, cancel := t.context(ctx, "DeleteLoginSession")
+	defer cancel()
+	return t.store.DeleteLoginSession(ctx, id)
+}
+
+func (t *timeoutStore) DeleteLoginSessions(ctx context.Context, owner string) error {
+	ctx, cancel := t.context(ctx, "DeleteLoginSessions")
+	defer cancel()
+	return t.store.DeleteLoginSessions(ctx, owner)
+}
+
+func (t *timeoutStore) CreateOneTimeToken(ctx context.Context, token *models.OneTimeToken) error {
+	ctx, cancel := t.context(ctx, "CreateOneTimeToken")
+	defer cancel()
+	return t.store.CreateOneTimeToken(ctx, token)
+}
+
+func (t *timeoutStore) GetOneTimeToken(ctx context.Context, id string) (*models.OneTimeToken, error) {
+	ctx, cancel := t.context(ctx, "GetOneTimeToken")
+	defer cancel()
+	return t.store.GetOneTimeToken(ctx, id)
+}
+
+func (t *timeoutStore) UseOneTimeToken(ctx context.Context, id, purpose string, now int64) (*models.OneTimeToken, error) {
+	ctx, cancel := t.context(ctx, "UseOneTimeToken")
+	defer cancel()
+	return t.store.UseOneTimeToken(ctx, id, purpose, now)
+}
+
+func (t *timeoutStore) DeleteOneTimeTokens(ctx context.Context, owner, purpose string) error {
+	ctx, cancel := t.context(ctx, "DeleteOneTimeTokens")
+	defer cancel()
+	return t.store.DeleteOneTimeTokens(ctx, owner, purpose)
+}
+
+func (t *timeoutStore) GetSession(ctx context.Context, id, owner string) (*models.Session, error) {
+	ctx, cancel := t.context(ctx, "GetSession")
+	defer cancel()
+	return t.store.GetSession(ctx, id, owner)
+}
+
+func (t *timeoutStore) GetSessions(ctx context.Context, owner string, filter models.SessionFilter) ([]*models.Session, error) {
+	ctx, cancel := t.context(ctx, "GetSessions")
+	defer cancel()
+	return t.store.GetSessions(ctx, owner, filter)
+}
+
+func (t *timeoutStore) GetSessionsPage(ctx context.Context, owner string, filter models.SessionFilter, page models.Page) (*models.SessionPage, error) {
+	ctx, cancel := t.context(ctx, "GetSessionsPage")
+	defer cancel()
+	return t.store.GetSessionsPage(ctx, owner, filter, page)
+}
+
+func (t *timeoutStore) StreamSessions(ctx context.Context, owner string, filter models.SessionFilter, fn func(*models.Session) error) error {
+	ctx, cancel := t.context(ctx, "StreamSessions")
+	defer cancel()
+	return t.store.StreamSessions(ctx, owner, filter, fn)
+}
+
+func (t *timeoutStore) CreateSession(ctx context.Context, session *models.Session) (*models.Session, error) {
+	ctx, cancel := t.context(ctx, "CreateSession")
+	defer cancel()
+	return t.store.CreateSession(ctx, session)
+}
+
+func (t *timeoutStore) CreateSessions(ctx context.Context, sessions []*models.Session) error {
+	ctx, cancel := t.context(ctx, "CreateSessions")
+	defer cancel()
+	return t.store.CreateSessions(ctx, sessions)
+}
+
+func (t *timeoutStore) CreateSessionWithPolicy(ctx context.Context, session *models.Session, policy string) ([]*models.Session, error) {
+	ctx, cancel := t.context(ctx, "CreateSessionWithPolicy")
+	defer cancel()
+	return t.store.CreateSessionWithPolicy(ctx, session, policy)
+}
+
+func (t *timeoutStore) GetOverlaps(ctx context.Context, owner string) ([]*models.Overlap, error) {
+	ctx, cancel := t.context(ctx, "GetOverlaps")
+	defer cancel()
+	return t.store.GetOverlaps(ctx, owner)
+}
+
+func (t *timeoutStore) GetImportedHashes(ctx context.Context, owner string, hashes []string) ([]string, error) {
+	ctx, cancel := t.context(ctx, "GetImportedHashes")
+	defer cancel()
+	return t.store.GetImportedHashes(ctx, owner, hashes)
+}
+
+func (t *timeoutStore) GetDraftSessions(ctx context.Context, owner string) ([]*models.Session, error) {
+	ctx, cancel := t.context(ctx, "GetDraftSessions")
+	defer cancel()
+	return t.store.GetDraftSessions(ctx, owner)
+}
+
+func (t *timeoutStore) ConfirmDrafts(ctx context.Context, owner string, ids []string, policy string) (int64, []*models.Session, error) {
+	ctx, cancel := t.context(ctx, "ConfirmDrafts")
+	defer cancel()
+	return t.store.ConfirmDrafts(ctx, owner, ids, policy)
+}
+
+func (t *timeoutStore) UpdateSession(ctx context.Context, id string, info models.SessionInfo) error {
+	ctx, cancel := t.context(ctx, "UpdateSession")
+	defer cancel()
+	return t.store.UpdateSession(ctx, id, info)
+}
+
+func (t *timeoutStore) DeleteSession(ctx context.Context, id string) error {
+	ctx, cancel := t.context(ctx, "DeleteSession")
+	defer cancel()
+	return t.store.DeleteSession(ctx, id)
+}
+
+func (t *timeoutStore) GetReport(ctx context.Context, owner string, query models.ReportQuery) ([]*models.ReportRow, error) {
+	ctx, cancel := t.context(ctx, "GetReport")
+	defer cancel()
+	return t.store.GetReport(ctx, owner, query)
+}
+
+func (t *timeoutStore) GetTags(ctx context.Context, owner string) ([]*models.TagCount, error) {
+	ctx, cancel := t.context(ctx, "GetTags")
+	defer cancel()
+	return t.store.GetTags(ctx, owner)
+}
+
+func (t *timeoutStore) MergeTags(ctx context.Context, owner string, tags []string, into string) (int64, error) {
+	ctx, cancel := t.context(ctx, "MergeTags")
+	defer cancel()
+	return t.store.MergeTags(ctx, owner, tags, into)
+}
+
+func (t *timeoutStore) StartTimer(ctx context.Context, session *models.Session) (*models.Session, error) {
+	ctx, cancel := t.context(ctx, "StartTimer")
+	defer cancel()
+	return t.store.StartTimer(ctx, session)
+}
+
+func (t *timeoutStore) GetRunningTimer(ctx context.Context, owner string) (*models.Session, error) {
+	ctx, cancel := t.context(ctx, "GetRunningTimer")
+	defer cancel()
+	return t.store.GetRunningTimer(ctx, owner)
+}
+
+func (t *timeoutStore) PauseTimer(ctx context.Context, owner string, at int64) (*models.Session, error) {
+	ctx, cancel := t.context(ctx, "PauseTimer")
+	defer cancel()
+	return t.store.PauseTimer(ctx, owner, at)
+}
+
+func (t *timeoutStore) ResumeTimer(ctx context.Context, owner string, at int64) (*models.Session, error) {
+	ctx, cancel := t.context(ctx, "ResumeTimer")
+	defer cancel()
+	return t.store.ResumeTimer(ctx, owner, at)
+}
+
+func (t *timeoutStore) StopTimer(ctx context.Context, owner string, end int64) (*models.Session, error) {
+	ctx, cancel := t.context(ctx, "StopTimer")
+	defer cancel()
+	return t.store.StopTimer(ctx, owner, end)
+}
+
+func (t *timeoutStore) CreateProject(ctx context.Context, project *models.Project) (*models.Project, error) {
+	ctx, cancel := t.context(ctx, "CreateProject")
+	defer cancel()
+	return t.store.CreateProject(ctx, project)
+}
+
+func (t *timeoutStore) GetProject(ctx context.Context, id, owner string) (*models.Project, error) {
+	ctx, cancel := t.context(ctx, "GetProject")
+	defer cancel()
+	return t.store.GetProject(ctx, id, owner)
+}
+
+func (t *timeoutStore) GetProjects(ctx context.Context, owner string, includeArchived bool) ([]*models.Project, error) {
+	ctx, cancel := t.context(ctx, "GetProjects")
+	defer cancel()
+	return t.store.GetProjects(ctx, owner, includeArchived)
+}
+
+func (t *timeoutStore) UpdateProject(ctx context.Context, id string, info models.ProjectInfo) error {
+	ctx, cancel := t.context(ctx, "UpdateProject")
+	defer cancel()
+	return t.store.UpdateProject(ctx, id, info)
+}
+
+func (t *timeoutStore) DeleteProject(ctx context.Context, id string) error {
+	ctx, cancel := t.context(ctx, "DeleteProject")
+	defer cancel()
+	return t.store.DeleteProject(ctx, id)
+}
+
+func (t *timeoutStore) CreateClient(ctx context.Context, client *models.Client) (*models.Client, error) {
+	ctx, cancel := t.context(ctx, "CreateClient")
+	defer cancel()
+	return t.store.CreateClient(ctx, client)
+}
+
+func (t *timeoutStore) GetClient(ctx context.Context, id, owner string) (*models.Client, error) {
+	ctx, cancel := t.context(ctx, "GetClient")
+	defer cancel()
+	return t.store.GetClient(ctx, id, owner)
+}
+
+func (t *timeoutStore) GetClients(ctx context.Context, owner string, includeArchived bool) ([]*models.Client, error) {
+	ctx, cancel := t.context(ctx, "GetClients")
+	defer cancel()
+	return t.store.GetClients(ctx, owner, includeArchived)
+}
+
+func (t *timeoutStore) UpdateClient(ctx context.Context, id string, info models.ClientInfo) error {
+	ctx, cancel := t.context(ctx, "UpdateClient")
+	defer cancel()
+	return t.store.UpdateClient(ctx, id, info)
+}
+
+func (t *timeoutStore) DeleteClient(ctx context.Context, id string) error {
+	ctx, cancel := t.context(ctx, "DeleteClient")
+	defer cancel()
+	return t.store.DeleteClient(ctx, id)
+}
+
+func (t *timeoutStore) CreateRate(ctx context.Context, rate *models.Rate) (*models.Rate, error) {
+	ctx, cancel := t.context(ctx, "CreateRate")
+	defer cancel()
+	return t.store.CreateRate(ctx, rate)
+}
+
+func (t *timeoutStore) GetRates(ctx context.Context, owner string) ([]*models.Rate, error) {
+	ctx, cancel := t.context(ctx, "GetRates")
+	defer cancel()
+	return t.store.GetRates(ctx, owner)
+}
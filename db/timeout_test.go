@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+// deadlineStore records the deadline of the context GetUser is called with
+type deadlineStore struct {
+	Datastore
+	deadline time.Time
+	ok       bool
+}
+
+func (d *deadlineStore) GetUser(ctx context.Context, id string) (*models.User, error) {
+	d.deadline, d.ok = ctx.Deadline()
+	return &models.User{ID: id}, ctx.Err()
+}
+
+func TestParseTimeouts(t *testing.T) {
+	timeouts, err := ParseTimeouts("5s", "GetReport=30s, StreamSessions=0")
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Second, timeouts.For("GetUser"))
+	assert.Equal(t, 30*time.Second, timeouts.For("GetReport"))
+	assert.Equal(t, time.Duration(0), timeouts.For("StreamSessions"))
+
+	_, err = ParseTimeouts("5s", "GetReports=30s")
+	assert.Error(t, err)
+	_, err = ParseTimeouts("5s", "GetReport")
+	assert.Error(t, err)
+	_, err = ParseTimeouts("five seconds", "")
+	assert.Error(t, err)
+}
+
+func TestWithTimeouts(t *testing.T) {
+	inner := &deadlineStore{}
+	store := WithTimeouts(inner, Timeouts{Default: time.Minute, Operations: map[string]time.Duration{}})
+
+	_, err := store.GetUser(context.Background(), "id")
+	assert.NoError(t, err)
+	assert.True(t, inner.ok)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), inner.deadline, time.Second)
+
+	// the deadline of the caller still applies when it is sooner
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = store.GetUser(ctx, "id")
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Second), inner.deadline, time.Second)
+
+	// operations without a timeout only have the deadline of the caller
+	store = WithTimeouts(inner, Timeouts{Operations: map[string]time.Duration{"GetUser": 0}})
+	_, err = store.GetUser(context.Background(), "id")
+	assert.NoError(t, err)
+	assert.False(t, inner.ok)
+
+	// the store sees when the caller gives up
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = store.GetUser(ctx, "id")
+	assert.Equal(t, context.Canceled, err)
+}
@@ -0,0 +1,46 @@
+package db
+
+import "github.com/victor-nach/time-tracker/models"
+
+// PauseTimer closes the open segment of a running timer, the stores persist the updated session
+func PauseTimer(session *models.Session, at int64) error {
+	if session.Paused {
+		return ErrTimerPaused
+	}
+	session.Segments = closeSegment(session.Intervals(), at)
+	session.Duration = session.TotalDuration()
+	session.Paused = true
+	return nil
+}
+
+// ResumeTimer opens a new segment on a paused timer
+func ResumeTimer(session *models.Session, at int64) error {
+	if !session.Paused {
+		return ErrTimerNotPaused
+	}
+	session.Segments = append(session.Intervals(), models.Segment{Start: at})
+	session.Paused = false
+	return nil
+}
+
+// StopTimer ends a running or paused timer, turning it into a regular session
+func StopTimer(session *models.Session, end int64) error {
+	if !session.Paused {
+		session.Segments = closeSegment(session.Intervals(), end)
+	}
+	session.End = end
+	session.Duration = session.TotalDuration()
+	session.Running = false
+	session.Paused = false
+	return nil
+}
+
+// closeSegment ends the last open segment at the given time
+func closeSegment(segments []models.Segment, at int64) []models.Segment {
+	closed := make([]models.Segment, len(segments))
+	copy(closed, segments)
+	if n := len(closed); n > 0 && closed[n-1].End == 0 {
+		closed[n-1].End = at
+	}
+	return closed
+}
@@ -8,11 +8,13 @@ require (
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
 	github.com/go-chi/chi v3.3.2+incompatible
 	github.com/joho/godotenv v1.3.0
+	github.com/lib/pq v1.10.9
 	github.com/oklog/ulid/v2 v2.0.2
 	github.com/ory/dockertest/v3 v3.6.5
 	github.com/rs/cors v1.6.0
 	github.com/stretchr/testify v1.7.0
 	github.com/vektah/gqlparser/v2 v2.1.0
+	go.etcd.io/bbolt v1.3.6
 	go.mongodb.org/mongo-driver v1.5.3
 	go.uber.org/zap v1.17.0
 	golang.org/x/crypto v0.0.0-20200302210943-78000ba7a073
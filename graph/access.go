@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"go.uber.org/zap"
+)
+
+// accountMutations manage the account itself, users that did not verify their email can still use them
+var accountMutations = map[string]bool{
+	"signUp":               true,
+	"login":                true,
+	"refreshToken":         true,
+	"logout":               true,
+	"logoutAllDevices":     true,
+	"revokeDevice":         true,
+	"requestPasswordReset": true,
+	"resetPassword":        true,
+	"verifyEmail":          true,
+	"resendVerification":   true,
+	"updateProfile":        true,
+}
+
+// ReadOnlyUnverified is a field middleware refusing the mutations of users that did not verify
+// their email, other than the ones managing their account. Queries are not restricted
+func (r *Resolver) ReadOnlyUnverified(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	field := graphql.GetFieldContext(ctx)
+	if field == nil || field.Object != "Mutation" || accountMutations[field.Field.Name] {
+		return next(ctx)
+	}
+	if err := r.RequireVerifiedEmail(ctx); err != nil {
+		return nil, err
+	}
+	return next(ctx)
+}
+
+// RequireVerifiedEmail returns an EmailNotVerifiedErr when the authenticated user did not verify
+// their email. Unauthenticated requests pass, the resolvers refuse them
+func (r *Resolver) RequireVerifiedEmail(ctx context.Context) error {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		return nil
+	}
+	user, err := r.store.GetUser(ctx, claims.UserId)
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("require verified email", zap.Error(err))
+		return err
+	}
+	if !user.EmailVerified {
+		err := rerrors.Format(rerrors.EmailNotVerifiedErr, nil)
+		r.logger.Error("require verified email", zap.Error(err))
+		return err
+	}
+	return nil
+}
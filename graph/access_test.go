@@ -0,0 +1,59 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"github.com/victor-nach/time-tracker/lib/tokenhandler"
+	"github.com/victor-nach/time-tracker/mocks"
+	"github.com/victor-nach/time-tracker/models"
+	"github.com/victor-nach/time-tracker/server/middlewares"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestReadOnlyUnverified(t *testing.T) {
+	tests := []struct {
+		name     string
+		object   string
+		field    string
+		verified bool
+		allowed  bool
+	}{
+		{name: "Test mutation of unverified user", object: "Mutation", field: "saveSession", allowed: false},
+		{name: "Test mutation of verified user", object: "Mutation", field: "saveSession", verified: true, allowed: true},
+		{name: "Test account mutation of unverified user", object: "Mutation", field: "resendVerification", allowed: true},
+		{name: "Test profile update of unverified user", object: "Mutation", field: "updateProfile", allowed: true},
+		{name: "Test query of unverified user", object: "Query", field: "sessions", allowed: true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			storeMock := new(mocks.Datastore)
+			resolvers := NewResolver(storeMock, nil, zaptest.NewLogger(t))
+			storeMock.On("GetUser", mock.Anything, "userId").
+				Return(&models.User{ID: "userId", EmailVerified: testCase.verified}, nil)
+
+			ctx := context.WithValue(context.Background(), middlewares.AuthContextKey, tokenhandler.Claims{UserId: "userId"})
+			field := &graphql.FieldContext{Object: testCase.object}
+			field.Field.Field = &ast.Field{Name: testCase.field}
+			ctx = graphql.WithFieldContext(ctx, field)
+
+			called := false
+			_, err := resolvers.ReadOnlyUnverified(ctx, func(ctx context.Context) (interface{}, error) {
+				called = true
+				return nil, nil
+			})
+			assert.Equal(t, testCase.allowed, called)
+			if testCase.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, rerrors.EmailNotVerifiedErr, err.(*rerrors.Err).Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/lib/mailer"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"github.com/victor-nach/time-tracker/lib/securetoken"
+	"github.com/victor-nach/time-tracker/lib/tokenhandler"
+	"github.com/victor-nach/time-tracker/mocks"
+	"github.com/victor-nach/time-tracker/models"
+	"github.com/victor-nach/time-tracker/server/middlewares"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestMutationResolver_RequestPasswordReset(t *testing.T) {
+	const (
+		success = iota
+		unknownEmail
+		mailQueueFull
+	)
+
+	var tests = []struct {
+		name     string
+		testType int
+	}{
+		{
+			name:     "Successfully mail reset link",
+			testType: success,
+		},
+		{
+			name:     "Test unknown email gets the same response",
+			testType: unknownEmail,
+		},
+		{
+			name:     "Test mail dropped when too many mails are being sent",
+			testType: mailQueueFull,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			storeMock, mailerMock := new(mocks.Datastore), new(mocks.Mailer)
+			resolvers := NewResolver(storeMock, nil, zaptest.NewLogger(t)).WithMailer(mailerMock, "https://tracker.app/")
+
+			switch testCase.testType {
+			case success:
+				storeMock.On("GetUserByEmail", mock.Anything, "ada@email.com").
+					Return(&models.User{ID: "userId", Email: "ada@email.com", Name: "Ada"}, nil)
+				var stored *models.OneTimeToken
+				storeMock.On("CreateOneTimeToken", mock.Anything, mock.Anything).Return(nil).
+					Run(func(args mock.Arguments) { stored = args.Get(1).(*models.OneTimeToken) })
+				var sent mailer.Message
+				mailerMock.On("Send", mock.Anything, mock.Anything).Return(nil).
+					Run(func(args mock.Arguments) { sent = args.Get(1).(mailer.Message) })
+
+				resp, err := resolvers.Mutation().RequestPasswordReset(context.Background(), "ada@email.com")
+				assert.NoError(t, err)
+				assert.True(t, resp.Success)
+				assert.NoError(t, resolvers.WaitMails(context.Background()))
+
+				// the mail carries the token, only its hash is stored
+				assert.Equal(t, "ada@email.com", sent.To)
+				start := strings.Index(sent.Body, "https://tracker.app/reset-password?token=")
+				assert.NotEqual(t, -1, start)
+				token := strings.Fields(sent.Body[start+len("https://tracker.app/reset-password?token="):])[0]
+				assert.Equal(t, securetoken.Hash(token), stored.ID)
+				assert.Equal(t, "userId", stored.Owner)
+				assert.Equal(t, models.PurposePasswordReset, stored.Purpose)
+				assert.Greater(t, stored.ExpiresAt, stored.Ts)
+
+			case unknownEmail:
+				storeMock.On("GetUserByEmail", mock.Anything, "eve@email.com").Return(nil, db.ErrNotFound)
+
+				resp, err := resolvers.Mutation().RequestPasswordReset(context.Background(), "eve@email.com")
+				assert.NoError(t, err)
+				assert.True(t, resp.Success)
+				mailerMock.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+
+			case mailQueueFull:
+				for i := 0; i < maxBackgroundMails; i++ {
+					resolvers.mailSlots <- struct{}{}
+				}
+				storeMock.On("GetUserByEmail", mock.Anything, "ada@email.com").
+					Return(&models.User{ID: "userId", Email: "ada@email.com", Name: "Ada"}, nil)
+
+				resp, err := resolvers.Mutation().RequestPasswordReset(context.Background(), "ada@email.com")
+				assert.NoError(t, err)
+				assert.True(t, resp.Success)
+				assert.NoError(t, resolvers.WaitMails(context.Background()))
+				storeMock.AssertNotCalled(t, "CreateOneTimeToken", mock.Anything, mock.Anything)
+				mailerMock.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+			}
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMutationResolver_ResetPassword(t *testing.T) {
+	const (
+		success = iota
+		invalidTokenError
+		shortPasscodeError
+		concurrentUseError
+		updateUserError
+	)
+
+	var tests = []struct {
+		name     string
+		testType int
+	}{
+		{
+			name:     "Successfully reset passcode",
+			testType: success,
+		},
+		{
+			name:     "Test used token",
+			testType: invalidTokenError,
+		},
+		{
+			name:     "Test short passcode",
+			testType: shortPasscodeError,
+		},
+		{
+			name:     "Test token used by a concurrent reset",
+			testType: concurrentUseError,
+		},
+		{
+			name:     "Test database error when the passcode can not be updated",
+			testType: updateUserError,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			storeMock := new(mocks.Datastore)
+			resolvers := NewResolver(storeMock, nil, zaptest.NewLogger(t))
+			hash := securetoken.Hash("token")
+			reset := &models.OneTimeToken{ID: hash, Owner: "userId", Purpose: models.PurposePasswordReset, ExpiresAt: time.Now().Add(time.Hour).Unix()}
+
+			switch testCase.testType {
+			case success:
+				storeMock.On("GetOneTimeToken", mock.Anything, hash).Return(reset, nil)
+				storeMock.On("UseOneTimeToken", mock.Anything, hash, models.PurposePasswordReset, mock.Anything).Return(reset, nil)
+				storeMock.On("UpdateUser", mock.Anything, "userId", mock.MatchedBy(func(info models.UserInfo) bool {
+					return info.Password != nil && *info.Password != "new passcode"
+				})).Return(nil)
+				storeMock.On("DeleteOneTimeTokens", mock.Anything, "userId", models.PurposePasswordReset).Return(nil)
+				storeMock.On("RevokeUserRefreshTokens", mock.Anything, "userId").Return(nil)
+				storeMock.On("DeleteLoginSessions", mock.Anything, "userId").Return(nil)
+				storeMock.On("IncrementTokenGeneration", mock.Anything, "userId").Return(int64(1), nil)
+
+				resp, err := resolvers.Mutation().ResetPassword(context.Background(), "token", "new passcode")
+				assert.NoError(t, err)
+				assert.True(t, resp.Success)
+
+			case invalidTokenError:
+				reset.Used = true
+				storeMock.On("GetOneTimeToken", mock.Anything, hash).Return(reset, nil)
+
+				_, err := resolvers.Mutation().ResetPassword(context.Background(), "token", "new passcode")
+				assert.Equal(t, rerrors.InvalidTokenErr, err.(*rerrors.Err).Code)
+				storeMock.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything, mock.Anything)
+
+			case concurrentUseError:
+				storeMock.On("GetOneTimeToken", mock.Anything, hash).Return(reset, nil)
+				storeMock.On("UseOneTimeToken", mock.Anything, hash, models.PurposePasswordReset, mock.Anything).Return(nil, db.ErrTokenUsed)
+
+				_, err := resolvers.Mutation().ResetPassword(context.Background(), "token", "new passcode")
+				assert.Equal(t, rerrors.InvalidTokenErr, err.(*rerrors.Err).Code)
+				storeMock.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything, mock.Anything)
+
+			case updateUserError:
+				storeMock.On("GetOneTimeToken", mock.Anything, hash).Return(reset, nil)
+				storeMock.On("UseOneTimeToken", mock.Anything, hash, models.PurposePasswordReset, mock.Anything).Return(reset, nil)
+				storeMock.On("UpdateUser", mock.Anything, "userId", mock.Anything).Return(errors.New("connection reset"))
+
+				_, err := resolvers.Mutation().ResetPassword(context.Background(), "token", "new passcode")
+				assert.Equal(t, rerrors.DatabaseErr, err.(*rerrors.Err).Code)
+				storeMock.AssertNotCalled(t, "DeleteOneTimeTokens", mock.Anything, mock.Anything, mock.Anything)
+
+			case shortPasscodeError:
+				_, err := resolvers.Mutation().ResetPassword(context.Background(), "token", "short")
+				assert.Equal(t, rerrors.ValidationErr, err.(*rerrors.Err).Code)
+				assert.Equal(t, "newPasscode", err.(*rerrors.Err).Fields[0].Field)
+			}
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMutationResolver_VerifyEmail(t *testing.T) {
+	const (
+		success = iota
+		expiredTokenError
+	)
+
+	var tests = []struct {
+		name     string
+		testType int
+	}{
+		{
+			name:     "Successfully verify email",
+			testType: success,
+		},
+		{
+			name:     "Test expired token",
+			testType: expiredTokenError,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			storeMock := new(mocks.Datastore)
+			resolvers := NewResolver(storeMock, nil, zaptest.NewLogger(t))
+			hash := securetoken.Hash("token")
+
+			switch testCase.testType {
+			case success:
+				storeMock.On("UseOneTimeToken", mock.Anything, hash, models.PurposeEmailVerification, mock.Anything).
+					Return(&models.OneTimeToken{ID: hash, Owner: "userId", Purpose: models.PurposeEmailVerification}, nil)
+				verified := true
+				storeMock.On("UpdateUser", mock.Anything, "userId", models.UserInfo{EmailVerified: &verified}).Return(nil)
+				storeMock.On("DeleteOneTimeTokens", mock.Anything, "userId", models.PurposeEmailVerification).Return(nil)
+
+				resp, err := resolvers.Mutation().VerifyEmail(context.Background(), "token")
+				assert.NoError(t, err)
+				assert.True(t, resp.Success)
+
+			case expiredTokenError:
+				storeMock.On("UseOneTimeToken", mock.Anything, hash, models.PurposeEmailVerification, mock.Anything).
+					Return(nil, db.ErrTokenExpired)
+
+				_, err := resolvers.Mutation().VerifyEmail(context.Background(), "token")
+				assert.Equal(t, rerrors.InvalidTokenErr, err.(*rerrors.Err).Code)
+			}
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMutationResolver_ResendVerification(t *testing.T) {
+	const (
+		success = iota
+		alreadyVerifiedError
+	)
+
+	var tests = []struct {
+		name     string
+		testType int
+	}{
+		{
+			name:     "Successfully resend verification link",
+			testType: success,
+		},
+		{
+			name:     "Test email already verified",
+			testType: alreadyVerifiedError,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			storeMock, mailerMock := new(mocks.Datastore), new(mocks.Mailer)
+			resolvers := NewResolver(storeMock, nil, zaptest.NewLogger(t)).WithMailer(mailerMock, "https://tracker.app")
+			ctx := context.WithValue(context.Background(), middlewares.AuthContextKey,
+				tokenhandler.Claims{UserId: "userId"})
+
+			switch testCase.testType {
+			case success:
+				storeMock.On("GetUser", mock.Anything, "userId").
+					Return(&models.User{ID: "userId", Email: "ada@email.com"}, nil)
+				storeMock.On("DeleteOneTimeTokens", mock.Anything, "userId", models.PurposeEmailVerification).Return(nil)
+				storeMock.On("CreateOneTimeToken", mock.Anything, mock.MatchedBy(func(token *models.OneTimeToken) bool {
+					return token.Owner == "userId" && token.Purpose == models.PurposeEmailVerification
+				})).Return(nil)
+				mailerMock.On("Send", mock.Anything, mock.MatchedBy(func(msg mailer.Message) bool {
+					return msg.To == "ada@email.com" && strings.Contains(msg.Body, "https://tracker.app/verify-email?token=")
+				})).Return(nil)
+
+				resp, err := resolvers.Mutation().ResendVerification(ctx)
+				assert.NoError(t, err)
+				assert.True(t, resp.Success)
+				mailerMock.AssertExpectations(t)
+
+			case alreadyVerifiedError:
+				storeMock.On("GetUser", mock.Anything, "userId").
+					Return(&models.User{ID: "userId", Email: "ada@email.com", EmailVerified: true}, nil)
+
+				_, err := resolvers.Mutation().ResendVerification(ctx)
+				assert.Equal(t, rerrors.EmailVerifiedErr, err.(*rerrors.Err).Code)
+				mailerMock.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+			}
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
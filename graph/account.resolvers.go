@@ -0,0 +1,160 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+
+	"github.com/victor-nach/time-tracker/db"
+	types "github.com/victor-nach/time-tracker/graph/model"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"github.com/victor-nach/time-tracker/lib/securetoken"
+	"github.com/victor-nach/time-tracker/models"
+	"go.uber.org/zap"
+)
+
+func (r *mutationResolver) RequestPasswordReset(ctx context.Context, email string) (*types.Response, error) {
+	// every outcome gets the same response, so it does not tell whether the email belongs to an account
+	resp := &types.Response{
+		Success: true,
+		Message: "If this email belongs to an account, a link to reset its passcode was sent to it",
+	}
+
+	user, err := r.store.GetUserByEmail(ctx, email)
+	if err != nil {
+		r.logger.Info("request password reset", zap.Error(err))
+		return resp, nil
+	}
+
+	// the link is stored and mailed in the background, so the response takes as long as for an unknown email
+	r.sendInBackground("request password reset", func(ctx context.Context) {
+		r.sendPasswordReset(ctx, user)
+	})
+	return resp, nil
+}
+
+func (r *mutationResolver) ResetPassword(ctx context.Context, token string, newPasscode string) (*types.Response, error) {
+	if err := validatePasscode("newPasscode", newPasscode); err != nil {
+		r.logger.Error("reset password", zap.Error(err))
+		return nil, err
+	}
+
+	// the token is checked before hashing, so unknown tokens do not cost a hash
+	reset, err := r.store.GetOneTimeToken(ctx, securetoken.Hash(token))
+	if err == nil {
+		err = db.CheckOneTimeToken(reset, models.PurposePasswordReset, r.clock.Now().Unix())
+	}
+	if err == db.ErrNotFound || err == db.ErrTokenUsed || err == db.ErrTokenExpired {
+		err = rerrors.Format(rerrors.InvalidTokenErr, err)
+		r.logger.Error("reset password", zap.Error(err))
+		return nil, err
+	} else if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("reset password", zap.Error(err))
+		return nil, err
+	}
+
+	hashPasscode, err := r.encryptor.HashPassword(newPasscode)
+	if err != nil {
+		err := rerrors.Format(rerrors.InternalErr, nil)
+		r.logger.Error("reset password", zap.Error(err))
+		return nil, err
+	}
+
+	// using the token up before the update makes it single use, of two concurrent resets only one gets here
+	reset, err = r.store.UseOneTimeToken(ctx, reset.ID, models.PurposePasswordReset, r.clock.Now().Unix())
+	if err == db.ErrNotFound || err == db.ErrTokenUsed || err == db.ErrTokenExpired {
+		err = rerrors.Format(rerrors.InvalidTokenErr, err)
+		r.logger.Error("reset password", zap.Error(err))
+		return nil, err
+	} else if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("reset password", zap.Error(err))
+		return nil, err
+	}
+
+	if err := r.store.UpdateUser(ctx, reset.Owner, models.UserInfo{Password: &hashPasscode}); err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("reset password", zap.Error(err))
+		return nil, err
+	}
+	// the other reset links of the user are dropped, and whoever knew the old passcode is signed out
+	if err := r.store.DeleteOneTimeTokens(ctx, reset.Owner, models.PurposePasswordReset); err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("reset password", zap.Error(err))
+		return nil, err
+	}
+	if err := r.signOutAll(ctx, reset.Owner); err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("reset password", zap.Error(err))
+		return nil, err
+	}
+
+	return &types.Response{
+		Success: true,
+		Message: "Successfully reset passcode, please log in again",
+	}, nil
+}
+
+func (r *mutationResolver) VerifyEmail(ctx context.Context, token string) (*types.Response, error) {
+	verification, err := r.store.UseOneTimeToken(ctx, securetoken.Hash(token), models.PurposeEmailVerification, r.clock.Now().Unix())
+	if err == db.ErrNotFound || err == db.ErrTokenUsed || err == db.ErrTokenExpired {
+		err = rerrors.Format(rerrors.InvalidTokenErr, err)
+		r.logger.Error("verify email", zap.Error(err))
+		return nil, err
+	} else if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("verify email", zap.Error(err))
+		return nil, err
+	}
+
+	verified := true
+	if err := r.store.UpdateUser(ctx, verification.Owner, models.UserInfo{EmailVerified: &verified}); err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("verify email", zap.Error(err))
+		return nil, err
+	}
+	if err := r.store.DeleteOneTimeTokens(ctx, verification.Owner, models.PurposeEmailVerification); err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("verify email", zap.Error(err))
+		return nil, err
+	}
+
+	return &types.Response{
+		Success: true,
+		Message: "Successfully verified email",
+	}, nil
+}
+
+func (r *mutationResolver) ResendVerification(ctx context.Context) (*types.Response, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("resend verification", zap.Error(err))
+		return nil, err
+	}
+
+	user, err := r.store.GetUser(ctx, claims.UserId)
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("resend verification", zap.Error(err))
+		return nil, err
+	}
+	if user.EmailVerified {
+		err := rerrors.Format(rerrors.EmailVerifiedErr, nil)
+		r.logger.Error("resend verification", zap.Error(err))
+		return nil, err
+	}
+
+	if err := r.sendVerification(ctx, user); err != nil {
+		err = rerrors.Format(rerrors.InternalErr, err)
+		r.logger.Error("resend verification", zap.Error(err))
+		return nil, err
+	}
+
+	return &types.Response{
+		Success: true,
+		Message: "Successfully sent verification link to " + user.Email,
+	}, nil
+}
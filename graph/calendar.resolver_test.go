@@ -0,0 +1,64 @@
+package graph
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/victor-nach/time-tracker/lib/ical"
+	"github.com/victor-nach/time-tracker/lib/securetoken"
+	"github.com/victor-nach/time-tracker/lib/tokenhandler"
+	"github.com/victor-nach/time-tracker/mocks"
+	"github.com/victor-nach/time-tracker/models"
+	"github.com/victor-nach/time-tracker/server/middlewares"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestMutationResolver_ImportCalendar(t *testing.T) {
+	storeMock := new(mocks.Datastore)
+	resolvers := NewResolver(storeMock, nil, zaptest.NewLogger(t))
+	ctx := context.WithValue(context.Background(), middlewares.AuthContextKey,
+		tokenhandler.Claims{UserId: "userId"})
+
+	calendar := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\nUID:first\r\nSUMMARY:Standup\r\nCATEGORIES:meeting\r\nDTSTART:20210301T090000Z\r\nDTEND:20210301T091500Z\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:second\r\nSUMMARY:Planning\r\nDTSTART:20210301T100000Z\r\nDTEND:20210301T110000Z\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	events, err := ical.Parse(strings.NewReader(calendar), nil)
+	assert.NoError(t, err)
+
+	storeMock.On("GetUser", mock.Anything, "userId").Return(&models.User{ID: "userId"}, nil)
+	storeMock.On("GetImportedHashes", mock.Anything, "userId", []string{events[0].Hash(), events[1].Hash()}).
+		Return([]string{events[1].Hash()}, nil)
+	storeMock.On("CreateSessions", mock.Anything, mock.MatchedBy(func(sessions []*models.Session) bool {
+		return len(sessions) == 1 && sessions[0].Draft && sessions[0].Title == "Standup" && sessions[0].Duration == 900
+	})).Return(nil)
+
+	drafts, err := resolvers.Mutation().ImportCalendar(ctx, graphql.Upload{File: strings.NewReader(calendar)})
+	assert.NoError(t, err)
+	assert.Len(t, drafts, 1)
+	assert.True(t, drafts[0].Draft)
+	assert.Equal(t, []string{"meeting"}, drafts[0].Tags)
+	storeMock.AssertExpectations(t)
+}
+
+func TestMutationResolver_RotateCalendarToken(t *testing.T) {
+	storeMock := new(mocks.Datastore)
+	resolvers := NewResolver(storeMock, nil, zaptest.NewLogger(t))
+	ctx := context.WithValue(context.Background(), middlewares.AuthContextKey,
+		tokenhandler.Claims{UserId: "userId"})
+
+	var stored string
+	storeMock.On("UpdateUser", mock.Anything, "userId", mock.Anything).Run(func(args mock.Arguments) {
+		stored = *args.Get(2).(models.UserInfo).CalendarToken
+	}).Return(nil)
+
+	feed, err := resolvers.Mutation().RotateCalendarToken(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "/calendar/"+feed.Token+".ics", feed.URL)
+	// only the hash of the token is stored
+	assert.Equal(t, securetoken.Hash(feed.Token), stored)
+}
@@ -0,0 +1,203 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+	types "github.com/victor-nach/time-tracker/graph/model"
+	"github.com/victor-nach/time-tracker/lib/ical"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"github.com/victor-nach/time-tracker/lib/securetoken"
+	"github.com/victor-nach/time-tracker/models"
+	"go.uber.org/zap"
+)
+
+func (r *mutationResolver) RotateCalendarToken(ctx context.Context) (*types.CalendarFeed, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("rotate calendar token", zap.Error(err))
+		return nil, err
+	}
+
+	token, err := securetoken.Generate()
+	if err != nil {
+		err = rerrors.Format(rerrors.InternalErr, err)
+		r.logger.Error("rotate calendar token", zap.Error(err))
+		return nil, err
+	}
+
+	// only the hash is stored, the token can not be shown again
+	hash := securetoken.Hash(token)
+	if err := r.store.UpdateUser(ctx, claims.UserId, models.UserInfo{CalendarToken: &hash}); err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("rotate calendar token", zap.Error(err))
+		return nil, err
+	}
+
+	return &types.CalendarFeed{
+		Token: token,
+		URL:   "/calendar/" + token + ".ics",
+	}, nil
+}
+
+func (r *mutationResolver) DisableCalendarFeed(ctx context.Context) (*types.Response, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("disable calendar feed", zap.Error(err))
+		return nil, err
+	}
+
+	disabled := ""
+	if err := r.store.UpdateUser(ctx, claims.UserId, models.UserInfo{CalendarToken: &disabled}); err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("disable calendar feed", zap.Error(err))
+		return nil, err
+	}
+
+	return &types.Response{
+		Success: true,
+		Message: "Successfully disabled calendar feed",
+	}, nil
+}
+
+func (r *mutationResolver) ImportCalendar(ctx context.Context, file graphql.Upload) ([]*types.Session, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("import calendar", zap.Error(err))
+		return nil, err
+	}
+
+	user, err := r.store.GetUser(ctx, claims.UserId)
+	if err != nil {
+		err = rerrors.Format(rerrors.CustomerNotFoundErr, err)
+		r.logger.Error("import calendar", zap.Error(err))
+		return nil, err
+	}
+
+	events, err := ical.Parse(file.File, user.Location())
+	if err != nil {
+		err = rerrors.Format(rerrors.ImportFormatErr, err)
+		r.logger.Error("import calendar", zap.Error(err))
+		return nil, err
+	}
+
+	// events uploaded before are skipped, whether their drafts were confirmed or not
+	hashes := make([]string, len(events))
+	for i, event := range events {
+		hashes[i] = event.Hash()
+	}
+	imported, err := r.store.GetImportedHashes(ctx, claims.UserId, hashes)
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("import calendar", zap.Error(err))
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, hash := range imported {
+		seen[hash] = true
+	}
+
+	now := r.clock.Now().Unix()
+	drafts := []*models.Session{}
+	for i, event := range events {
+		if seen[hashes[i]] {
+			continue
+		}
+		seen[hashes[i]] = true
+		drafts = append(drafts, &models.Session{
+			ID:          r.idGen.Generate(),
+			Owner:       claims.UserId,
+			Title:       event.Summary,
+			Description: event.Description,
+			Tags:        normalizeTags(event.Categories),
+			Start:       event.Start,
+			End:         event.End,
+			Duration:    event.End - event.Start,
+			Segments:    []models.Segment{{Start: event.Start, End: event.End}},
+			ImportHash:  hashes[i],
+			Draft:       true,
+			Ts:          now,
+		})
+	}
+
+	if err := r.store.CreateSessions(ctx, drafts); err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("import calendar", zap.Error(err))
+		return nil, err
+	}
+
+	sessionsResp := make([]*types.Session, len(drafts))
+	for i, s := range drafts {
+		sessionsResp[i] = mapSession(s, nil)
+	}
+	return sessionsResp, nil
+}
+
+func (r *mutationResolver) ConfirmDrafts(ctx context.Context, ids []string) (*types.Response, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("confirm drafts", zap.Error(err))
+		return nil, err
+	}
+
+	user, err := r.store.GetUser(ctx, claims.UserId)
+	if err != nil {
+		err = rerrors.Format(rerrors.CustomerNotFoundErr, err)
+		r.logger.Error("confirm drafts", zap.Error(err))
+		return nil, err
+	}
+
+	confirmed, conflicts, err := r.store.ConfirmDrafts(ctx, claims.UserId, ids, user.OverlapPolicy)
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("confirm drafts", zap.Error(err))
+		return nil, err
+	}
+
+	resp := &types.Response{
+		Success: true,
+		Message: fmt.Sprintf("Successfully confirmed %d sessions", confirmed),
+	}
+	if len(conflicts) > 0 {
+		resp.Overlaps = overlapIDs(conflicts)
+		switch user.OverlapPolicy {
+		case models.OverlapReject:
+			resp.Message = fmt.Sprintf("Confirmed %d sessions, drafts overlapping sessions you already saved were kept as drafts", confirmed)
+		case models.OverlapTrim:
+			resp.Message = fmt.Sprintf("Confirmed %d sessions without the time they overlapped with sessions you already saved", confirmed)
+		default:
+			resp.Message = fmt.Sprintf("Confirmed %d sessions, they overlap sessions you already saved", confirmed)
+		}
+	}
+	return resp, nil
+}
+
+func (r *queryResolver) DraftSessions(ctx context.Context) ([]*types.Session, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("draft sessions", zap.Error(err))
+		return nil, err
+	}
+
+	drafts, err := r.store.GetDraftSessions(ctx, claims.UserId)
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("draft sessions", zap.Error(err))
+		return nil, err
+	}
+
+	sessionsResp := make([]*types.Session, len(drafts))
+	for i, s := range drafts {
+		sessionsResp[i] = mapSession(s, nil)
+	}
+	return sessionsResp, nil
+}
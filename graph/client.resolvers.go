@@ -0,0 +1,237 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+
+	"github.com/victor-nach/time-tracker/db"
+	types "github.com/victor-nach/time-tracker/graph/model"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"github.com/victor-nach/time-tracker/models"
+	"go.uber.org/zap"
+)
+
+func (r *mutationResolver) CreateClient(ctx context.Context, input types.ClientInput) (*types.Client, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("create client", zap.Error(err))
+		return nil, err
+	}
+
+	client := models.Client{
+		ID:    r.idGen.Generate(),
+		Owner: claims.UserId,
+		Name:  input.Name,
+		Ts:    r.clock.Now().Unix(),
+	}
+
+	if _, err := r.store.CreateClient(ctx, &client); err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("create client", zap.Error(err))
+		return nil, err
+	}
+
+	return mapClient(&client), nil
+}
+
+func (r *mutationResolver) UpdateClient(ctx context.Context, id string, input types.UpdateClientInput) (*types.Client, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("update client", zap.Error(err))
+		return nil, err
+	}
+
+	if _, err := r.store.GetClient(ctx, id, claims.UserId); err != nil {
+		err = rerrors.Format(rerrors.ClientNotFoundErr, err)
+		r.logger.Error("update client", zap.Error(err))
+		return nil, err
+	}
+
+	clientInfo := models.ClientInfo{
+		Name:     input.Name,
+		Archived: input.Archived,
+	}
+	if err := r.store.UpdateClient(ctx, id, clientInfo); err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("update client", zap.Error(err))
+		return nil, err
+	}
+
+	client, err := r.store.GetClient(ctx, id, claims.UserId)
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("update client", zap.Error(err))
+		return nil, err
+	}
+
+	return mapClient(client), nil
+}
+
+func (r *mutationResolver) DeleteClient(ctx context.Context, id string, archive *bool) (*types.Response, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("delete client", zap.Error(err))
+		return nil, err
+	}
+
+	if _, err := r.store.GetClient(ctx, id, claims.UserId); err != nil {
+		err = rerrors.Format(rerrors.ClientNotFoundErr, err)
+		r.logger.Error("delete client", zap.Error(err))
+		return nil, err
+	}
+
+	err = r.store.DeleteClient(ctx, id)
+	if err == db.ErrClientInUse && archive != nil && *archive {
+		archived := true
+		if err := r.store.UpdateClient(ctx, id, models.ClientInfo{Archived: &archived}); err != nil {
+			err = rerrors.Format(rerrors.DatabaseErr, err)
+			r.logger.Error("archive client", zap.Error(err))
+			return nil, err
+		}
+
+		return &types.Response{
+			Success: true,
+			Message: "Client still has projects, successfully archived client",
+		}, nil
+	}
+	if err != nil {
+		code := rerrors.DatabaseErr
+		if err == db.ErrClientInUse {
+			code = rerrors.ClientInUseErr
+		}
+		err = rerrors.Format(code, err)
+		r.logger.Error("delete client", zap.Error(err))
+		return nil, err
+	}
+
+	return &types.Response{
+		Success: true,
+		Message: "Successfully deleted client",
+	}, nil
+}
+
+func (r *mutationResolver) SetHourlyRate(ctx context.Context, input types.RateInput) (*types.Rate, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("set hourly rate", zap.Error(err))
+		return nil, err
+	}
+
+	if input.HourlyRate < 0 {
+		err := rerrors.Format(rerrors.InvalidRequestErr, nil)
+		r.logger.Error("set hourly rate", zap.Error(err))
+		return nil, err
+	}
+
+	now := r.clock.Now().Unix()
+	rate := models.Rate{
+		ID:            r.idGen.Generate(),
+		Owner:         claims.UserId,
+		Scope:         input.Scope.String(),
+		HourlyRate:    input.HourlyRate,
+		EffectiveFrom: now,
+		Ts:            now,
+	}
+	if input.EffectiveFrom != nil {
+		rate.EffectiveFrom = int64(*input.EffectiveFrom)
+	}
+
+	scopeID := ""
+	if input.ScopeID != nil {
+		scopeID = *input.ScopeID
+	}
+	switch input.Scope {
+	case types.RateScopeClient:
+		if _, err := r.store.GetClient(ctx, scopeID, claims.UserId); err != nil {
+			err = rerrors.Format(rerrors.ClientNotFoundErr, err)
+			r.logger.Error("set hourly rate", zap.Error(err))
+			return nil, err
+		}
+		rate.ScopeID = scopeID
+	case types.RateScopeProject:
+		if _, err := r.store.GetProject(ctx, scopeID, claims.UserId); err != nil {
+			err = rerrors.Format(rerrors.ProjectNotFoundErr, err)
+			r.logger.Error("set hourly rate", zap.Error(err))
+			return nil, err
+		}
+		rate.ScopeID = scopeID
+	}
+
+	if _, err := r.store.CreateRate(ctx, &rate); err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("set hourly rate", zap.Error(err))
+		return nil, err
+	}
+
+	return mapRate(&rate), nil
+}
+
+func (r *queryResolver) Client(ctx context.Context, id string) (*types.Client, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("client", zap.Error(err))
+		return nil, err
+	}
+
+	client, err := r.store.GetClient(ctx, id, claims.UserId)
+	if err != nil {
+		err = rerrors.Format(rerrors.ClientNotFoundErr, err)
+		r.logger.Error("client", zap.Error(err))
+		return nil, err
+	}
+
+	return mapClient(client), nil
+}
+
+func (r *queryResolver) Clients(ctx context.Context, includeArchived *bool) ([]*types.Client, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("clients", zap.Error(err))
+		return nil, err
+	}
+
+	clients, err := r.store.GetClients(ctx, claims.UserId, includeArchived != nil && *includeArchived)
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("clients", zap.Error(err))
+		return nil, err
+	}
+
+	clientsResp := make([]*types.Client, len(clients))
+	for i, c := range clients {
+		clientsResp[i] = mapClient(c)
+	}
+
+	return clientsResp, nil
+}
+
+func (r *queryResolver) Rates(ctx context.Context) ([]*types.Rate, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("rates", zap.Error(err))
+		return nil, err
+	}
+
+	rates, err := r.store.GetRates(ctx, claims.UserId)
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("rates", zap.Error(err))
+		return nil, err
+	}
+
+	ratesResp := make([]*types.Rate, len(rates))
+	for i, rate := range rates {
+		ratesResp[i] = mapRate(rate)
+	}
+
+	return ratesResp, nil
+}
@@ -0,0 +1,91 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"github.com/victor-nach/time-tracker/lib/tokenhandler"
+	"github.com/victor-nach/time-tracker/mocks"
+	"github.com/victor-nach/time-tracker/models"
+	"github.com/victor-nach/time-tracker/server/middlewares"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestQueryResolver_Devices(t *testing.T) {
+	storeMock := new(mocks.Datastore)
+	resolvers := NewResolver(storeMock, nil, zaptest.NewLogger(t))
+	ctx := context.WithValue(context.Background(), middlewares.AuthContextKey,
+		tokenhandler.Claims{UserId: "userId", SessionId: "phone"})
+
+	storeMock.On("GetLoginSessions", mock.Anything, "userId").Return([]*models.LoginSession{
+		{ID: "phone", Owner: "userId", UserAgent: "app", IP: "10.0.0.1", CreatedAt: 100, LastUsedAt: 300},
+		{ID: "laptop", Owner: "userId", UserAgent: "firefox", IP: "10.0.0.2", CreatedAt: 200, LastUsedAt: 200},
+	}, nil)
+
+	devices, err := resolvers.Query().Devices(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, devices, 2)
+	assert.Equal(t, "phone", devices[0].ID)
+	assert.Equal(t, "app", devices[0].UserAgent)
+	assert.Equal(t, 300, devices[0].LastUsedAt)
+	assert.True(t, devices[0].Current)
+	assert.False(t, devices[1].Current)
+
+	_, err = resolvers.Query().Devices(context.Background())
+	assert.Equal(t, rerrors.InvalidAuthErr, err.(*rerrors.Err).Code)
+}
+
+func TestMutationResolver_RevokeDevice(t *testing.T) {
+	const (
+		success = iota
+		deviceNotFoundError
+	)
+
+	var tests = []struct {
+		name     string
+		testType int
+	}{
+		{
+			name:     "Successfully revoke device",
+			testType: success,
+		},
+		{
+			name:     "Test device of another user",
+			testType: deviceNotFoundError,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			storeMock := new(mocks.Datastore)
+			resolvers := NewResolver(storeMock, nil, zaptest.NewLogger(t))
+			ctx := context.WithValue(context.Background(), middlewares.AuthContextKey,
+				tokenhandler.Claims{UserId: "userId"})
+
+			switch testCase.testType {
+			case success:
+				storeMock.On("GetLoginSession", mock.Anything, "laptop", "userId").
+					Return(&models.LoginSession{ID: "laptop", Owner: "userId"}, nil)
+				storeMock.On("RevokeRefreshFamily", mock.Anything, "laptop").Return(nil)
+				storeMock.On("DeleteLoginSession", mock.Anything, "laptop").Return(nil)
+
+				resp, err := resolvers.Mutation().RevokeDevice(ctx, "laptop")
+				assert.NoError(t, err)
+				assert.True(t, resp.Success)
+				storeMock.AssertNotCalled(t, "IncrementTokenGeneration", mock.Anything, mock.Anything)
+
+			case deviceNotFoundError:
+				storeMock.On("GetLoginSession", mock.Anything, "laptop", "userId").Return(nil, db.ErrNotFound)
+
+				_, err := resolvers.Mutation().RevokeDevice(ctx, "laptop")
+				assert.Equal(t, rerrors.DeviceNotFoundErr, err.(*rerrors.Err).Code)
+				storeMock.AssertNotCalled(t, "RevokeRefreshFamily", mock.Anything, mock.Anything)
+			}
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+
+	types "github.com/victor-nach/time-tracker/graph/model"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"go.uber.org/zap"
+)
+
+func (r *mutationResolver) RevokeDevice(ctx context.Context, id string) (*types.Response, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("revoke device", zap.Error(err))
+		return nil, err
+	}
+
+	if _, err := r.store.GetLoginSession(ctx, id, claims.UserId); err != nil {
+		err = rerrors.Format(rerrors.DeviceNotFoundErr, err)
+		r.logger.Error("revoke device", zap.Error(err))
+		return nil, err
+	}
+
+	if err := r.signOut(ctx, id); err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("revoke device", zap.Error(err))
+		return nil, err
+	}
+
+	return &types.Response{
+		Success: true,
+		Message: "Successfully signed out device",
+	}, nil
+}
+
+func (r *queryResolver) Devices(ctx context.Context) ([]*types.Device, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("devices", zap.Error(err))
+		return nil, err
+	}
+
+	logins, err := r.store.GetLoginSessions(ctx, claims.UserId)
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("devices", zap.Error(err))
+		return nil, err
+	}
+
+	devices := make([]*types.Device, 0, len(logins))
+	for _, login := range logins {
+		devices = append(devices, mapDevice(login, claims.SessionId))
+	}
+	return devices, nil
+}
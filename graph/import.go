@@ -0,0 +1,188 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/victor-nach/time-tracker/db"
+	types "github.com/victor-nach/time-tracker/graph/model"
+	"github.com/victor-nach/time-tracker/lib/importer"
+	"github.com/victor-nach/time-tracker/lib/validation"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+// applyImportOverlaps checks the sessions of an import, ordered like their rows, against the sessions
+// saved when the import started and the sessions of the rows before them. It follows the overlap policy
+// like saveSession: rejected sessions are left out and reported as errors of their rows
+func (r *mutationResolver) applyImportOverlaps(ctx context.Context, owner, policy string, sessions []*models.Session,
+	rows []int, result *types.ImportResult) ([]*models.Session, error) {
+	if len(sessions) == 0 {
+		return sessions, nil
+	}
+	from, to := sessions[0].Start, sessions[0].End
+	for _, s := range sessions {
+		if s.Start < from {
+			from = s.Start
+		}
+		if s.End > to {
+			to = s.End
+		}
+	}
+	saved, err := r.store.GetSessions(ctx, owner, models.SessionFilter{From: from, To: to})
+	if err != nil {
+		return nil, err
+	}
+
+	// the sessions of the import are not saved yet, their rows tell the user which ones they are
+	importedRows := map[string]int{}
+	kept := make([]*models.Session, 0, len(sessions))
+	for i, s := range sessions {
+		var candidates []*models.Session
+		for _, c := range saved {
+			if c.Start < s.End && c.End > s.Start {
+				candidates = append(candidates, c)
+			}
+		}
+		conflicts, err := db.ApplyOverlapPolicy(s, candidates, policy)
+		if err == db.ErrSessionOverlap {
+			overlaps := overlapIDs(conflicts)
+			for j, id := range overlaps {
+				if row, ok := importedRows[id]; ok {
+					overlaps[j] = fmt.Sprintf("row %d", row)
+				}
+			}
+			result.Errors = append(result.Errors, &types.ImportRowError{
+				Row:     rows[i],
+				Message: "overlaps " + strings.Join(overlaps, ", "),
+			})
+			continue
+		}
+		saved = append(saved, s)
+		importedRows[s.ID] = rows[i]
+		kept = append(kept, s)
+	}
+
+	sort.SliceStable(result.Errors, func(i, j int) bool { return result.Errors[i].Row < result.Errors[j].Row })
+	return kept, nil
+}
+
+// importProjects resolves the project and client names of imported entries, matching existing ones
+// case insensitively. Missing ones get an id while the rows are mapped and are only created by create,
+// for the sessions that are kept, unless the import is a dry run
+type importProjects struct {
+	r        *Resolver
+	owner    string
+	result   *types.ImportResult
+	projects map[string]string
+	clients  map[string]string
+	// pending holds the projects to create by id, with the name of their client
+	pending map[string]*pendingProject
+}
+
+type pendingProject struct {
+	project *models.Project
+	client  string
+}
+
+func (r *Resolver) newImportProjects(ctx context.Context, owner string, result *types.ImportResult) (*importProjects, error) {
+	p := &importProjects{r: r, owner: owner, result: result, projects: map[string]string{}, clients: map[string]string{},
+		pending: map[string]*pendingProject{}}
+
+	projects, err := r.store.GetProjects(ctx, owner, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, project := range projects {
+		p.projects[strings.ToLower(project.Name)] = project.ID
+	}
+	clients, err := r.store.GetClients(ctx, owner, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, client := range clients {
+		p.clients[strings.ToLower(client.Name)] = client.ID
+	}
+	return p, nil
+}
+
+// resolve returns the id of the named project, a missing project is attached to the named client once created
+func (p *importProjects) resolve(project, client string) string {
+	if project == "" {
+		return ""
+	}
+	if id, ok := p.projects[strings.ToLower(project)]; ok {
+		return id
+	}
+
+	newProject := &models.Project{
+		ID:    p.r.idGen.Generate(),
+		Owner: p.owner,
+		Name:  project,
+		Ts:    p.r.clock.Now().Unix(),
+	}
+	p.projects[strings.ToLower(project)] = newProject.ID
+	p.pending[newProject.ID] = &pendingProject{project: newProject, client: client}
+	return newProject.ID
+}
+
+// create creates the missing projects and clients the sessions use, in the order of the sessions
+func (p *importProjects) create(ctx context.Context, sessions []*models.Session) error {
+	for _, s := range sessions {
+		pending, ok := p.pending[s.ProjectID]
+		if !ok {
+			continue
+		}
+		delete(p.pending, s.ProjectID)
+
+		clientID, err := p.resolveClient(ctx, pending.client)
+		if err != nil {
+			return err
+		}
+		pending.project.ClientID = clientID
+		if !p.result.DryRun {
+			if _, err := p.r.store.CreateProject(ctx, pending.project); err != nil {
+				return err
+			}
+		}
+		p.result.CreatedProjects = append(p.result.CreatedProjects, pending.project.Name)
+	}
+	return nil
+}
+
+func (p *importProjects) resolveClient(ctx context.Context, client string) (string, error) {
+	if client == "" {
+		return "", nil
+	}
+	if id, ok := p.clients[strings.ToLower(client)]; ok {
+		return id, nil
+	}
+
+	newClient := &models.Client{
+		ID:    p.r.idGen.Generate(),
+		Owner: p.owner,
+		Name:  client,
+		Ts:    p.r.clock.Now().Unix(),
+	}
+	if !p.result.DryRun {
+		if _, err := p.r.store.CreateClient(ctx, newClient); err != nil {
+			return "", err
+		}
+	}
+	p.clients[strings.ToLower(client)] = newClient.ID
+	p.result.CreatedClients = append(p.result.CreatedClients, client)
+	return newClient.ID, nil
+}
+
+// validateImportEntry applies the limits of saveSession to the free text fields of an imported row,
+// it returns the invalid fields as the message of a row error
+func validateImportEntry(entry importer.Entry, tags []string) string {
+	v := &validation.Errors{}
+	validateSessionInfo(v, &entry.Title, &entry.Description, tags)
+	messages := make([]string, len(v.Fields()))
+	for i, field := range v.Fields() {
+		messages[i] = field.Field + " " + field.Message
+	}
+	return strings.Join(messages, ", ")
+}
@@ -0,0 +1,184 @@
+package graph
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/victor-nach/time-tracker/lib/importer"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"github.com/victor-nach/time-tracker/lib/tokenhandler"
+	"github.com/victor-nach/time-tracker/mocks"
+	"github.com/victor-nach/time-tracker/models"
+	"github.com/victor-nach/time-tracker/server/middlewares"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestMutationResolver_ImportSessions(t *testing.T) {
+	const (
+		dryRun = iota
+		importSessions
+		overlapRejected
+		rejectedProject
+		invalidRow
+		unknownLayoutError
+	)
+
+	const export = "Date,Client,Project,Project Code,Task,Notes,Hours,Hours Rounded,Billable?,Invoiced?\n" +
+		"2021-03-01,Acme,Website,,,Landing page,1.5,1.5,No,No\n" +
+		"2021-03-02,,Blog,,,Post,1,1,No,No\n" +
+		"2021-03-03,,Blog,,,Post,oops,1,No,No\n"
+
+	var tests = []struct {
+		name     string
+		testType int
+		policy   string
+	}{
+		{
+			name:     "Successfully preview an import",
+			testType: dryRun,
+		},
+		{
+			name:     "Successfully import sessions and skip duplicates",
+			testType: importSessions,
+		},
+		{
+			name:     "Test rows overlapping saved sessions are rejected",
+			testType: overlapRejected,
+			policy:   models.OverlapReject,
+		},
+		{
+			name:     "Test projects and clients of rejected rows are not created",
+			testType: rejectedProject,
+			policy:   models.OverlapReject,
+		},
+		{
+			name:     "Test rows over the length limits are row errors",
+			testType: invalidRow,
+		},
+		{
+			name:     "Test unknown layout error",
+			testType: unknownLayoutError,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			storeMock := new(mocks.Datastore)
+			resolvers := NewResolver(storeMock, nil, zaptest.NewLogger(t))
+			ctx := context.WithValue(context.Background(), middlewares.AuthContextKey,
+				tokenhandler.Claims{UserId: "userId"})
+			storeMock.On("GetUser", mock.Anything, "userId").Return(&models.User{ID: "userId", OverlapPolicy: testCase.policy}, nil)
+
+			_, entries, _, err := importer.Parse(strings.NewReader(export), time.UTC)
+			assert.NoError(t, err)
+			hashes := []string{entries[0].Hash(importer.SourceHarvest), entries[1].Hash(importer.SourceHarvest)}
+			storeMock.On("GetProjects", mock.Anything, "userId", true).Return([]*models.Project{{ID: "blogId", Name: "blog"}}, nil)
+			storeMock.On("GetClients", mock.Anything, "userId", true).Return([]*models.Client{}, nil)
+			rangeFilter := models.SessionFilter{From: entries[0].Start, To: entries[1].End}
+
+			switch testCase.testType {
+			case dryRun:
+				storeMock.On("GetImportedHashes", mock.Anything, "userId", hashes).Return([]string{}, nil)
+				storeMock.On("GetSessions", mock.Anything, "userId", rangeFilter).Return([]*models.Session{}, nil)
+
+				yes := true
+				result, err := resolvers.Mutation().ImportSessions(ctx, graphql.Upload{File: strings.NewReader(export)}, &yes)
+				assert.NoError(t, err)
+				assert.Equal(t, importer.SourceHarvest, result.Source)
+				assert.True(t, result.DryRun)
+				assert.Equal(t, 0, result.Imported)
+				assert.Len(t, result.Sessions, 2)
+				assert.Equal(t, "blogId", *result.Sessions[1].ProjectID)
+				assert.Equal(t, []string{"Website"}, result.CreatedProjects)
+				assert.Equal(t, []string{"Acme"}, result.CreatedClients)
+				assert.Len(t, result.Errors, 1)
+				assert.Equal(t, 4, result.Errors[0].Row)
+				storeMock.AssertNotCalled(t, "CreateProject", mock.Anything, mock.Anything)
+				storeMock.AssertNotCalled(t, "CreateSessions", mock.Anything, mock.Anything)
+
+			case importSessions:
+				storeMock.On("GetImportedHashes", mock.Anything, "userId", hashes).Return([]string{hashes[0]}, nil)
+				storeMock.On("GetSessions", mock.Anything, "userId", models.SessionFilter{From: entries[1].Start, To: entries[1].End}).
+					Return([]*models.Session{}, nil)
+				storeMock.On("CreateSessions", mock.Anything, mock.MatchedBy(func(sessions []*models.Session) bool {
+					return len(sessions) == 1 && sessions[0].ProjectID == "blogId" && sessions[0].ImportHash == hashes[1] &&
+						sessions[0].Duration == 3600
+				})).Return(nil)
+
+				result, err := resolvers.Mutation().ImportSessions(ctx, graphql.Upload{File: strings.NewReader(export)}, nil)
+				assert.NoError(t, err)
+				assert.Equal(t, 1, result.Imported)
+				assert.Equal(t, 1, result.Duplicates)
+				assert.Empty(t, result.CreatedProjects)
+				storeMock.AssertExpectations(t)
+
+			case overlapRejected:
+				storeMock.On("GetImportedHashes", mock.Anything, "userId", hashes).Return([]string{}, nil)
+				saved := &models.Session{ID: "savedId", Owner: "userId", Start: entries[1].Start, End: entries[1].Start + 60}
+				storeMock.On("GetSessions", mock.Anything, "userId", rangeFilter).Return([]*models.Session{saved}, nil)
+				storeMock.On("CreateProject", mock.Anything, mock.Anything).Return(nil, nil)
+				storeMock.On("CreateClient", mock.Anything, mock.Anything).Return(nil, nil)
+				storeMock.On("CreateSessions", mock.Anything, mock.MatchedBy(func(sessions []*models.Session) bool {
+					return len(sessions) == 1 && sessions[0].ImportHash == hashes[0]
+				})).Return(nil)
+
+				result, err := resolvers.Mutation().ImportSessions(ctx, graphql.Upload{File: strings.NewReader(export)}, nil)
+				assert.NoError(t, err)
+				assert.Equal(t, 1, result.Imported)
+				if assert.Len(t, result.Errors, 2) {
+					assert.Equal(t, 3, result.Errors[0].Row)
+					assert.Equal(t, "overlaps savedId", result.Errors[0].Message)
+					assert.Equal(t, 4, result.Errors[1].Row)
+				}
+
+			case rejectedProject:
+				storeMock.On("GetImportedHashes", mock.Anything, "userId", hashes).Return([]string{}, nil)
+				saved := &models.Session{ID: "savedId", Owner: "userId", Start: entries[0].Start, End: entries[0].Start + 60}
+				storeMock.On("GetSessions", mock.Anything, "userId", rangeFilter).Return([]*models.Session{saved}, nil)
+				storeMock.On("CreateSessions", mock.Anything, mock.MatchedBy(func(sessions []*models.Session) bool {
+					return len(sessions) == 1 && sessions[0].ProjectID == "blogId"
+				})).Return(nil)
+
+				result, err := resolvers.Mutation().ImportSessions(ctx, graphql.Upload{File: strings.NewReader(export)}, nil)
+				assert.NoError(t, err)
+				assert.Equal(t, 1, result.Imported)
+				assert.Empty(t, result.CreatedProjects)
+				assert.Empty(t, result.CreatedClients)
+				storeMock.AssertNotCalled(t, "CreateProject", mock.Anything, mock.Anything)
+				storeMock.AssertNotCalled(t, "CreateClient", mock.Anything, mock.Anything)
+
+			case invalidRow:
+				long := "2021-03-01,Acme,Website,,," + strings.Repeat("a", maxTitleLength+1) + ",1.5,1.5,No,No\n"
+				file := strings.Replace(export, "2021-03-01,Acme,Website,,,Landing page,1.5,1.5,No,No\n", long, 1)
+				_, invalid, _, err := importer.Parse(strings.NewReader(file), time.UTC)
+				assert.NoError(t, err)
+				storeMock.On("GetImportedHashes", mock.Anything, "userId", mock.Anything).Return([]string{}, nil)
+				storeMock.On("GetSessions", mock.Anything, "userId", models.SessionFilter{From: invalid[1].Start, To: invalid[1].End}).
+					Return([]*models.Session{}, nil)
+				storeMock.On("CreateSessions", mock.Anything, mock.MatchedBy(func(sessions []*models.Session) bool {
+					return len(sessions) == 1 && sessions[0].ProjectID == "blogId"
+				})).Return(nil)
+
+				result, err := resolvers.Mutation().ImportSessions(ctx, graphql.Upload{File: strings.NewReader(file)}, nil)
+				assert.NoError(t, err)
+				assert.Equal(t, 1, result.Imported)
+				assert.Empty(t, result.CreatedProjects)
+				if assert.Len(t, result.Errors, 2) {
+					assert.Equal(t, 2, result.Errors[0].Row)
+					assert.Equal(t, "title must be at most 200 characters", result.Errors[0].Message)
+				}
+
+			case unknownLayoutError:
+				result, err := resolvers.Mutation().ImportSessions(ctx, graphql.Upload{File: strings.NewReader("id,title\n")}, nil)
+				assert.Nil(t, result)
+				assert.IsType(t, &rerrors.Err{}, err)
+				assert.Equal(t, rerrors.ImportFormatErr, err.(*rerrors.Err).Code)
+			}
+		})
+	}
+}
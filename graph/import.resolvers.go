@@ -0,0 +1,141 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	types "github.com/victor-nach/time-tracker/graph/model"
+	"github.com/victor-nach/time-tracker/lib/importer"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"github.com/victor-nach/time-tracker/models"
+	"go.uber.org/zap"
+)
+
+func (r *mutationResolver) ImportSessions(ctx context.Context, file graphql.Upload, dryRun *bool) (*types.ImportResult, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("import sessions", zap.Error(err))
+		return nil, err
+	}
+
+	user, err := r.store.GetUser(ctx, claims.UserId)
+	if err != nil {
+		err = rerrors.Format(rerrors.CustomerNotFoundErr, err)
+		r.logger.Error("import sessions", zap.Error(err))
+		return nil, err
+	}
+
+	source, entries, rowErrs, err := importer.Parse(file.File, user.Location())
+	if err != nil {
+		code := rerrors.InvalidRequestErr
+		if err == importer.ErrUnknownLayout {
+			code = rerrors.ImportFormatErr
+		}
+		err = rerrors.Format(code, err)
+		r.logger.Error("import sessions", zap.Error(err))
+		return nil, err
+	}
+
+	result := &types.ImportResult{
+		Source:          source,
+		DryRun:          dryRun != nil && *dryRun,
+		CreatedProjects: []string{},
+		CreatedClients:  []string{},
+		Errors:          make([]*types.ImportRowError, len(rowErrs)),
+	}
+	for i, rowErr := range rowErrs {
+		result.Errors[i] = &types.ImportRowError{Row: rowErr.Row, Message: rowErr.Message}
+	}
+
+	// entries imported before, or repeated in the file, are skipped
+	hashes := make([]string, len(entries))
+	for i, entry := range entries {
+		hashes[i] = entry.Hash(source)
+	}
+	imported, err := r.store.GetImportedHashes(ctx, claims.UserId, hashes)
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("import sessions", zap.Error(err))
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, hash := range imported {
+		seen[hash] = true
+	}
+
+	projects, err := r.newImportProjects(ctx, claims.UserId, result)
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("import sessions", zap.Error(err))
+		return nil, err
+	}
+
+	now := r.clock.Now().Unix()
+	var sessions []*models.Session
+	var rows []int
+	for i, entry := range entries {
+		if seen[hashes[i]] {
+			result.Duplicates++
+			continue
+		}
+		tags := normalizeTags(entry.Tags)
+		if message := validateImportEntry(entry, tags); message != "" {
+			result.Errors = append(result.Errors, &types.ImportRowError{Row: entry.Row, Message: message})
+			continue
+		}
+		seen[hashes[i]] = true
+
+		sessions = append(sessions, &models.Session{
+			ID:          r.idGen.Generate(),
+			Owner:       claims.UserId,
+			Title:       entry.Title,
+			Description: entry.Description,
+			ProjectID:   projects.resolve(entry.Project, entry.Client),
+			Tags:        tags,
+			Billable:    entry.Billable,
+			Start:       entry.Start,
+			End:         entry.End,
+			Duration:    entry.End - entry.Start,
+			Segments:    []models.Segment{{Start: entry.Start, End: entry.End}},
+			ImportHash:  hashes[i],
+			Ts:          now,
+		})
+		rows = append(rows, entry.Row)
+	}
+
+	sessions, err = r.applyImportOverlaps(ctx, claims.UserId, user.OverlapPolicy, sessions, rows, result)
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("import sessions", zap.Error(err))
+		return nil, err
+	}
+
+	// only the projects and clients of the kept sessions are created
+	if err := projects.create(ctx, sessions); err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("import sessions", zap.Error(err))
+		return nil, err
+	}
+
+	if !result.DryRun {
+		if err := r.store.CreateSessions(ctx, sessions); err != nil {
+			err = rerrors.Format(rerrors.DatabaseErr, err)
+			r.logger.Error("import sessions", zap.Error(err))
+			return nil, err
+		}
+		result.Imported = len(sessions)
+	}
+
+	result.Sessions, err = r.mapSessions(ctx, claims.UserId, sessions)
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("import sessions", zap.Error(err))
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -16,41 +16,225 @@ type AuthResponse struct {
 	User         *User  `json:"User"`
 }
 
-type Response struct {
-	Success bool    `json:"success"`
-	Message string  `json:"message"`
-	Token   *string `json:"token"`
+type CalendarFeed struct {
+	Token string `json:"token"`
+	URL   string `json:"url"`
 }
 
-type Session struct {
+type Client struct {
+	ID       string `json:"id"`
+	Owner    string `json:"owner"`
+	Name     string `json:"name"`
+	Archived bool   `json:"archived"`
+	Ts       int    `json:"Ts"`
+}
+
+type ClientInput struct {
+	Name string `json:"name"`
+}
+
+type DateRange struct {
+	From *int `json:"from"`
+	To   *int `json:"to"`
+}
+
+type Device struct {
+	ID         string `json:"id"`
+	UserAgent  string `json:"userAgent"`
+	IP         string `json:"ip"`
+	CreatedAt  int    `json:"createdAt"`
+	LastUsedAt int    `json:"lastUsedAt"`
+	Current    bool   `json:"current"`
+}
+
+type ImportResult struct {
+	Source          string            `json:"source"`
+	DryRun          bool              `json:"dryRun"`
+	Imported        int               `json:"imported"`
+	Duplicates      int               `json:"duplicates"`
+	CreatedProjects []string          `json:"createdProjects"`
+	CreatedClients  []string          `json:"createdClients"`
+	Sessions        []*Session        `json:"sessions"`
+	Errors          []*ImportRowError `json:"errors"`
+}
+
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Message string `json:"message"`
+}
+
+type Overlap struct {
+	First    *Session `json:"first"`
+	Second   *Session `json:"second"`
+	Start    int      `json:"start"`
+	End      int      `json:"end"`
+	Duration int      `json:"duration"`
+}
+
+type PageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor"`
+	EndCursor       *string `json:"endCursor"`
+}
+
+type ProfileInput struct {
+	Name          *string        `json:"name"`
+	TimeZone      *string        `json:"timeZone"`
+	WeekStart     *Weekday       `json:"weekStart"`
+	OverlapPolicy *OverlapPolicy `json:"overlapPolicy"`
+}
+
+type Project struct {
 	ID          string  `json:"id"`
 	Owner       string  `json:"owner"`
-	Title       *string `json:"title"`
+	ClientID    *string `json:"clientId"`
+	Name        string  `json:"name"`
 	Description *string `json:"description"`
-	Start       int     `json:"start"`
-	End         int     `json:"end"`
-	Duration    int     `json:"duration"`
+	Archived    bool    `json:"archived"`
 	Ts          int     `json:"Ts"`
 }
 
-type SessionInput struct {
-	Title       *string `json:"title"`
+type ProjectInput struct {
+	ClientID    *string `json:"clientId"`
+	Name        string  `json:"name"`
 	Description *string `json:"description"`
-	Start       int     `json:"start"`
-	End         int     `json:"end"`
-	Duration    int     `json:"duration"`
+}
+
+type Rate struct {
+	ID            string    `json:"id"`
+	Scope         RateScope `json:"scope"`
+	ScopeID       *string   `json:"scopeId"`
+	HourlyRate    float64   `json:"hourlyRate"`
+	EffectiveFrom int       `json:"effectiveFrom"`
+	Ts            int       `json:"Ts"`
+}
+
+type RateInput struct {
+	Scope         RateScope `json:"scope"`
+	ScopeID       *string   `json:"scopeId"`
+	HourlyRate    float64   `json:"hourlyRate"`
+	EffectiveFrom *int      `json:"effectiveFrom"`
+}
+
+type Report struct {
+	TotalDuration int             `json:"totalDuration"`
+	SessionCount  int             `json:"sessionCount"`
+	Amount        float64         `json:"amount"`
+	Buckets       []*ReportBucket `json:"buckets"`
+}
+
+type ReportBucket struct {
+	Group         ReportGroup     `json:"group"`
+	Key           string          `json:"key"`
+	Label         string          `json:"label"`
+	TotalDuration int             `json:"totalDuration"`
+	SessionCount  int             `json:"sessionCount"`
+	Amount        float64         `json:"amount"`
+	Buckets       []*ReportBucket `json:"buckets"`
+}
+
+type Response struct {
+	Success  bool     `json:"success"`
+	Message  string   `json:"message"`
+	Token    *string  `json:"token"`
+	Overlaps []string `json:"overlaps"`
+}
+
+type Segment struct {
+	Start int  `json:"start"`
+	End   *int `json:"end"`
+}
+
+type SegmentInput struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+type Session struct {
+	ID          string     `json:"id"`
+	Owner       string     `json:"owner"`
+	Title       *string    `json:"title"`
+	Description *string    `json:"description"`
+	ProjectID   *string    `json:"projectId"`
+	Tags        []string   `json:"tags"`
+	Billable    bool       `json:"billable"`
+	Start       int        `json:"start"`
+	End         int        `json:"end"`
+	Duration    int        `json:"duration"`
+	Amount      float64    `json:"amount"`
+	Segments    []*Segment `json:"segments"`
+	Running     bool       `json:"running"`
+	Paused      bool       `json:"paused"`
+	Draft       bool       `json:"draft"`
+	Ts          int        `json:"Ts"`
+}
+
+type SessionConnection struct {
+	Edges      []*SessionEdge `json:"edges"`
+	PageInfo   *PageInfo      `json:"pageInfo"`
+	TotalCount int            `json:"totalCount"`
+}
+
+type SessionEdge struct {
+	Cursor string   `json:"cursor"`
+	Node   *Session `json:"node"`
+}
+
+type SessionFilter struct {
+	Period    *FilterType `json:"period"`
+	Range     *DateRange  `json:"range"`
+	ProjectID *string     `json:"projectId"`
+	AnyTags   []string    `json:"anyTags"`
+	AllTags   []string    `json:"allTags"`
+}
+
+type SessionInput struct {
+	Title       *string         `json:"title"`
+	Description *string         `json:"description"`
+	ProjectID   *string         `json:"projectId"`
+	Tags        []string        `json:"tags"`
+	Billable    *bool           `json:"billable"`
+	Start       int             `json:"start"`
+	End         int             `json:"end"`
+	Duration    *int            `json:"duration"`
+	Segments    []*SegmentInput `json:"segments"`
+}
+
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
 }
 
 type User struct {
-	ID    string  `json:"id"`
-	Name  *string `json:"name"`
-	Email string  `json:"email"`
-	Ts    int     `json:"Ts"`
+	ID            string        `json:"id"`
+	Name          *string       `json:"name"`
+	Email         string        `json:"email"`
+	TimeZone      string        `json:"timeZone"`
+	WeekStart     Weekday       `json:"weekStart"`
+	OverlapPolicy OverlapPolicy `json:"overlapPolicy"`
+	EmailVerified bool          `json:"emailVerified"`
+	Ts            int           `json:"Ts"`
 }
 
-type UpdateSessionInput struct {
-	Title       *string `json:"title"`
+type UpdateClientInput struct {
+	Name     *string `json:"name"`
+	Archived *bool   `json:"archived"`
+}
+
+type UpdateProjectInput struct {
+	ClientID    *string `json:"clientId"`
+	Name        *string `json:"name"`
 	Description *string `json:"description"`
+	Archived    *bool   `json:"archived"`
+}
+
+type UpdateSessionInput struct {
+	Title       *string  `json:"title"`
+	Description *string  `json:"description"`
+	ProjectID   *string  `json:"projectId"`
+	Tags        []string `json:"tags"`
+	Billable    *bool    `json:"billable"`
 }
 
 type FilterType string
@@ -95,3 +279,189 @@ func (e *FilterType) UnmarshalGQL(v interface{}) error {
 func (e FilterType) MarshalGQL(w io.Writer) {
 	fmt.Fprint(w, strconv.Quote(e.String()))
 }
+
+type OverlapPolicy string
+
+const (
+	OverlapPolicyReject OverlapPolicy = "reject"
+	OverlapPolicyWarn   OverlapPolicy = "warn"
+	OverlapPolicyTrim   OverlapPolicy = "trim"
+)
+
+var AllOverlapPolicy = []OverlapPolicy{
+	OverlapPolicyReject,
+	OverlapPolicyWarn,
+	OverlapPolicyTrim,
+}
+
+func (e OverlapPolicy) IsValid() bool {
+	switch e {
+	case OverlapPolicyReject, OverlapPolicyWarn, OverlapPolicyTrim:
+		return true
+	}
+	return false
+}
+
+func (e OverlapPolicy) String() string {
+	return string(e)
+}
+
+func (e *OverlapPolicy) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OverlapPolicy(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid overlapPolicy", str)
+	}
+	return nil
+}
+
+func (e OverlapPolicy) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type RateScope string
+
+const (
+	RateScopeUser    RateScope = "user"
+	RateScopeClient  RateScope = "client"
+	RateScopeProject RateScope = "project"
+)
+
+var AllRateScope = []RateScope{
+	RateScopeUser,
+	RateScopeClient,
+	RateScopeProject,
+}
+
+func (e RateScope) IsValid() bool {
+	switch e {
+	case RateScopeUser, RateScopeClient, RateScopeProject:
+		return true
+	}
+	return false
+}
+
+func (e RateScope) String() string {
+	return string(e)
+}
+
+func (e *RateScope) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = RateScope(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid rateScope", str)
+	}
+	return nil
+}
+
+func (e RateScope) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ReportGroup string
+
+const (
+	ReportGroupDay     ReportGroup = "day"
+	ReportGroupWeek    ReportGroup = "week"
+	ReportGroupMonth   ReportGroup = "month"
+	ReportGroupProject ReportGroup = "project"
+	ReportGroupTag     ReportGroup = "tag"
+	ReportGroupClient  ReportGroup = "client"
+)
+
+var AllReportGroup = []ReportGroup{
+	ReportGroupDay,
+	ReportGroupWeek,
+	ReportGroupMonth,
+	ReportGroupProject,
+	ReportGroupTag,
+	ReportGroupClient,
+}
+
+func (e ReportGroup) IsValid() bool {
+	switch e {
+	case ReportGroupDay, ReportGroupWeek, ReportGroupMonth, ReportGroupProject, ReportGroupTag, ReportGroupClient:
+		return true
+	}
+	return false
+}
+
+func (e ReportGroup) String() string {
+	return string(e)
+}
+
+func (e *ReportGroup) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ReportGroup(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid reportGroup", str)
+	}
+	return nil
+}
+
+func (e ReportGroup) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type Weekday string
+
+const (
+	WeekdaySunday    Weekday = "sunday"
+	WeekdayMonday    Weekday = "monday"
+	WeekdayTuesday   Weekday = "tuesday"
+	WeekdayWednesday Weekday = "wednesday"
+	WeekdayThursday  Weekday = "thursday"
+	WeekdayFriday    Weekday = "friday"
+	WeekdaySaturday  Weekday = "saturday"
+)
+
+var AllWeekday = []Weekday{
+	WeekdaySunday,
+	WeekdayMonday,
+	WeekdayTuesday,
+	WeekdayWednesday,
+	WeekdayThursday,
+	WeekdayFriday,
+	WeekdaySaturday,
+}
+
+func (e Weekday) IsValid() bool {
+	switch e {
+	case WeekdaySunday, WeekdayMonday, WeekdayTuesday, WeekdayWednesday, WeekdayThursday, WeekdayFriday, WeekdaySaturday:
+		return true
+	}
+	return false
+}
+
+func (e Weekday) String() string {
+	return string(e)
+}
+
+func (e *Weekday) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Weekday(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid weekday", str)
+	}
+	return nil
+}
+
+func (e Weekday) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
@@ -0,0 +1,317 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/db/memory"
+	types "github.com/victor-nach/time-tracker/graph/model"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"github.com/victor-nach/time-tracker/lib/tokenhandler"
+	"github.com/victor-nach/time-tracker/mocks"
+	"github.com/victor-nach/time-tracker/models"
+	"github.com/victor-nach/time-tracker/server/middlewares"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestMutationResolver_UpdateProfile(t *testing.T) {
+	const (
+		success = iota
+		invalidTimeZoneError
+	)
+
+	var tests = []struct {
+		name     string
+		testType int
+		timeZone string
+	}{
+		{
+			name:     "Successfully update profile",
+			testType: success,
+			timeZone: "Africa/Lagos",
+		},
+		{
+			name:     "Test invalid time zone error",
+			testType: invalidTimeZoneError,
+			timeZone: "Mars/Olympus",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			storeMock := new(mocks.Datastore)
+			resolvers := NewResolver(storeMock, nil, zaptest.NewLogger(t))
+			ctx := context.WithValue(context.Background(), middlewares.AuthContextKey,
+				tokenhandler.Claims{UserId: "userId"})
+
+			weekStart := types.WeekdayMonday
+			input := types.ProfileInput{TimeZone: &testCase.timeZone, WeekStart: &weekStart}
+
+			switch testCase.testType {
+			case success:
+				monday := 1
+				storeMock.On("UpdateUser", mock.Anything, "userId", models.UserInfo{TimeZone: &testCase.timeZone, WeekStart: &monday}).
+					Return(nil)
+				mockUser := mockData.User
+				mockUser.TimeZone = testCase.timeZone
+				mockUser.WeekStart = monday
+				storeMock.On("GetUser", mock.Anything, "userId").Return(&mockUser, nil)
+
+				user, err := resolvers.Mutation().UpdateProfile(ctx, input)
+				assert.NoError(t, err)
+				assert.Equal(t, "Africa/Lagos", user.TimeZone)
+				assert.Equal(t, types.WeekdayMonday, user.WeekStart)
+
+			case invalidTimeZoneError:
+				user, err := resolvers.Mutation().UpdateProfile(ctx, input)
+				assert.Nil(t, user)
+				assert.IsType(t, &rerrors.Err{}, err)
+				assert.Equal(t, rerrors.InvalidRequestErr, err.(*rerrors.Err).Code)
+			}
+		})
+	}
+}
+
+func TestMutationResolver_SignUp(t *testing.T) {
+	storeMock := new(mocks.Datastore)
+	resolvers := NewResolver(storeMock, nil, zaptest.NewLogger(t))
+
+	// another sign up takes the email between the check and the insert
+	storeMock.On("GetUserByEmail", mock.Anything, "ada@email.com").Return(nil, db.ErrNotFound)
+	storeMock.On("CreateUser", mock.Anything, mock.Anything).Return(nil, db.ErrEmailExists)
+
+	resp, err := resolvers.Mutation().SignUp(context.Background(), "ada@email.com", "passcode", "Ada")
+	assert.Nil(t, resp)
+	assert.IsType(t, &rerrors.Err{}, err)
+	assert.Equal(t, rerrors.EmailExistsError, err.(*rerrors.Err).Code)
+
+	for _, email := range []string{"ada", "Ada <ada@email.com>", ""} {
+		_, err = resolvers.Mutation().SignUp(context.Background(), email, "passcode", "Ada")
+		assert.Equal(t, rerrors.ValidationErr, err.(*rerrors.Err).Code, email)
+	}
+}
+
+func TestMutationResolver_RefreshToken(t *testing.T) {
+	const (
+		success = iota
+		accessTokenError
+		reusedTokenError
+		reusedWithoutLoginError
+	)
+
+	var tests = []struct {
+		name     string
+		testType int
+	}{
+		{
+			name:     "Successfully rotate refresh token",
+			testType: success,
+		},
+		{
+			name:     "Test access token refused",
+			testType: accessTokenError,
+		},
+		{
+			name:     "Test reused refresh token",
+			testType: reusedTokenError,
+		},
+		{
+			name:     "Test reused refresh token without login session",
+			testType: reusedWithoutLoginError,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			storeMock, tokenHandlerMock := new(mocks.Datastore), new(mocks.TokenHandler)
+			resolvers := NewResolver(storeMock, tokenHandlerMock, zaptest.NewLogger(t))
+			claims := &tokenhandler.Claims{UserId: "userId"}
+			claims.Id = "tokenId"
+			next := mock.MatchedBy(func(token *models.RefreshToken) bool { return token.Owner == "userId" && token.ID != "tokenId" })
+
+			switch testCase.testType {
+			case success:
+				tokenHandlerMock.On("ValidateToken", "refresh", tokenhandler.RefreshToken).Return(claims, nil)
+				storeMock.On("RotateRefreshToken", mock.Anything, "tokenId", next).Return(nil).
+					Run(func(args mock.Arguments) { args.Get(2).(*models.RefreshToken).Family = "family" })
+				storeMock.On("GetTokenGeneration", mock.Anything, "userId").Return(int64(2), nil)
+				storeMock.On("UpdateLoginSession", mock.Anything, "family", mock.Anything).Return(nil)
+				tokenHandlerMock.On("NewToken", "userId", "family", int64(2), mock.Anything).Return("newAccess", nil)
+				tokenHandlerMock.On("NewRefreshToken", "userId", mock.Anything, mock.Anything).Return("newRefresh", nil)
+
+				resp, err := resolvers.Mutation().RefreshToken(context.Background(), "refresh")
+				assert.NoError(t, err)
+				assert.Equal(t, "newAccess", resp.JwtToken)
+				assert.Equal(t, "newRefresh", resp.RefreshToken)
+
+			case accessTokenError:
+				tokenHandlerMock.On("ValidateToken", "access", tokenhandler.RefreshToken).Return(nil, tokenhandler.ErrWrongTokenUse)
+
+				_, err := resolvers.Mutation().RefreshToken(context.Background(), "access")
+				assert.Equal(t, rerrors.InvalidAuthErr, err.(*rerrors.Err).Code)
+
+			case reusedTokenError:
+				tokenHandlerMock.On("ValidateToken", "refresh", tokenhandler.RefreshToken).Return(claims, nil)
+				storeMock.On("RotateRefreshToken", mock.Anything, "tokenId", next).Return(db.ErrTokenReused)
+				storeMock.On("GetRefreshToken", mock.Anything, "tokenId").
+					Return(&models.RefreshToken{ID: "tokenId", Family: "family", Owner: "userId"}, nil)
+				storeMock.On("GetLoginSession", mock.Anything, "family", "userId").
+					Return(&models.LoginSession{ID: "family", Owner: "userId"}, nil)
+				storeMock.On("DeleteLoginSession", mock.Anything, "family").Return(nil)
+
+				_, err := resolvers.Mutation().RefreshToken(context.Background(), "refresh")
+				assert.Equal(t, rerrors.InvalidAuthErr, err.(*rerrors.Err).Code)
+				tokenHandlerMock.AssertNotCalled(t, "NewToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				storeMock.AssertNotCalled(t, "IncrementTokenGeneration", mock.Anything, mock.Anything)
+
+			case reusedWithoutLoginError:
+				tokenHandlerMock.On("ValidateToken", "refresh", tokenhandler.RefreshToken).Return(claims, nil)
+				storeMock.On("RotateRefreshToken", mock.Anything, "tokenId", next).Return(db.ErrTokenReused)
+				storeMock.On("GetRefreshToken", mock.Anything, "tokenId").
+					Return(&models.RefreshToken{ID: "tokenId", Family: "family", Owner: "userId"}, nil)
+				storeMock.On("GetLoginSession", mock.Anything, "family", "userId").Return(nil, db.ErrNotFound)
+				storeMock.On("RevokeRefreshFamily", mock.Anything, "family").Return(nil)
+
+				_, err := resolvers.Mutation().RefreshToken(context.Background(), "refresh")
+				assert.Equal(t, rerrors.InvalidAuthErr, err.(*rerrors.Err).Code)
+				storeMock.AssertNotCalled(t, "IncrementTokenGeneration", mock.Anything, mock.Anything)
+			}
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMutationResolver_RefreshTokenReuseRevokesAccessTokens(t *testing.T) {
+	store, tokenHandler := memory.New(), tokenhandler.New("secret")
+	logger := zaptest.NewLogger(t)
+	resolvers := NewResolver(store, tokenHandler, logger)
+	ctx := context.Background()
+
+	signUp, err := resolvers.Mutation().SignUp(ctx, "user@mail.com", "passcode", "user")
+	assert.NoError(t, err)
+	refreshed, err := resolvers.Mutation().RefreshToken(ctx, signUp.RefreshToken)
+	assert.NoError(t, err)
+
+	auth := middlewares.NewAuthMiddleware(tokenHandler, store, logger)
+	authenticated := func(token string) bool {
+		ok := false
+		handler := auth.HandleAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, ok = r.Context().Value(middlewares.AuthContextKey).(tokenhandler.Claims)
+		}))
+		req := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		return ok
+	}
+	assert.True(t, authenticated(refreshed.JwtToken))
+
+	other, err := resolvers.Mutation().Login(ctx, "user@mail.com", "passcode")
+	assert.NoError(t, err)
+
+	_, err = resolvers.Mutation().RefreshToken(ctx, signUp.RefreshToken)
+	assert.Equal(t, rerrors.InvalidAuthErr, err.(*rerrors.Err).Code)
+	assert.False(t, authenticated(signUp.JwtToken))
+	assert.False(t, authenticated(refreshed.JwtToken))
+	// only the family of the reused token is revoked, the other device stays signed in
+	assert.True(t, authenticated(other.JwtToken))
+	_, err = resolvers.Mutation().RefreshToken(ctx, other.RefreshToken)
+	assert.NoError(t, err)
+}
+
+func TestMutationResolver_Logout(t *testing.T) {
+	const (
+		success = iota
+		foreignTokenError
+	)
+
+	var tests = []struct {
+		name     string
+		testType int
+	}{
+		{
+			name:     "Successfully log out",
+			testType: success,
+		},
+		{
+			name:     "Test refresh token of another user",
+			testType: foreignTokenError,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			storeMock, tokenHandlerMock := new(mocks.Datastore), new(mocks.TokenHandler)
+			resolvers := NewResolver(storeMock, tokenHandlerMock, zaptest.NewLogger(t))
+			claims := &tokenhandler.Claims{UserId: "userId"}
+			claims.Id = "tokenId"
+			tokenHandlerMock.On("ValidateToken", "refresh", tokenhandler.RefreshToken).Return(claims, nil)
+
+			switch testCase.testType {
+			case success:
+				storeMock.On("GetRefreshToken", mock.Anything, "tokenId").
+					Return(&models.RefreshToken{ID: "tokenId", Family: "family", Owner: "userId"}, nil)
+				storeMock.On("RevokeRefreshFamily", mock.Anything, "family").Return(nil)
+				storeMock.On("DeleteLoginSession", mock.Anything, "family").Return(nil)
+
+				resp, err := resolvers.Mutation().Logout(context.Background(), "refresh")
+				assert.NoError(t, err)
+				assert.True(t, resp.Success)
+
+			case foreignTokenError:
+				storeMock.On("GetRefreshToken", mock.Anything, "tokenId").
+					Return(&models.RefreshToken{ID: "tokenId", Family: "family", Owner: "otherId"}, nil)
+
+				_, err := resolvers.Mutation().Logout(context.Background(), "refresh")
+				assert.Equal(t, rerrors.InvalidAuthErr, err.(*rerrors.Err).Code)
+				storeMock.AssertNotCalled(t, "RevokeRefreshFamily", mock.Anything, mock.Anything)
+			}
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMutationResolver_LogoutAllDevices(t *testing.T) {
+	storeMock := new(mocks.Datastore)
+	resolvers := NewResolver(storeMock, nil, zaptest.NewLogger(t))
+	ctx := context.WithValue(context.Background(), middlewares.AuthContextKey,
+		tokenhandler.Claims{UserId: "userId"})
+
+	storeMock.On("RevokeUserRefreshTokens", mock.Anything, "userId").Return(nil)
+	storeMock.On("DeleteLoginSessions", mock.Anything, "userId").Return(nil)
+	storeMock.On("IncrementTokenGeneration", mock.Anything, "userId").Return(int64(1), nil)
+
+	resp, err := resolvers.Mutation().LogoutAllDevices(ctx)
+	assert.NoError(t, err)
+	assert.True(t, resp.Success)
+	storeMock.AssertExpectations(t)
+
+	_, err = resolvers.Mutation().LogoutAllDevices(context.Background())
+	assert.Equal(t, rerrors.InvalidAuthErr, err.(*rerrors.Err).Code)
+}
+
+func TestMutationResolver_StartTimer(t *testing.T) {
+	storeMock := new(mocks.Datastore)
+	clockMock := new(mocks.Clock)
+	resolvers := NewResolver(storeMock, nil, zaptest.NewLogger(t))
+	resolvers.clock = clockMock
+	ctx := context.WithValue(context.Background(), middlewares.AuthContextKey,
+		tokenhandler.Claims{UserId: "userId"})
+
+	now := time.Date(2021, 6, 16, 12, 0, 0, 0, time.UTC)
+	clockMock.On("Now").Return(now)
+	storeMock.On("StartTimer", mock.Anything, mock.MatchedBy(func(s *models.Session) bool {
+		return s.Owner == "userId" && s.Start == now.Unix() && s.Ts == now.Unix() &&
+			len(s.Segments) == 1 && s.Segments[0].Start == now.Unix()
+	})).Return(nil, nil)
+
+	session, err := resolvers.Mutation().StartTimer(ctx, nil, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, session)
+	storeMock.AssertExpectations(t)
+}
@@ -5,8 +5,11 @@ package graph
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/victor-nach/time-tracker/db"
 	"github.com/victor-nach/time-tracker/graph/generated"
 	types "github.com/victor-nach/time-tracker/graph/model"
 	"github.com/victor-nach/time-tracker/lib/rerrors"
@@ -16,7 +19,12 @@ import (
 )
 
 func (r *mutationResolver) SignUp(ctx context.Context, email string, passcode string, name string) (*types.AuthResponse, error) {
-	if _, err := r.store.GetUserByEmail(email); err == nil {
+	if err := validateEmail("email", email); err != nil {
+		r.logger.Error("sign up", zap.Error(err))
+		return nil, err
+	}
+
+	if _, err := r.store.GetUserByEmail(ctx, email); err == nil {
 		err := rerrors.Format(rerrors.EmailExistsError, err)
 		r.logger.Error("sign up", zap.Error(err))
 		return nil, err
@@ -35,16 +43,26 @@ func (r *mutationResolver) SignUp(ctx context.Context, email string, passcode st
 		Name:     name,
 		Email:    email,
 		Password: hashPasscode,
-		Ts:       time.Now().Unix(),
+		Ts:       r.clock.Now().Unix(),
 	}
 
-	if _, err := r.store.CreateUser(&user); err != nil {
+	// the store rejects the email when another sign up took it after the check above
+	if _, err := r.store.CreateUser(ctx, &user); err == db.ErrEmailExists {
+		err = rerrors.Format(rerrors.EmailExistsError, err)
+		r.logger.Error("sign up", zap.Error(err))
+		return nil, err
+	} else if err != nil {
 		err = rerrors.Format(rerrors.DatabaseErr, err)
 		r.logger.Error("save session", zap.Error(err))
 		return nil, err
 	}
 
-	authToken, refreshToken, err := r.genAuthTokens(userId)
+	// the account works without a verified email, the user can ask for a new link when this one is lost
+	if err := r.sendVerification(ctx, &user); err != nil {
+		r.logger.Error("sign up", zap.Error(rerrors.Format(rerrors.InternalErr, err)))
+	}
+
+	authToken, refreshToken, err := r.genAuthTokens(ctx, &user)
 	if err != nil {
 		return nil, err
 	}
@@ -61,7 +79,7 @@ func (r *mutationResolver) SignUp(ctx context.Context, email string, passcode st
 }
 
 func (r *mutationResolver) Login(ctx context.Context, email string, passcode string) (*types.AuthResponse, error) {
-	user, err := r.store.GetUserByEmail(email)
+	user, err := r.store.GetUserByEmail(ctx, email)
 	if err != nil {
 		err := rerrors.Format(rerrors.InvalidAuthErr, err)
 		r.logger.Error("sign up", zap.Error(err))
@@ -74,7 +92,10 @@ func (r *mutationResolver) Login(ctx context.Context, email string, passcode str
 		return nil, err
 	}
 
-	authToken, refreshToken, err := r.genAuthTokens(user.ID)
+	authToken, refreshToken, err := r.genAuthTokens(ctx, user)
+	if err != nil {
+		return nil, err
+	}
 
 	resp := &types.AuthResponse{
 		Success:      true,
@@ -87,23 +108,63 @@ func (r *mutationResolver) Login(ctx context.Context, email string, passcode str
 	return resp, nil
 }
 
-func (r *mutationResolver) RefreshToken(ctx context.Context) (*types.AuthResponse, error) {
-	claims, err := r.getClaimsFromCtx(ctx)
+func (r *mutationResolver) RefreshToken(ctx context.Context, token string) (*types.AuthResponse, error) {
+	claims, err := r.tokenHandler.ValidateToken(token, tokenhandler.RefreshToken)
 	if err != nil {
 		err = rerrors.Format(rerrors.InvalidAuthErr, err)
-		r.logger.Error("save session", zap.Error(err))
+		r.logger.Error("refresh token", zap.Error(err))
+		return nil, err
+	}
+
+	next := r.newRefreshToken(claims.UserId)
+	if err := r.store.RotateRefreshToken(ctx, claims.Id, next); err == db.ErrTokenReused {
+		// a refresh token used twice was copied, the store revoked every refresh token of its login.
+		// Deleting the login session signs the device out and revokes the access tokens of the family,
+		// the other devices of the user stay signed in
+		r.logger.Warn("refresh token reused", zap.String("userId", claims.UserId), zap.String("tokenId", claims.Id))
+		if token, err := r.store.GetRefreshToken(ctx, claims.Id); err != nil {
+			r.logger.Error("refresh token", zap.Error(err))
+		} else if _, err := r.store.GetLoginSession(ctx, token.Family, claims.UserId); err != nil {
+			// without a login session only the refresh tokens of the family can be revoked
+			if err := r.store.RevokeRefreshFamily(ctx, token.Family); err != nil {
+				r.logger.Error("refresh token", zap.Error(err))
+			}
+		} else if err := r.store.DeleteLoginSession(ctx, token.Family); err != nil {
+			r.logger.Error("refresh token", zap.Error(err))
+		}
+		return nil, rerrors.Format(rerrors.InvalidAuthErr, err)
+	} else if err == db.ErrTokenRevoked || err == db.ErrNotFound {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("refresh token", zap.Error(err))
+		return nil, err
+	} else if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("refresh token", zap.Error(err))
 		return nil, err
 	}
-	tokenExpiry := time.Now().Add(tokenhandler.AuthTokenDuration)
-	authToken, err := r.tokenHandler.NewToken(claims.UserId, tokenExpiry)
 
-	refreshExpiry := time.Now().Add(tokenhandler.RefreshTokenDuration)
-	refreshToken, err := r.tokenHandler.NewToken(claims.UserId, refreshExpiry)
+	generation, err := r.store.GetTokenGeneration(ctx, claims.UserId)
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("refresh token", zap.Error(err))
+		return nil, err
+	}
+
+	// the device keeps its login session, a failure to record its use does not sign it out
+	now, client := r.clock.Now().Unix(), clientFromCtx(ctx)
+	info := models.LoginSessionInfo{UserAgent: &client.UserAgent, IP: &client.IP, LastUsedAt: &now}
+	if err := r.store.UpdateLoginSession(ctx, next.Family, info); err != nil {
+		r.logger.Error("refresh token", zap.Error(err))
+	}
+
+	authToken, refreshToken, err := r.signTokens(claims.UserId, generation, next)
 	if err != nil {
 		return nil, err
 	}
 
 	resp := &types.AuthResponse{
+		Success:      true,
+		Message:      "Token refreshed",
 		JwtToken:     authToken,
 		RefreshToken: refreshToken,
 	}
@@ -111,6 +172,100 @@ func (r *mutationResolver) RefreshToken(ctx context.Context) (*types.AuthRespons
 	return resp, nil
 }
 
+func (r *mutationResolver) Logout(ctx context.Context, refreshToken string) (*types.Response, error) {
+	claims, err := r.tokenHandler.ValidateToken(refreshToken, tokenhandler.RefreshToken)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("logout", zap.Error(err))
+		return nil, err
+	}
+
+	token, err := r.store.GetRefreshToken(ctx, claims.Id)
+	if err == nil && token.Owner != claims.UserId {
+		err = db.ErrNotFound
+	}
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("logout", zap.Error(err))
+		return nil, err
+	}
+
+	if err := r.signOut(ctx, token.Family); err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("logout", zap.Error(err))
+		return nil, err
+	}
+
+	return &types.Response{
+		Success: true,
+		Message: "Successfully logged out",
+	}, nil
+}
+
+func (r *mutationResolver) LogoutAllDevices(ctx context.Context) (*types.Response, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("logout all devices", zap.Error(err))
+		return nil, err
+	}
+
+	if err := r.signOutAll(ctx, claims.UserId); err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("logout all devices", zap.Error(err))
+		return nil, err
+	}
+
+	return &types.Response{
+		Success: true,
+		Message: "Successfully logged out of every device",
+	}, nil
+}
+
+func (r *mutationResolver) UpdateProfile(ctx context.Context, input types.ProfileInput) (*types.User, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("update profile", zap.Error(err))
+		return nil, err
+	}
+
+	userInfo := models.UserInfo{
+		Name:     input.Name,
+		TimeZone: input.TimeZone,
+	}
+	if input.TimeZone != nil {
+		if _, err := time.LoadLocation(*input.TimeZone); err != nil {
+			err = rerrors.Format(rerrors.InvalidRequestErr, err)
+			r.logger.Error("update profile", zap.Error(err))
+			return nil, err
+		}
+	}
+	if input.WeekStart != nil {
+		weekStart := weekdayIndex(*input.WeekStart)
+		userInfo.WeekStart = &weekStart
+	}
+	if input.OverlapPolicy != nil {
+		policy := input.OverlapPolicy.String()
+		userInfo.OverlapPolicy = &policy
+	}
+
+	if err := r.store.UpdateUser(ctx, claims.UserId, userInfo); err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("update profile", zap.Error(err))
+		return nil, err
+	}
+
+	user, err := r.store.GetUser(ctx, claims.UserId)
+	if err != nil {
+		err = rerrors.Format(rerrors.CustomerNotFoundErr, err)
+		r.logger.Error("update profile", zap.Error(err))
+		return nil, err
+	}
+
+	return mapUser(user), nil
+}
+
 func (r *mutationResolver) SaveSession(ctx context.Context, input *types.SessionInput) (*types.Response, error) {
 	claims, err := r.getClaimsFromCtx(ctx)
 	if err != nil {
@@ -119,14 +274,18 @@ func (r *mutationResolver) SaveSession(ctx context.Context, input *types.Session
 		return nil, err
 	}
 
+	if err := validateSession(input, r.clock.Now()); err != nil {
+		r.logger.Error("save session", zap.Error(err))
+		return nil, err
+	}
+
 	sessionId := r.idGen.Generate()
 	session := models.Session{
-		ID:       sessionId,
-		Owner:    claims.UserId,
-		Start:    int64(input.Start),
-		End:      int64(input.End),
-		Duration: int64(input.Duration),
-		Ts:       time.Now().Unix(),
+		ID:    sessionId,
+		Owner: claims.UserId,
+		Start: int64(input.Start),
+		End:   int64(input.End),
+		Ts:    r.clock.Now().Unix(),
 	}
 	if input.Title != nil {
 		session.Title = *input.Title
@@ -134,8 +293,41 @@ func (r *mutationResolver) SaveSession(ctx context.Context, input *types.Session
 	if input.Description != nil {
 		session.Description = *input.Description
 	}
+	if input.ProjectID != nil && *input.ProjectID != "" {
+		if _, err := r.store.GetProject(ctx, *input.ProjectID, claims.UserId); err != nil {
+			err = rerrors.Format(rerrors.ProjectNotFoundErr, err)
+			r.logger.Error("save session", zap.Error(err))
+			return nil, err
+		}
+		session.ProjectID = *input.ProjectID
+	}
+	if input.Billable != nil {
+		session.Billable = *input.Billable
+	}
+	session.Tags = normalizeTags(input.Tags)
+	for _, segment := range input.Segments {
+		session.Segments = append(session.Segments, models.Segment{
+			Start: int64(segment.Start),
+			End:   int64(segment.End),
+		})
+	}
+	session.Segments = session.Intervals()
+	// the duration is derived from the segments and never trusted from the input
+	session.Duration = session.TotalDuration()
 
-	if _, err := r.store.CreateSession(&session); err != nil {
+	user, err := r.store.GetUser(ctx, claims.UserId)
+	if err != nil {
+		err = rerrors.Format(rerrors.CustomerNotFoundErr, err)
+		r.logger.Error("save session", zap.Error(err))
+		return nil, err
+	}
+
+	conflicts, err := r.store.CreateSessionWithPolicy(ctx, &session, user.OverlapPolicy)
+	if err == db.ErrSessionOverlap {
+		err = rerrors.Format(rerrors.SessionOverlapErr, fmt.Errorf("overlaps %s", strings.Join(overlapIDs(conflicts), ", ")))
+		r.logger.Error("save session", zap.Error(err))
+		return nil, err
+	} else if err != nil {
 		err = rerrors.Format(rerrors.DatabaseErr, err)
 		r.logger.Error("save session", zap.Error(err))
 		return nil, err
@@ -146,6 +338,13 @@ func (r *mutationResolver) SaveSession(ctx context.Context, input *types.Session
 		Message: "Successfully created session!",
 		Token:   &sessionId,
 	}
+	if len(conflicts) > 0 {
+		resp.Overlaps = overlapIDs(conflicts)
+		resp.Message = "Session created, it overlaps sessions you already saved"
+		if user.OverlapPolicy == models.OverlapTrim {
+			resp.Message = "Session created without the time it overlapped with sessions you already saved"
+		}
+	}
 
 	return resp, nil
 }
@@ -158,17 +357,35 @@ func (r *mutationResolver) UpdateSessionInfo(ctx context.Context, id string, inp
 		return nil, err
 	}
 
-	if _, err := r.store.GetSession(id, claims.UserId); err != nil {
+	if err := validateSessionUpdate(input); err != nil {
+		r.logger.Error("update session", zap.Error(err))
+		return nil, err
+	}
+
+	if _, err := r.store.GetSession(ctx, id, claims.UserId); err != nil {
 		err = rerrors.Format(rerrors.SessionNotFoundErr, err)
 		r.logger.Error("delete session", zap.Error(err))
 		return nil, err
 	}
 
+	if input.ProjectID != nil && *input.ProjectID != "" {
+		if _, err := r.store.GetProject(ctx, *input.ProjectID, claims.UserId); err != nil {
+			err = rerrors.Format(rerrors.ProjectNotFoundErr, err)
+			r.logger.Error("update session", zap.Error(err))
+			return nil, err
+		}
+	}
+
 	sessionInfo := models.SessionInfo{
 		Title:       input.Title,
 		Description: input.Description,
+		ProjectID:   input.ProjectID,
+		Billable:    input.Billable,
+	}
+	if input.Tags != nil {
+		sessionInfo.Tags = normalizeTags(input.Tags)
 	}
-	if err := r.store.UpdateSession(id, sessionInfo); err != nil {
+	if err := r.store.UpdateSession(ctx, id, sessionInfo); err != nil {
 		err = rerrors.Format(rerrors.DatabaseErr, err)
 		r.logger.Error("delete session", zap.Error(err))
 		return nil, err
@@ -188,13 +405,13 @@ func (r *mutationResolver) DeleteSession(ctx context.Context, id string) (*types
 		return nil, err
 	}
 
-	if _, err := r.store.GetSession(id, claims.UserId); err != nil {
+	if _, err := r.store.GetSession(ctx, id, claims.UserId); err != nil {
 		err = rerrors.Format(rerrors.SessionNotFoundErr, err)
 		r.logger.Error("delete session", zap.Error(err))
 		return nil, err
 	}
 
-	if err := r.store.DeleteSession(id); err != nil {
+	if err := r.store.DeleteSession(ctx, id); err != nil {
 		err = rerrors.Format(rerrors.DatabaseErr, err)
 		r.logger.Error("delete session", zap.Error(err))
 		return nil, err
@@ -206,6 +423,102 @@ func (r *mutationResolver) DeleteSession(ctx context.Context, id string) (*types
 	}, nil
 }
 
+func (r *mutationResolver) StartTimer(ctx context.Context, title *string, description *string) (*types.Session, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("start timer", zap.Error(err))
+		return nil, err
+	}
+
+	now := r.clock.Now().Unix()
+	session := models.Session{
+		ID:       r.idGen.Generate(),
+		Owner:    claims.UserId,
+		Start:    now,
+		Segments: []models.Segment{{Start: now}},
+		Running:  true,
+		Ts:       now,
+	}
+	if title != nil {
+		session.Title = *title
+	}
+	if description != nil {
+		session.Description = *description
+	}
+
+	if _, err := r.store.StartTimer(ctx, &session); err != nil {
+		err = formatTimerErr(err)
+		r.logger.Error("start timer", zap.Error(err))
+		return nil, err
+	}
+
+	return mapSession(&session, nil), nil
+}
+
+func (r *mutationResolver) PauseTimer(ctx context.Context) (*types.Session, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("pause timer", zap.Error(err))
+		return nil, err
+	}
+
+	session, err := r.store.PauseTimer(ctx, claims.UserId, r.clock.Now().Unix())
+	if err != nil {
+		err = formatTimerErr(err)
+		r.logger.Error("pause timer", zap.Error(err))
+		return nil, err
+	}
+
+	return mapSession(session, nil), nil
+}
+
+func (r *mutationResolver) ResumeTimer(ctx context.Context) (*types.Session, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("resume timer", zap.Error(err))
+		return nil, err
+	}
+
+	session, err := r.store.ResumeTimer(ctx, claims.UserId, r.clock.Now().Unix())
+	if err != nil {
+		err = formatTimerErr(err)
+		r.logger.Error("resume timer", zap.Error(err))
+		return nil, err
+	}
+
+	return mapSession(session, nil), nil
+}
+
+func (r *mutationResolver) StopTimer(ctx context.Context) (*types.Session, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("stop timer", zap.Error(err))
+		return nil, err
+	}
+
+	// the overlap policy does not apply, a timer tracked its time as it went and stopping it can not be
+	// refused. Sessions it overlaps are listed by the overlaps query
+	session, err := r.store.StopTimer(ctx, claims.UserId, r.clock.Now().Unix())
+	if err != nil {
+		err = formatTimerErr(err)
+		r.logger.Error("stop timer", zap.Error(err))
+		return nil, err
+	}
+
+	sessionsResp, err := r.mapSessions(ctx, claims.UserId, []*models.Session{session})
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("stop timer", zap.Error(err))
+		return nil, err
+	}
+
+	return sessionsResp[0], nil
+}
+
 // Mutation returns generated.MutationResolver implementation.
 func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResolver{r} }
 
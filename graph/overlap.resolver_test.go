@@ -0,0 +1,168 @@
+package graph
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/db/memory"
+	types "github.com/victor-nach/time-tracker/graph/model"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"github.com/victor-nach/time-tracker/lib/tokenhandler"
+	"github.com/victor-nach/time-tracker/mocks"
+	"github.com/victor-nach/time-tracker/models"
+	"github.com/victor-nach/time-tracker/server/middlewares"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestMutationResolver_SaveSessionOverlaps(t *testing.T) {
+	const (
+		noOverlap = iota
+		warnOverlap
+		rejectOverlap
+	)
+
+	var tests = []struct {
+		name     string
+		testType int
+		policy   string
+	}{
+		{
+			name:     "Successfully save session without overlaps",
+			testType: noOverlap,
+			policy:   models.OverlapReject,
+		},
+		{
+			name:     "Successfully save session with a warning",
+			testType: warnOverlap,
+			policy:   "",
+		},
+		{
+			name:     "Test session overlap error",
+			testType: rejectOverlap,
+			policy:   models.OverlapReject,
+		},
+	}
+
+	saved := &models.Session{ID: "savedId", Owner: "userId", Start: 100, End: 200}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			storeMock := new(mocks.Datastore)
+			resolvers := NewResolver(storeMock, nil, zaptest.NewLogger(t))
+			ctx := context.WithValue(context.Background(), middlewares.AuthContextKey,
+				tokenhandler.Claims{UserId: "userId"})
+
+			storeMock.On("GetUser", mock.Anything, "userId").
+				Return(&models.User{ID: "userId", OverlapPolicy: testCase.policy}, nil)
+			input := &types.SessionInput{Start: 150, End: 250}
+			policySession := mock.MatchedBy(func(s *models.Session) bool { return s.Start == 150 && s.End == 250 })
+
+			switch testCase.testType {
+			case noOverlap:
+				storeMock.On("CreateSessionWithPolicy", mock.Anything, policySession, testCase.policy).
+					Return([]*models.Session{}, nil)
+
+				resp, err := resolvers.Mutation().SaveSession(ctx, input)
+				assert.NoError(t, err)
+				assert.True(t, resp.Success)
+				assert.Empty(t, resp.Overlaps)
+
+			case warnOverlap:
+				storeMock.On("CreateSessionWithPolicy", mock.Anything, policySession, testCase.policy).
+					Return([]*models.Session{saved}, nil)
+
+				resp, err := resolvers.Mutation().SaveSession(ctx, input)
+				assert.NoError(t, err)
+				assert.True(t, resp.Success)
+				assert.Equal(t, []string{"savedId"}, resp.Overlaps)
+
+			case rejectOverlap:
+				storeMock.On("CreateSessionWithPolicy", mock.Anything, policySession, testCase.policy).
+					Return([]*models.Session{saved}, db.ErrSessionOverlap)
+
+				resp, err := resolvers.Mutation().SaveSession(ctx, input)
+				assert.Nil(t, resp)
+				assert.IsType(t, &rerrors.Err{}, err)
+				assert.Equal(t, rerrors.SessionOverlapErr, err.(*rerrors.Err).Code)
+				assert.Contains(t, err.(*rerrors.Err).Detail, "savedId")
+			}
+		})
+	}
+}
+
+func TestMutationResolver_ConfirmDraftsOverlaps(t *testing.T) {
+	storeMock := new(mocks.Datastore)
+	resolvers := NewResolver(storeMock, nil, zaptest.NewLogger(t))
+	ctx := context.WithValue(context.Background(), middlewares.AuthContextKey,
+		tokenhandler.Claims{UserId: "userId"})
+
+	saved := &models.Session{ID: "savedId", Owner: "userId", Start: 100, End: 200}
+	storeMock.On("GetUser", mock.Anything, "userId").
+		Return(&models.User{ID: "userId", OverlapPolicy: models.OverlapReject}, nil)
+	storeMock.On("ConfirmDrafts", mock.Anything, "userId", []string{"first", "second", "third"}, models.OverlapReject).
+		Return(int64(1), []*models.Session{saved, saved}, nil)
+
+	resp, err := resolvers.Mutation().ConfirmDrafts(ctx, []string{"first", "second", "third"})
+	assert.NoError(t, err)
+	assert.True(t, resp.Success)
+	assert.Equal(t, []string{"savedId"}, resp.Overlaps)
+	assert.Contains(t, resp.Message, "kept as drafts")
+	storeMock.AssertExpectations(t)
+}
+
+func TestMutationResolver_StopTimerIgnoresOverlapPolicy(t *testing.T) {
+	store := memory.New()
+	resolvers := NewResolver(store, nil, zaptest.NewLogger(t))
+	ctx := context.WithValue(context.Background(), middlewares.AuthContextKey,
+		tokenhandler.Claims{UserId: "userId"})
+	now := time.Now().Unix()
+
+	_, err := store.CreateUser(ctx, &models.User{ID: "userId", Email: "user@email.com", OverlapPolicy: models.OverlapReject})
+	assert.NoError(t, err)
+	_, err = store.CreateSession(ctx, &models.Session{ID: "savedId", Owner: "userId", Start: now - 7200, End: now - 3600})
+	assert.NoError(t, err)
+	_, err = store.StartTimer(ctx, &models.Session{
+		ID: "timerId", Owner: "userId", Start: now - 5400, Segments: []models.Segment{{Start: now - 5400}}, Running: true,
+	})
+	assert.NoError(t, err)
+
+	// stopping a timer can not be refused, the overlap it made is listed instead
+	session, err := resolvers.Mutation().StopTimer(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "timerId", session.ID)
+	overlaps, err := resolvers.Query().Overlaps(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, overlaps, 1) {
+		assert.Equal(t, "savedId", overlaps[0].First.ID)
+		assert.Equal(t, "timerId", overlaps[0].Second.ID)
+	}
+}
+
+func TestQueryResolver_Overlaps(t *testing.T) {
+	storeMock := new(mocks.Datastore)
+	resolvers := NewResolver(storeMock, nil, zaptest.NewLogger(t))
+	ctx := context.WithValue(context.Background(), middlewares.AuthContextKey,
+		tokenhandler.Claims{UserId: "userId"})
+
+	long := &models.Session{ID: "long", Owner: "userId", Start: 0, End: 1000}
+	first := &models.Session{ID: "first", Owner: "userId", Start: 100, End: 200}
+	second := &models.Session{ID: "second", Owner: "userId", Start: 300, End: 400}
+	storeMock.On("GetOverlaps", mock.Anything, "userId").Return([]*models.Overlap{
+		{First: long, Second: first, Start: 100, End: 200, Duration: 100},
+		{First: long, Second: second, Start: 300, End: 400, Duration: 100},
+	}, nil)
+
+	overlaps, err := resolvers.Query().Overlaps(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, overlaps, 2) {
+		assert.Equal(t, "long", overlaps[0].First.ID)
+		assert.Equal(t, "first", overlaps[0].Second.ID)
+		assert.Equal(t, "second", overlaps[1].Second.ID)
+		assert.Same(t, overlaps[0].First, overlaps[1].First)
+		assert.Equal(t, 100, overlaps[1].Duration)
+	}
+}
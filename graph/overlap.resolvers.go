@@ -0,0 +1,60 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+
+	types "github.com/victor-nach/time-tracker/graph/model"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"github.com/victor-nach/time-tracker/models"
+	"go.uber.org/zap"
+)
+
+func (r *queryResolver) Overlaps(ctx context.Context) ([]*types.Overlap, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("overlaps", zap.Error(err))
+		return nil, err
+	}
+
+	overlaps, err := r.store.GetOverlaps(ctx, claims.UserId)
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("overlaps", zap.Error(err))
+		return nil, err
+	}
+
+	// a session can be part of several overlaps, it is mapped once
+	var sessions []*models.Session
+	index := map[string]int{}
+	for _, o := range overlaps {
+		for _, s := range []*models.Session{o.First, o.Second} {
+			if _, ok := index[s.ID]; !ok {
+				index[s.ID] = len(sessions)
+				sessions = append(sessions, s)
+			}
+		}
+	}
+	sessionsResp, err := r.mapSessions(ctx, claims.UserId, sessions)
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("overlaps", zap.Error(err))
+		return nil, err
+	}
+
+	overlapsResp := make([]*types.Overlap, len(overlaps))
+	for i, o := range overlaps {
+		overlapsResp[i] = &types.Overlap{
+			First:    sessionsResp[index[o.First.ID]],
+			Second:   sessionsResp[index[o.Second.ID]],
+			Start:    int(o.Start),
+			End:      int(o.End),
+			Duration: int(o.Duration),
+		}
+	}
+
+	return overlapsResp, nil
+}
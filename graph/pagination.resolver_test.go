@@ -0,0 +1,86 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/victor-nach/time-tracker/lib/cursor"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"github.com/victor-nach/time-tracker/lib/tokenhandler"
+	"github.com/victor-nach/time-tracker/mocks"
+	"github.com/victor-nach/time-tracker/models"
+	"github.com/victor-nach/time-tracker/server/middlewares"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestQueryResolver_SessionsConnection(t *testing.T) {
+	const (
+		success = iota
+		invalidCursorError
+		invalidPageSizeError
+	)
+
+	var tests = []struct {
+		name     string
+		testType int
+	}{
+		{
+			name:     "Successfully get a page of sessions",
+			testType: success,
+		},
+		{
+			name:     "Test invalid cursor error",
+			testType: invalidCursorError,
+		},
+		{
+			name:     "Test invalid page size error",
+			testType: invalidPageSizeError,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			storeMock := new(mocks.Datastore)
+			resolvers := NewResolver(storeMock, nil, zaptest.NewLogger(t))
+			ctx := context.WithValue(context.Background(), middlewares.AuthContextKey,
+				tokenhandler.Claims{UserId: "userId"})
+
+			switch testCase.testType {
+			case success:
+				after := models.Cursor{Ts: 200, ID: "afterId"}
+				first, afterCursor := 2, cursor.Encode(after)
+				sessions := []*models.Session{{ID: "first", Ts: 150}, {ID: "second", Ts: 100}}
+				storeMock.On("GetSessionsPage", mock.Anything, "userId", models.SessionFilter{}, models.Page{First: 2, After: &after}).
+					Return(&models.SessionPage{Sessions: sessions, TotalCount: 5, HasNextPage: true, HasPreviousPage: true}, nil)
+
+				conn, err := resolvers.Query().SessionsConnection(ctx, &first, &afterCursor, nil, nil, nil)
+				assert.NoError(t, err)
+				assert.Equal(t, 5, conn.TotalCount)
+				assert.Len(t, conn.Edges, 2)
+				assert.Equal(t, "first", conn.Edges[0].Node.ID)
+				assert.True(t, conn.PageInfo.HasNextPage)
+				assert.True(t, conn.PageInfo.HasPreviousPage)
+
+				end, err := cursor.Decode(*conn.PageInfo.EndCursor)
+				assert.NoError(t, err)
+				assert.Equal(t, models.Cursor{Ts: 100, ID: "second"}, *end)
+
+			case invalidCursorError:
+				after := "invalid"
+				conn, err := resolvers.Query().SessionsConnection(ctx, nil, &after, nil, nil, nil)
+				assert.Nil(t, conn)
+				assert.IsType(t, &rerrors.Err{}, err)
+				assert.Equal(t, rerrors.InvalidRequestErr, err.(*rerrors.Err).Code)
+
+			case invalidPageSizeError:
+				first := maxPageSize + 1
+				conn, err := resolvers.Query().SessionsConnection(ctx, &first, nil, nil, nil, nil)
+				assert.Nil(t, conn)
+				assert.IsType(t, &rerrors.Err{}, err)
+				assert.Equal(t, rerrors.InvalidRequestErr, err.(*rerrors.Err).Code)
+			}
+		})
+	}
+}
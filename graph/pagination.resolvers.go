@@ -0,0 +1,73 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+
+	types "github.com/victor-nach/time-tracker/graph/model"
+	"github.com/victor-nach/time-tracker/lib/cursor"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"github.com/victor-nach/time-tracker/models"
+	"go.uber.org/zap"
+)
+
+func (r *queryResolver) SessionsConnection(ctx context.Context, first *int, after *string, last *int, before *string, filter *types.SessionFilter) (*types.SessionConnection, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("sessions connection", zap.Error(err))
+		return nil, err
+	}
+
+	page, err := mapPage(first, after, last, before)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidRequestErr, err)
+		r.logger.Error("sessions connection", zap.Error(err))
+		return nil, err
+	}
+
+	fil, err := r.sessionFilter(ctx, claims.UserId, filter)
+	if err != nil {
+		r.logger.Error("sessions connection", zap.Error(err))
+		return nil, err
+	}
+
+	sessionPage, err := r.store.GetSessionsPage(ctx, claims.UserId, fil, page)
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("sessions connection", zap.Error(err))
+		return nil, err
+	}
+
+	sessions, err := r.mapSessions(ctx, claims.UserId, sessionPage.Sessions)
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("sessions connection", zap.Error(err))
+		return nil, err
+	}
+
+	edges := make([]*types.SessionEdge, len(sessions))
+	for i, s := range sessionPage.Sessions {
+		edges[i] = &types.SessionEdge{
+			Cursor: cursor.Encode(models.Cursor{Ts: s.Ts, ID: s.ID}),
+			Node:   sessions[i],
+		}
+	}
+
+	pageInfo := &types.PageInfo{
+		HasNextPage:     sessionPage.HasNextPage,
+		HasPreviousPage: sessionPage.HasPreviousPage,
+	}
+	if len(edges) > 0 {
+		pageInfo.StartCursor = &edges[0].Cursor
+		pageInfo.EndCursor = &edges[len(edges)-1].Cursor
+	}
+
+	return &types.SessionConnection{
+		Edges:      edges,
+		PageInfo:   pageInfo,
+		TotalCount: int(sessionPage.TotalCount),
+	}, nil
+}
@@ -0,0 +1,152 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/victor-nach/time-tracker/db"
+	types "github.com/victor-nach/time-tracker/graph/model"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"github.com/victor-nach/time-tracker/lib/tokenhandler"
+	"github.com/victor-nach/time-tracker/mocks"
+	"github.com/victor-nach/time-tracker/models"
+	"github.com/victor-nach/time-tracker/server/middlewares"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestMutationResolver_DeleteProject(t *testing.T) {
+	const (
+		success = iota
+		projectNotFoundError
+		projectInUseError
+		archiveProject
+	)
+
+	var tests = []struct {
+		name     string
+		testType int
+	}{
+		{
+			name:     "Successfully delete project",
+			testType: success,
+		},
+		{
+			name:     "Test project not found error",
+			testType: projectNotFoundError,
+		},
+		{
+			name:     "Test project in use error",
+			testType: projectInUseError,
+		},
+		{
+			name:     "Successfully archive project in use",
+			testType: archiveProject,
+		},
+	}
+
+	mockProject := models.Project{ID: "projectId", Owner: "userId", Name: "project"}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			storeMock := new(mocks.Datastore)
+			resolvers := NewResolver(storeMock, nil, zaptest.NewLogger(t))
+			ctx := context.WithValue(context.Background(), middlewares.AuthContextKey,
+				tokenhandler.Claims{UserId: "userId"})
+
+			switch testCase.testType {
+			case success:
+				storeMock.On("GetProject", mock.Anything, "projectId", "userId").Return(&mockProject, nil)
+				storeMock.On("DeleteProject", mock.Anything, "projectId").Return(nil)
+
+				resp, err := resolvers.Mutation().DeleteProject(ctx, "projectId", nil)
+				assert.NoError(t, err)
+				assert.True(t, resp.Success)
+
+			case projectNotFoundError:
+				storeMock.On("GetProject", mock.Anything, "projectId", "userId").Return(nil, errors.New(""))
+
+				resp, err := resolvers.Mutation().DeleteProject(ctx, "projectId", nil)
+				assert.Nil(t, resp)
+				assert.IsType(t, &rerrors.Err{}, err)
+				assert.Equal(t, rerrors.ProjectNotFoundErr, err.(*rerrors.Err).Code)
+
+			case projectInUseError:
+				storeMock.On("GetProject", mock.Anything, "projectId", "userId").Return(&mockProject, nil)
+				storeMock.On("DeleteProject", mock.Anything, "projectId").Return(db.ErrProjectInUse)
+
+				resp, err := resolvers.Mutation().DeleteProject(ctx, "projectId", nil)
+				assert.Nil(t, resp)
+				assert.IsType(t, &rerrors.Err{}, err)
+				assert.Equal(t, rerrors.ProjectInUseErr, err.(*rerrors.Err).Code)
+
+			case archiveProject:
+				archive := true
+				storeMock.On("GetProject", mock.Anything, "projectId", "userId").Return(&mockProject, nil)
+				storeMock.On("DeleteProject", mock.Anything, "projectId").Return(db.ErrProjectInUse)
+				storeMock.On("UpdateProject", mock.Anything, "projectId", mock.MatchedBy(func(info models.ProjectInfo) bool {
+					return info.Archived != nil && *info.Archived
+				})).Return(nil)
+
+				resp, err := resolvers.Mutation().DeleteProject(ctx, "projectId", &archive)
+				assert.NoError(t, err)
+				assert.True(t, resp.Success)
+				storeMock.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestMutationResolver_UpdateProject(t *testing.T) {
+	mockProject := models.Project{
+		ID:            "projectId",
+		Owner:         "userId",
+		ClientID:      "newClient",
+		ClientHistory: []models.ClientLink{{Until: 1000}},
+	}
+	oldClient := "oldClient"
+
+	var tests = []struct {
+		name     string
+		clientID *string
+		matches  func(info models.ProjectInfo) bool
+	}{
+		{
+			name:     "Successfully record the previous client when the client changes",
+			clientID: &oldClient,
+			matches: func(info models.ProjectInfo) bool {
+				if info.ClientHistory == nil || len(*info.ClientHistory) != 2 {
+					return false
+				}
+				link := (*info.ClientHistory)[1]
+				return link.ClientID == "newClient" && link.Until > 1000
+			},
+		},
+		{
+			name:     "Successfully keep the history when the client stays",
+			clientID: &mockProject.ClientID,
+			matches: func(info models.ProjectInfo) bool {
+				return info.ClientHistory == nil
+			},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			storeMock := new(mocks.Datastore)
+			resolvers := NewResolver(storeMock, nil, zaptest.NewLogger(t))
+			ctx := context.WithValue(context.Background(), middlewares.AuthContextKey,
+				tokenhandler.Claims{UserId: "userId"})
+
+			storeMock.On("GetProject", mock.Anything, "projectId", "userId").Return(&mockProject, nil)
+			storeMock.On("GetClient", mock.Anything, *testCase.clientID, "userId").Return(&models.Client{}, nil)
+			storeMock.On("UpdateProject", mock.Anything, "projectId", mock.MatchedBy(testCase.matches)).Return(nil)
+
+			_, err := resolvers.Mutation().UpdateProject(ctx, "projectId", types.UpdateProjectInput{ClientID: testCase.clientID})
+			assert.NoError(t, err)
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+
+	"github.com/victor-nach/time-tracker/db"
+	types "github.com/victor-nach/time-tracker/graph/model"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"github.com/victor-nach/time-tracker/models"
+	"go.uber.org/zap"
+)
+
+func (r *mutationResolver) CreateProject(ctx context.Context, input types.ProjectInput) (*types.Project, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("create project", zap.Error(err))
+		return nil, err
+	}
+
+	project := models.Project{
+		ID:    r.idGen.Generate(),
+		Owner: claims.UserId,
+		Name:  input.Name,
+		Ts:    r.clock.Now().Unix(),
+	}
+	if input.Description != nil {
+		project.Description = *input.Description
+	}
+	if input.ClientID != nil && *input.ClientID != "" {
+		if _, err := r.store.GetClient(ctx, *input.ClientID, claims.UserId); err != nil {
+			err = rerrors.Format(rerrors.ClientNotFoundErr, err)
+			r.logger.Error("create project", zap.Error(err))
+			return nil, err
+		}
+		project.ClientID = *input.ClientID
+	}
+
+	if _, err := r.store.CreateProject(ctx, &project); err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("create project", zap.Error(err))
+		return nil, err
+	}
+
+	return mapProject(&project), nil
+}
+
+func (r *mutationResolver) UpdateProject(ctx context.Context, id string, input types.UpdateProjectInput) (*types.Project, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("update project", zap.Error(err))
+		return nil, err
+	}
+
+	current, err := r.store.GetProject(ctx, id, claims.UserId)
+	if err != nil {
+		err = rerrors.Format(rerrors.ProjectNotFoundErr, err)
+		r.logger.Error("update project", zap.Error(err))
+		return nil, err
+	}
+
+	if input.ClientID != nil && *input.ClientID != "" {
+		if _, err := r.store.GetClient(ctx, *input.ClientID, claims.UserId); err != nil {
+			err = rerrors.Format(rerrors.ClientNotFoundErr, err)
+			r.logger.Error("update project", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	projectInfo := models.ProjectInfo{
+		ClientID:    input.ClientID,
+		Name:        input.Name,
+		Description: input.Description,
+		Archived:    input.Archived,
+	}
+	// sessions keep the client their project had when they started
+	if input.ClientID != nil && *input.ClientID != current.ClientID {
+		history := append(append([]models.ClientLink{}, current.ClientHistory...),
+			models.ClientLink{ClientID: current.ClientID, Until: r.clock.Now().Unix()})
+		projectInfo.ClientHistory = &history
+	}
+	if err := r.store.UpdateProject(ctx, id, projectInfo); err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("update project", zap.Error(err))
+		return nil, err
+	}
+
+	project, err := r.store.GetProject(ctx, id, claims.UserId)
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("update project", zap.Error(err))
+		return nil, err
+	}
+
+	return mapProject(project), nil
+}
+
+func (r *mutationResolver) DeleteProject(ctx context.Context, id string, archive *bool) (*types.Response, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("delete project", zap.Error(err))
+		return nil, err
+	}
+
+	if _, err := r.store.GetProject(ctx, id, claims.UserId); err != nil {
+		err = rerrors.Format(rerrors.ProjectNotFoundErr, err)
+		r.logger.Error("delete project", zap.Error(err))
+		return nil, err
+	}
+
+	err = r.store.DeleteProject(ctx, id)
+	if err == db.ErrProjectInUse && archive != nil && *archive {
+		archived := true
+		if err := r.store.UpdateProject(ctx, id, models.ProjectInfo{Archived: &archived}); err != nil {
+			err = rerrors.Format(rerrors.DatabaseErr, err)
+			r.logger.Error("archive project", zap.Error(err))
+			return nil, err
+		}
+
+		return &types.Response{
+			Success: true,
+			Message: "Project still has sessions, successfully archived project",
+		}, nil
+	}
+	if err != nil {
+		code := rerrors.DatabaseErr
+		if err == db.ErrProjectInUse {
+			code = rerrors.ProjectInUseErr
+		}
+		err = rerrors.Format(code, err)
+		r.logger.Error("delete project", zap.Error(err))
+		return nil, err
+	}
+
+	return &types.Response{
+		Success: true,
+		Message: "Successfully deleted project",
+	}, nil
+}
+
+func (r *queryResolver) Project(ctx context.Context, id string) (*types.Project, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("project", zap.Error(err))
+		return nil, err
+	}
+
+	project, err := r.store.GetProject(ctx, id, claims.UserId)
+	if err != nil {
+		err = rerrors.Format(rerrors.ProjectNotFoundErr, err)
+		r.logger.Error("project", zap.Error(err))
+		return nil, err
+	}
+
+	return mapProject(project), nil
+}
+
+func (r *queryResolver) Projects(ctx context.Context, includeArchived *bool) ([]*types.Project, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("projects", zap.Error(err))
+		return nil, err
+	}
+
+	projects, err := r.store.GetProjects(ctx, claims.UserId, includeArchived != nil && *includeArchived)
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("projects", zap.Error(err))
+		return nil, err
+	}
+
+	projectsResp := make([]*types.Project, len(projects))
+	for i, p := range projects {
+		projectsResp[i] = mapProject(p)
+	}
+
+	return projectsResp, nil
+}
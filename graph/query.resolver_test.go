@@ -7,6 +7,8 @@ import (
 	"github.com/99designs/gqlgen/client"
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/victor-nach/time-tracker/db"
 	"github.com/victor-nach/time-tracker/graph/generated"
 	types "github.com/victor-nach/time-tracker/graph/model"
 	"github.com/victor-nach/time-tracker/lib/rerrors"
@@ -50,6 +52,7 @@ func TestQueryResolver_Me(t *testing.T) {
 		success = iota
 		invalidAuthError
 		customerNotFoundError
+		revokedTokenError
 	)
 
 	var tests = []struct {
@@ -68,6 +71,10 @@ func TestQueryResolver_Me(t *testing.T) {
 			name:     "Test customer not found error",
 			testType: customerNotFoundError,
 		},
+		{
+			name:     "Test revoked access token",
+			testType: revokedTokenError,
+		},
 	}
 
 	for _, testCase := range tests {
@@ -78,12 +85,15 @@ func TestQueryResolver_Me(t *testing.T) {
 			switch testCase.testType {
 			case success:
 				mockToken := "token"
-				tokenHandlerMock.On("ValidateToken", mockToken).
-					Return(&tokenhandler.Claims{UserId: "userId"}, nil)
-				storeMock.On("GetUser", "userId").Return(&mockData.User, nil)
+				tokenHandlerMock.On("ValidateToken", mockToken, tokenhandler.AccessToken).
+					Return(&tokenhandler.Claims{UserId: "userId", SessionId: "sessionId"}, nil)
+				storeMock.On("GetTokenGeneration", mock.Anything, "userId").Return(int64(0), nil)
+				storeMock.On("GetLoginSession", mock.Anything, "sessionId", "userId").
+					Return(&models.LoginSession{ID: "sessionId", Owner: "userId"}, nil)
+				storeMock.On("GetUser", mock.Anything, "userId").Return(&mockData.User, nil)
 
 				srv := handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{Resolvers: resolvers}))
-				authMw := middlewares.NewAuthMiddleware(tokenHandlerMock, zaptest.NewLogger(t))
+				authMw := middlewares.NewAuthMiddleware(tokenHandlerMock, storeMock, zaptest.NewLogger(t))
 				gqlClient := client.New(authMw.HandleAuth(srv))
 
 				query := `query {me { id name email Ts } }`
@@ -104,7 +114,7 @@ func TestQueryResolver_Me(t *testing.T) {
 				fmt.Println(me, err)
 
 			case customerNotFoundError:
-				storeMock.On("GetUser", "userId").Return(nil, errors.New(""))
+				storeMock.On("GetUser", mock.Anything, "userId").Return(nil, errors.New(""))
 
 				ctx := context.WithValue(context.Background(), middlewares.AuthContextKey,
 					tokenhandler.Claims{UserId: "userId"})
@@ -112,6 +122,26 @@ func TestQueryResolver_Me(t *testing.T) {
 				assert.IsType(t, &rerrors.Err{}, err)
 				assert.Equal(t, rerrors.CustomerNotFoundErr, err.(*rerrors.Err).Code)
 				fmt.Println(me, err)
+
+			case revokedTokenError:
+				// the token was issued before the user logged out
+				mockToken := "token"
+				tokenHandlerMock.On("ValidateToken", mockToken, tokenhandler.AccessToken).
+					Return(&tokenhandler.Claims{UserId: "userId", Generation: 1}, nil)
+				storeMock.On("GetTokenGeneration", mock.Anything, "userId").Return(int64(2), nil)
+
+				srv := handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{Resolvers: resolvers}))
+				authMw := middlewares.NewAuthMiddleware(tokenHandlerMock, storeMock, zaptest.NewLogger(t))
+				gqlClient := client.New(authMw.HandleAuth(srv))
+
+				var resp struct {
+					Me *types.User
+				}
+				err := gqlClient.Post(`query {me { id } }`, &resp, func(bd *client.Request) {
+					bd.HTTP.Header.Add("Authorization", fmt.Sprintf("Bearer %v", mockToken))
+				})
+				assert.Error(t, err)
+				storeMock.AssertNotCalled(t, "GetUser", mock.Anything, mock.Anything)
 			}
 		})
 	}
@@ -150,12 +180,15 @@ func TestQueryResolver_Session(t *testing.T) {
 			switch testCase.testType {
 			case success:
 				mockToken := "token"
-				tokenHandlerMock.On("ValidateToken", mockToken).
-					Return(&tokenhandler.Claims{UserId: "userId"}, nil)
-				storeMock.On("GetSession", "id", "userId").Return(&mockData.Session, nil)
+				tokenHandlerMock.On("ValidateToken", mockToken, tokenhandler.AccessToken).
+					Return(&tokenhandler.Claims{UserId: "userId", SessionId: "sessionId"}, nil)
+				storeMock.On("GetTokenGeneration", mock.Anything, "userId").Return(int64(0), nil)
+				storeMock.On("GetLoginSession", mock.Anything, "sessionId", "userId").
+					Return(&models.LoginSession{ID: "sessionId", Owner: "userId"}, nil)
+				storeMock.On("GetSession", mock.Anything, "id", "userId").Return(&mockData.Session, nil)
 
 				srv := handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{Resolvers: resolvers}))
-				authMw := middlewares.NewAuthMiddleware(tokenHandlerMock, zaptest.NewLogger(t))
+				authMw := middlewares.NewAuthMiddleware(tokenHandlerMock, storeMock, zaptest.NewLogger(t))
 				gqlClient := client.New(authMw.HandleAuth(srv))
 
 				query := `query { session(id: "id") { id owner title description start end duration Ts} }`
@@ -177,7 +210,7 @@ func TestQueryResolver_Session(t *testing.T) {
 				fmt.Println(me, err)
 
 			case SessionNotFoundErr:
-				storeMock.On("GetSession", "id", "userId").Return(nil, errors.New(""))
+				storeMock.On("GetSession", mock.Anything, "id", "userId").Return(nil, errors.New(""))
 
 				ctx := context.WithValue(context.Background(), middlewares.AuthContextKey,
 					tokenhandler.Claims{UserId: "userId"})
@@ -189,3 +222,119 @@ func TestQueryResolver_Session(t *testing.T) {
 		})
 	}
 }
+
+func TestQueryResolver_RunningTimer(t *testing.T) {
+	const (
+		success = iota
+		invalidAuthError
+		noRunningTimer
+	)
+
+	var tests = []struct {
+		name     string
+		testType int
+	}{
+		{
+			name:     "Successfully get running timer",
+			testType: success,
+		},
+		{
+			name:     "Test invalid auth error",
+			testType: invalidAuthError,
+		},
+		{
+			name:     "Test no running timer",
+			testType: noRunningTimer,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			storeMock := new(mocks.Datastore)
+			resolvers := NewResolver(storeMock, nil, zaptest.NewLogger(t))
+			ctx := context.WithValue(context.Background(), middlewares.AuthContextKey,
+				tokenhandler.Claims{UserId: "userId"})
+
+			switch testCase.testType {
+			case success:
+				mockSession := mockData.Session
+				mockSession.Running = true
+				storeMock.On("GetRunningTimer", mock.Anything, "userId").Return(&mockSession, nil)
+
+				session, err := resolvers.Query().RunningTimer(ctx)
+				assert.NoError(t, err)
+				assert.Equal(t, mockSession.ID, session.ID)
+				assert.True(t, session.Running)
+
+			case invalidAuthError:
+				session, err := resolvers.Query().RunningTimer(context.Background())
+				assert.Nil(t, session)
+				assert.IsType(t, &rerrors.Err{}, err)
+				assert.Equal(t, rerrors.InvalidAuthErr, err.(*rerrors.Err).Code)
+
+			case noRunningTimer:
+				storeMock.On("GetRunningTimer", mock.Anything, "userId").Return(nil, db.ErrNoRunningTimer)
+
+				session, err := resolvers.Query().RunningTimer(ctx)
+				assert.NoError(t, err)
+				assert.Nil(t, session)
+			}
+		})
+	}
+}
+
+func TestQueryResolver_Sessions(t *testing.T) {
+	const (
+		success = iota
+		invalidRangeError
+	)
+
+	from, to := 1000, 2000
+	var tests = []struct {
+		name      string
+		testType  int
+		dateRange types.DateRange
+	}{
+		{
+			name:      "Successfully get sessions in range",
+			testType:  success,
+			dateRange: types.DateRange{From: &from, To: &to},
+		},
+		{
+			name:      "Test invalid range error",
+			testType:  invalidRangeError,
+			dateRange: types.DateRange{From: &to, To: &from},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			storeMock := new(mocks.Datastore)
+			resolvers := NewResolver(storeMock, nil, zaptest.NewLogger(t))
+			ctx := context.WithValue(context.Background(), middlewares.AuthContextKey,
+				tokenhandler.Claims{UserId: "userId"})
+
+			switch testCase.testType {
+			case success:
+				expectedFilter := models.SessionFilter{
+					AnyTags: []string{"design"},
+					From:    int64(from),
+					To:      int64(to),
+				}
+				storeMock.On("GetSessions", mock.Anything, "userId", expectedFilter).
+					Return([]*models.Session{&mockData.Session}, nil)
+
+				sessions, err := resolvers.Query().Sessions(ctx, nil, &testCase.dateRange, nil, []string{" design ", ""}, nil)
+				assert.NoError(t, err)
+				assert.Len(t, sessions, 1)
+				assert.Equal(t, mockData.Session.ID, sessions[0].ID)
+
+			case invalidRangeError:
+				sessions, err := resolvers.Query().Sessions(ctx, nil, &testCase.dateRange, nil, nil, nil)
+				assert.Nil(t, sessions)
+				assert.IsType(t, &rerrors.Err{}, err)
+				assert.Equal(t, rerrors.InvalidRequestErr, err.(*rerrors.Err).Code)
+			}
+		})
+	}
+}
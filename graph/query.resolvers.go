@@ -5,11 +5,12 @@ package graph
 
 import (
 	"context"
-	"fmt"
 
+	"github.com/victor-nach/time-tracker/db"
 	"github.com/victor-nach/time-tracker/graph/generated"
 	types "github.com/victor-nach/time-tracker/graph/model"
 	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"github.com/victor-nach/time-tracker/models"
 	"go.uber.org/zap"
 )
 
@@ -21,7 +22,7 @@ func (r *queryResolver) Me(ctx context.Context) (*types.User, error) {
 		return nil, err
 	}
 
-	user, err := r.store.GetUser(claims.UserId)
+	user, err := r.store.GetUser(ctx, claims.UserId)
 	if err != nil {
 		err := rerrors.Format(rerrors.CustomerNotFoundErr, err)
 		r.logger.Error("sign up", zap.Error(err))
@@ -41,44 +42,79 @@ func (r *queryResolver) Session(ctx context.Context, id string) (*types.Session,
 		return nil, err
 	}
 
-	session, err := r.store.GetSession(id, claims.UserId)
+	session, err := r.store.GetSession(ctx, id, claims.UserId)
 	if err != nil {
 		err = rerrors.Format(rerrors.SessionNotFoundErr, err)
 		r.logger.Error("delete session", zap.Error(err))
 		return nil, err
 	}
 
-	resp := mapSession(session)
+	sessionsResp, err := r.mapSessions(ctx, claims.UserId, []*models.Session{session})
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("session", zap.Error(err))
+		return nil, err
+	}
 
-	return resp, nil
+	return sessionsResp[0], nil
 }
 
-func (r *queryResolver) Sessions(ctx context.Context, filter *types.FilterType) ([]*types.Session, error) {
-	fmt.Println("sessions query ...")
+func (r *queryResolver) Sessions(ctx context.Context, filter *types.FilterType, rangeArg *types.DateRange, projectID *string, anyTags []string, allTags []string) ([]*types.Session, error) {
 	claims, err := r.getClaimsFromCtx(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	fil := ""
-	if filter != nil {
-		fil = filter.String()
+	fil, err := r.sessionFilter(ctx, claims.UserId, &types.SessionFilter{
+		Period:    filter,
+		Range:     rangeArg,
+		ProjectID: projectID,
+		AnyTags:   anyTags,
+		AllTags:   allTags,
+	})
+	if err != nil {
+		r.logger.Error("sessions", zap.Error(err))
+		return nil, err
 	}
-	sessions, err := r.store.GetSessions(claims.UserId, fil)
+
+	sessions, err := r.store.GetSessions(ctx, claims.UserId, fil)
 	if err != nil {
 		err = rerrors.Format(rerrors.DatabaseErr, err)
 		r.logger.Error("delete session", zap.Error(err))
 		return nil, err
 	}
 
-	sessionsResp := make([]*types.Session, len(sessions))
-	for i, s := range sessions {
-		sessionsResp[i] = mapSession(s)
+	sessionsResp, err := r.mapSessions(ctx, claims.UserId, sessions)
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("sessions", zap.Error(err))
+		return nil, err
 	}
 
 	return sessionsResp, nil
 }
 
+func (r *queryResolver) RunningTimer(ctx context.Context) (*types.Session, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("running timer", zap.Error(err))
+		return nil, err
+	}
+
+	session, err := r.store.GetRunningTimer(ctx, claims.UserId)
+	if err == db.ErrNoRunningTimer {
+		return nil, nil
+	}
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("running timer", zap.Error(err))
+		return nil, err
+	}
+
+	return mapSession(session, nil), nil
+}
+
 // Query returns generated.QueryResolver implementation.
 func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
 
@@ -0,0 +1,65 @@
+package graph
+
+import (
+	"context"
+
+	types "github.com/victor-nach/time-tracker/graph/model"
+	"github.com/victor-nach/time-tracker/lib/report"
+)
+
+// reportLabels returns the readable names of the project and client keys of a report
+func (r *Resolver) reportLabels(ctx context.Context, owner string, groupBy []types.ReportGroup) (map[types.ReportGroup]map[string]string, error) {
+	labels := map[types.ReportGroup]map[string]string{
+		types.ReportGroupProject: {"": "No project"},
+		types.ReportGroupClient:  {"": "No client"},
+		types.ReportGroupTag:     {"": "No tag"},
+	}
+	for _, group := range groupBy {
+		switch group {
+		case types.ReportGroupProject:
+			projects, err := r.store.GetProjects(ctx, owner, true)
+			if err != nil {
+				return nil, err
+			}
+			for _, p := range projects {
+				labels[group][p.ID] = p.Name
+			}
+		case types.ReportGroupClient:
+			clients, err := r.store.GetClients(ctx, owner, true)
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range clients {
+				labels[group][c.ID] = c.Name
+			}
+		}
+	}
+	return labels, nil
+}
+
+// mapReportBuckets converts report buckets to the corresponding graphql type,
+// the group of each level of buckets follows the order of groupBy
+func mapReportBuckets(buckets []*report.Bucket, groupBy []types.ReportGroup, labels map[types.ReportGroup]map[string]string) []*types.ReportBucket {
+	resp := make([]*types.ReportBucket, len(buckets))
+	if len(buckets) == 0 {
+		return resp
+	}
+
+	group := groupBy[0]
+	for i, b := range buckets {
+		label, ok := labels[group][b.Key]
+		if !ok {
+			label = b.Key
+		}
+		resp[i] = &types.ReportBucket{
+			Group:         group,
+			Key:           b.Key,
+			Label:         label,
+			TotalDuration: int(b.Duration),
+			SessionCount:  int(b.SessionCount),
+			Amount:        b.Amount,
+			Buckets:       mapReportBuckets(b.Buckets, groupBy[1:], labels),
+		}
+	}
+	return resp
+}
@@ -0,0 +1,104 @@
+package graph
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	types "github.com/victor-nach/time-tracker/graph/model"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"github.com/victor-nach/time-tracker/lib/tokenhandler"
+	"github.com/victor-nach/time-tracker/mocks"
+	"github.com/victor-nach/time-tracker/models"
+	"github.com/victor-nach/time-tracker/server/middlewares"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestQueryResolver_Report(t *testing.T) {
+	const (
+		success = iota
+		missingRangeError
+		duplicateGroupError
+	)
+
+	var tests = []struct {
+		name     string
+		testType int
+	}{
+		{
+			name:     "Successfully get a report",
+			testType: success,
+		},
+		{
+			name:     "Test missing range end error",
+			testType: missingRangeError,
+		},
+		{
+			name:     "Test duplicate group error",
+			testType: duplicateGroupError,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			storeMock := new(mocks.Datastore)
+			resolvers := NewResolver(storeMock, nil, zaptest.NewLogger(t))
+			ctx := context.WithValue(context.Background(), middlewares.AuthContextKey,
+				tokenhandler.Claims{UserId: "userId"})
+			from, to := 100, 200
+
+			switch testCase.testType {
+			case success:
+				storeMock.On("GetUser", mock.Anything, "userId").
+					Return(&models.User{ID: "userId", TimeZone: "Africa/Lagos", WeekStart: 1}, nil)
+				storeMock.On("GetProjects", mock.Anything, "userId", true).
+					Return([]*models.Project{{ID: "projectId", Name: "Website"}}, nil)
+
+				loc, _ := time.LoadLocation("Africa/Lagos")
+				query := models.ReportQuery{
+					From:      100,
+					To:        200,
+					GroupBy:   []string{models.ReportGroupProject, models.ReportGroupDay},
+					Location:  loc,
+					WeekStart: time.Monday,
+				}
+				storeMock.On("GetReport", mock.Anything, "userId", query).Return([]*models.ReportRow{
+					{Keys: []string{}, Duration: 90, SessionCount: 3, Amount: 10},
+					{Keys: []string{"projectId"}, Duration: 60, SessionCount: 2, Amount: 10},
+					{Keys: []string{""}, Duration: 30, SessionCount: 1},
+					{Keys: []string{"projectId", "1970-01-01"}, Duration: 60, SessionCount: 2, Amount: 10},
+					{Keys: []string{"", "1970-01-01"}, Duration: 30, SessionCount: 1},
+				}, nil)
+
+				rep, err := resolvers.Query().Report(ctx, types.DateRange{From: &from, To: &to},
+					[]types.ReportGroup{types.ReportGroupProject, types.ReportGroupDay})
+				assert.NoError(t, err)
+				assert.Equal(t, 90, rep.TotalDuration)
+				assert.Equal(t, 3, rep.SessionCount)
+				assert.Len(t, rep.Buckets, 2)
+				assert.Equal(t, "No project", rep.Buckets[0].Label)
+				assert.Equal(t, "Website", rep.Buckets[1].Label)
+				assert.Equal(t, types.ReportGroupProject, rep.Buckets[1].Group)
+				assert.Equal(t, 10.0, rep.Buckets[1].Amount)
+				assert.Len(t, rep.Buckets[1].Buckets, 1)
+				assert.Equal(t, types.ReportGroupDay, rep.Buckets[1].Buckets[0].Group)
+				assert.Equal(t, "1970-01-01", rep.Buckets[1].Buckets[0].Label)
+
+			case missingRangeError:
+				rep, err := resolvers.Query().Report(ctx, types.DateRange{From: &from}, nil)
+				assert.Nil(t, rep)
+				assert.IsType(t, &rerrors.Err{}, err)
+				assert.Equal(t, rerrors.InvalidRequestErr, err.(*rerrors.Err).Code)
+
+			case duplicateGroupError:
+				rep, err := resolvers.Query().Report(ctx, types.DateRange{From: &from, To: &to},
+					[]types.ReportGroup{types.ReportGroupTag, types.ReportGroupTag})
+				assert.Nil(t, rep)
+				assert.IsType(t, &rerrors.Err{}, err)
+				assert.Equal(t, rerrors.InvalidRequestErr, err.(*rerrors.Err).Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	types "github.com/victor-nach/time-tracker/graph/model"
+	"github.com/victor-nach/time-tracker/lib/report"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"github.com/victor-nach/time-tracker/models"
+	"go.uber.org/zap"
+)
+
+func (r *queryResolver) Report(ctx context.Context, rangeArg types.DateRange, groupBy []types.ReportGroup) (*types.Report, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("report", zap.Error(err))
+		return nil, err
+	}
+
+	from, to, err := mapDateRange(&rangeArg)
+	if err == nil && (from == 0 || to == 0) {
+		err = rerrors.Format(rerrors.InvalidRequestErr, errors.New("report range must have a start and an end"))
+	}
+	if err != nil {
+		r.logger.Error("report", zap.Error(err))
+		return nil, err
+	}
+
+	query := models.ReportQuery{From: from, To: to}
+	seen := map[types.ReportGroup]bool{}
+	for _, group := range groupBy {
+		if seen[group] {
+			err := rerrors.Format(rerrors.InvalidRequestErr, errors.New("report groups must be unique"))
+			r.logger.Error("report", zap.Error(err))
+			return nil, err
+		}
+		seen[group] = true
+		query.GroupBy = append(query.GroupBy, group.String())
+	}
+
+	user, err := r.store.GetUser(ctx, claims.UserId)
+	if err != nil {
+		err = rerrors.Format(rerrors.CustomerNotFoundErr, err)
+		r.logger.Error("report", zap.Error(err))
+		return nil, err
+	}
+	query.Location = user.Location()
+	query.WeekStart = time.Weekday(user.WeekStart)
+
+	rows, err := r.store.GetReport(ctx, claims.UserId, query)
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("report", zap.Error(err))
+		return nil, err
+	}
+
+	labels, err := r.reportLabels(ctx, claims.UserId, groupBy)
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("report", zap.Error(err))
+		return nil, err
+	}
+
+	root := report.Build(rows)
+	return &types.Report{
+		TotalDuration: int(root.Duration),
+		SessionCount:  int(root.SessionCount),
+		Amount:        root.Amount,
+		Buckets:       mapReportBuckets(root.Buckets, groupBy, labels),
+	}, nil
+}
@@ -3,15 +3,23 @@ package graph
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/victor-nach/time-tracker/db"
 	types "github.com/victor-nach/time-tracker/graph/model"
+	"github.com/victor-nach/time-tracker/lib/billing"
+	"github.com/victor-nach/time-tracker/lib/clock"
+	"github.com/victor-nach/time-tracker/lib/cursor"
 	"github.com/victor-nach/time-tracker/lib/encryptor"
+	"github.com/victor-nach/time-tracker/lib/mailer"
 	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"github.com/victor-nach/time-tracker/lib/securetoken"
 	"github.com/victor-nach/time-tracker/lib/tokenhandler"
 	"github.com/victor-nach/time-tracker/lib/ulid"
 	"github.com/victor-nach/time-tracker/models"
 	"github.com/victor-nach/time-tracker/server/middlewares"
 	"go.uber.org/zap"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -21,20 +29,37 @@ type Resolver struct {
 	idGen        ulid.Idgenerator
 	encryptor    encryptor.Encryptor
 	tokenHandler tokenhandler.TokenHandler
-	logger       *zap.Logger
+	clock        clock.Clock
+	mailer       mailer.Mailer
+	// appURL is the address of the app that links in mails point to
+	appURL string
+	// mails counts the mails being sent in the background, mailSlots bounds how many are sent at once
+	mails     sync.WaitGroup
+	mailSlots chan struct{}
+	logger    *zap.Logger
 }
 
-// NewResolver returns a new resolver
+// NewResolver returns a new resolver, mails are written to the log until WithMailer sets a mailer
 func NewResolver(store db.Datastore, tokenHandler tokenhandler.TokenHandler, logger *zap.Logger) *Resolver {
 	return &Resolver{
 		store:        store,
 		idGen:        ulid.New(),
 		encryptor:    encryptor.NewEncryptor(),
 		tokenHandler: tokenHandler,
+		clock:        clock.New(),
+		mailer:       mailer.NewLog(logger),
+		mailSlots:    make(chan struct{}, maxBackgroundMails),
 		logger:       logger,
 	}
 }
 
+// WithMailer sets the mailer delivering mails and the address of the app their links point to
+func (r *Resolver) WithMailer(m mailer.Mailer, appURL string) *Resolver {
+	r.mailer = m
+	r.appURL = strings.TrimSuffix(appURL, "/")
+	return r
+}
+
 func (r *Resolver) getClaimsFromCtx(ctx context.Context) (*tokenhandler.Claims, error) {
 	claims, ok := ctx.Value(middlewares.AuthContextKey).(tokenhandler.Claims)
 	if !ok {
@@ -43,16 +68,195 @@ func (r *Resolver) getClaimsFromCtx(ctx context.Context) (*tokenhandler.Claims,
 	return &claims, nil
 }
 
-func (r *mutationResolver) genAuthTokens(userId string) (authToken string, refreshToken string, err error) {
-	tokenExpiry := time.Now().Add(tokenhandler.AuthTokenDuration)
-	authToken, err = r.tokenHandler.NewToken(userId, tokenExpiry)
+// clientFromCtx returns the device the request comes from, it is empty outside of http requests
+func clientFromCtx(ctx context.Context) middlewares.Client {
+	client, _ := ctx.Value(middlewares.ClientContextKey).(middlewares.Client)
+	return client
+}
+
+// genAuthTokens signs the tokens of a new login of the user, the device gets a login session
+// and its refresh token starts the family of the session
+func (r *mutationResolver) genAuthTokens(ctx context.Context, user *models.User) (authToken string, refreshToken string, err error) {
+	userId := user.ID
+	now := r.clock.Now().Unix()
+	client := clientFromCtx(ctx)
+	login := &models.LoginSession{
+		ID:         r.idGen.Generate(),
+		Owner:      userId,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastUsedAt: now,
+	}
+	if err := r.store.CreateLoginSession(ctx, login); err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("generate token", zap.Error(err))
+		return "", "", err
+	}
+
+	refresh := r.newRefreshToken(userId)
+	refresh.Family = login.ID
+	if err := r.store.CreateRefreshToken(ctx, refresh); err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("generate token", zap.Error(err))
+		return "", "", err
+	}
+	return r.signTokens(userId, user.TokenGeneration, refresh)
+}
+
+// signOut signs the device of a login session out: its refresh tokens are revoked and its session removed,
+// which revokes its access tokens as they carry the id of the session
+func (r *mutationResolver) signOut(ctx context.Context, loginSession string) error {
+	if err := r.store.RevokeRefreshFamily(ctx, loginSession); err != nil {
+		return err
+	}
+	return r.store.DeleteLoginSession(ctx, loginSession)
+}
+
+// signOutAll signs every device of the user out and revokes every token issued to them
+func (r *mutationResolver) signOutAll(ctx context.Context, userId string) error {
+	if err := r.store.RevokeUserRefreshTokens(ctx, userId); err != nil {
+		return err
+	}
+	if err := r.store.DeleteLoginSessions(ctx, userId); err != nil {
+		return err
+	}
+	_, err := r.store.IncrementTokenGeneration(ctx, userId)
+	return err
+}
+
+const (
+	// passwordResetTTL is how long a link to reset a passcode works
+	passwordResetTTL = time.Hour
+	// verificationTTL is how long a link to verify an email works
+	verificationTTL = 24 * time.Hour
+	// maxBackgroundMails is how many mails are sent in the background at once
+	maxBackgroundMails = 32
+	// backgroundMailTimeout is how long storing and sending a mail in the background may take
+	backgroundMailTimeout = 30 * time.Second
+)
+
+// sendVerification mails a link to verify the email of the user, the links mailed before stop working
+func (r *mutationResolver) sendVerification(ctx context.Context, user *models.User) error {
+	if err := r.store.DeleteOneTimeTokens(ctx, user.ID, models.PurposeEmailVerification); err != nil {
+		return err
+	}
+	token, err := r.newOneTimeToken(ctx, user.ID, models.PurposeEmailVerification, verificationTTL)
+	if err != nil {
+		return err
+	}
+	return r.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Hi %s,\n\nopen this link within a day to verify your email:\n\n%s\n\n"+
+			"If you did not sign up, you can ignore this mail.\n",
+			user.Name, r.link("/verify-email", token)),
+	})
+}
+
+// sendInBackground sends a mail without holding up the request, the mail is dropped when
+// maxBackgroundMails are already being sent so a flood of requests can not pile up senders
+func (r *Resolver) sendInBackground(name string, send func(ctx context.Context)) {
+	select {
+	case r.mailSlots <- struct{}{}:
+	default:
+		r.logger.Warn(name, zap.String("reason", "too many mails being sent, mail dropped"))
+		return
+	}
+
+	r.mails.Add(1)
+	go func() {
+		defer r.mails.Done()
+		defer func() { <-r.mailSlots }()
+		ctx, cancel := context.WithTimeout(context.Background(), backgroundMailTimeout)
+		defer cancel()
+		send(ctx)
+	}()
+}
+
+// WaitMails waits until the mails sent in the background are out or ctx is done
+func (r *Resolver) WaitMails(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		r.mails.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sendPasswordReset mails a link to reset the passcode of the user, failures are only logged
+// as the request was already answered
+func (r *mutationResolver) sendPasswordReset(ctx context.Context, user *models.User) {
+	token, err := r.newOneTimeToken(ctx, user.ID, models.PurposePasswordReset, passwordResetTTL)
+	if err != nil {
+		r.logger.Error("request password reset", zap.Error(rerrors.Format(rerrors.DatabaseErr, err)))
+		return
+	}
+
+	err = r.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your passcode",
+		Body: fmt.Sprintf("Hi %s,\n\nopen this link within an hour to choose a new passcode:\n\n%s\n\n"+
+			"If you did not ask to reset your passcode, you can ignore this mail.\n",
+			user.Name, r.link("/reset-password", token)),
+	})
+	if err != nil {
+		r.logger.Error("request password reset", zap.Error(rerrors.Format(rerrors.InternalErr, err)))
+	}
+}
+
+// newOneTimeToken stores the hash of a new one time token of the user for purpose and returns the token
+func (r *mutationResolver) newOneTimeToken(ctx context.Context, userId, purpose string, ttl time.Duration) (string, error) {
+	token, err := securetoken.Generate()
+	if err != nil {
+		return "", err
+	}
+	now := r.clock.Now()
+	err = r.store.CreateOneTimeToken(ctx, &models.OneTimeToken{
+		ID:        securetoken.Hash(token),
+		Owner:     userId,
+		Purpose:   purpose,
+		ExpiresAt: now.Add(ttl).Unix(),
+		Ts:        now.Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// link returns the address of a page of the app that receives token, tokens are url safe
+func (r *Resolver) link(path, token string) string {
+	return r.appURL + path + "?token=" + token
+}
+
+// newRefreshToken returns the record of a new refresh token of the user, the store sets its family
+func (r *mutationResolver) newRefreshToken(userId string) *models.RefreshToken {
+	now := r.clock.Now()
+	return &models.RefreshToken{
+		ID:        r.idGen.Generate(),
+		Owner:     userId,
+		ExpiresAt: now.Add(tokenhandler.RefreshTokenDuration).Unix(),
+		Ts:        now.Unix(),
+	}
+}
+
+// signTokens signs an access token of the user's current token generation and the refresh token of the stored record,
+// both belong to the login session of the refresh token family
+func (r *mutationResolver) signTokens(userId string, generation int64, refresh *models.RefreshToken) (authToken string, refreshToken string, err error) {
+	tokenExpiry := r.clock.Now().Add(tokenhandler.AuthTokenDuration)
+	authToken, err = r.tokenHandler.NewToken(userId, refresh.Family, generation, tokenExpiry)
 	if err != nil {
 		err := rerrors.Format(rerrors.InternalErr, nil)
 		r.logger.Error("generate token", zap.Error(err))
 		return "", "", err
 	}
-	refreshExpiry := time.Now().Add(tokenhandler.RefreshTokenDuration)
-	refreshToken, err = r.tokenHandler.NewToken(userId, refreshExpiry)
+	refreshToken, err = r.tokenHandler.NewRefreshToken(userId, refresh.ID, time.Unix(refresh.ExpiresAt, 0))
 	if err != nil {
 		err := rerrors.Format(rerrors.InternalErr, nil)
 		r.logger.Error("generate token", zap.Error(err))
@@ -61,26 +265,315 @@ func (r *mutationResolver) genAuthTokens(userId string) (authToken string, refre
 	return authToken, refreshToken, nil
 }
 
-// mapSession converts models.Session the corresponding graphql type
-func mapSession(data *models.Session) *types.Session {
+// sessionFilter converts the graphql session filter to the store filter,
+// errors returned are already formatted
+func (r *Resolver) sessionFilter(ctx context.Context, owner string, input *types.SessionFilter) (models.SessionFilter, error) {
+	filter := models.SessionFilter{}
+	if input == nil {
+		return filter, nil
+	}
+
+	if input.Period != nil {
+		// period boundaries follow the time zone and week start of the user
+		user, err := r.store.GetUser(ctx, owner)
+		if err != nil {
+			return filter, rerrors.Format(rerrors.CustomerNotFoundErr, err)
+		}
+		filter.Period = input.Period.String()
+		filter.Location = user.Location()
+		filter.WeekStart = time.Weekday(user.WeekStart)
+	}
+	if input.ProjectID != nil {
+		filter.ProjectID = *input.ProjectID
+	}
+	if len(input.AnyTags) > 0 {
+		filter.AnyTags = normalizeTags(input.AnyTags)
+	}
+	if len(input.AllTags) > 0 {
+		filter.AllTags = normalizeTags(input.AllTags)
+	}
+	if input.Range != nil {
+		from, to, err := mapDateRange(input.Range)
+		if err != nil {
+			return filter, err
+		}
+		filter.From, filter.To = from, to
+	}
+	return filter, nil
+}
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// mapPage converts relay pagination arguments to a store page
+func mapPage(first *int, after *string, last *int, before *string) (models.Page, error) {
+	page := models.Page{}
+	if first != nil && last != nil {
+		return page, errors.New("first and last can not be used together")
+	}
+
+	switch {
+	case first != nil:
+		page.First = *first
+	case last != nil:
+		page.Last = *last
+	default:
+		page.First = defaultPageSize
+	}
+	if page.First < 0 || page.Last < 0 || page.First > maxPageSize || page.Last > maxPageSize {
+		return page, fmt.Errorf("page size must be between 0 and %d", maxPageSize)
+	}
+
+	if after != nil {
+		c, err := cursor.Decode(*after)
+		if err != nil {
+			return page, err
+		}
+		page.After = c
+	}
+	if before != nil {
+		c, err := cursor.Decode(*before)
+		if err != nil {
+			return page, err
+		}
+		page.Before = c
+	}
+	return page, nil
+}
+
+// mapDateRange converts a graphql date range to unix timestamps, zero leaves a side of the range open
+func mapDateRange(dateRange *types.DateRange) (from, to int64, err error) {
+	if dateRange.From != nil {
+		from = int64(*dateRange.From)
+	}
+	if dateRange.To != nil {
+		to = int64(*dateRange.To)
+	}
+	if from < 0 || to < 0 || (to != 0 && from >= to) {
+		return 0, 0, rerrors.Format(rerrors.InvalidRequestErr, errors.New("range from must be before to"))
+	}
+	return from, to, nil
+}
+
+// normalizeTags trims the tags and drops empty and duplicate tags
+func normalizeTags(tags []string) []string {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// formatTimerErr converts timer errors from the store to the corresponding internal error
+func formatTimerErr(err error) error {
+	code := rerrors.DatabaseErr
+	switch err {
+	case db.ErrTimerRunning:
+		code = rerrors.TimerRunningErr
+	case db.ErrNoRunningTimer:
+		code = rerrors.NoRunningTimerErr
+	case db.ErrTimerPaused:
+		code = rerrors.TimerPausedErr
+	case db.ErrTimerNotPaused:
+		code = rerrors.TimerNotPausedErr
+	}
+	return rerrors.Format(code, err)
+}
+
+// newCalculator loads the rate history of a user for computing the amount earned for sessions
+func (r *Resolver) newCalculator(ctx context.Context, owner string) (*billing.Calculator, error) {
+	rates, err := r.store.GetRates(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	projects, err := r.store.GetProjects(ctx, owner, true)
+	if err != nil {
+		return nil, err
+	}
+	return billing.NewCalculator(rates, projects), nil
+}
+
+// mapSessions converts sessions to the corresponding graphql type,
+// the rate history is only loaded when one of the sessions is billable
+func (r *Resolver) mapSessions(ctx context.Context, owner string, sessions []*models.Session) ([]*types.Session, error) {
+	var calc *billing.Calculator
+	for _, s := range sessions {
+		if s.Billable && !s.Running {
+			c, err := r.newCalculator(ctx, owner)
+			if err != nil {
+				return nil, err
+			}
+			calc = c
+			break
+		}
+	}
+
+	sessionsResp := make([]*types.Session, len(sessions))
+	for i, s := range sessions {
+		sessionsResp[i] = mapSession(s, calc)
+	}
+	return sessionsResp, nil
+}
+
+// mapSession converts models.Session the corresponding graphql type,
+// amounts are only computed for finished sessions when a calculator is given
+func mapSession(data *models.Session, calc *billing.Calculator) *types.Session {
+	intervals := data.Intervals()
+	segments := make([]*types.Segment, len(intervals))
+	for i, s := range intervals {
+		segment := &types.Segment{Start: int(s.Start)}
+		if s.End != 0 {
+			end := int(s.End)
+			segment.End = &end
+		}
+		segments[i] = segment
+	}
+
+	var amount float64
+	if !data.Running {
+		amount = calc.Amount(data)
+	}
+
+	tags := data.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	var projectID *string
+	if data.ProjectID != "" {
+		projectID = &data.ProjectID
+	}
+
 	return &types.Session{
 		ID:          data.ID,
 		Owner:       data.Owner,
 		Title:       &data.Title,
 		Description: &data.Description,
+		ProjectID:   projectID,
+		Tags:        tags,
+		Billable:    data.Billable,
 		Duration:    int(data.Duration),
+		Amount:      amount,
 		Start:       int(data.Start),
 		End:         int(data.End),
+		Segments:    segments,
+		Running:     data.Running,
+		Draft:       data.Draft,
+		Paused:      data.Paused,
 		Ts:          int(data.Ts),
 	}
 }
 
+// mapProject converts models.Project to the corresponding graphql type
+func mapProject(data *models.Project) *types.Project {
+	var clientID *string
+	if data.ClientID != "" {
+		clientID = &data.ClientID
+	}
+
+	return &types.Project{
+		ID:          data.ID,
+		Owner:       data.Owner,
+		ClientID:    clientID,
+		Name:        data.Name,
+		Description: &data.Description,
+		Archived:    data.Archived,
+		Ts:          int(data.Ts),
+	}
+}
+
+// mapClient converts models.Client to the corresponding graphql type
+func mapClient(data *models.Client) *types.Client {
+	return &types.Client{
+		ID:       data.ID,
+		Owner:    data.Owner,
+		Name:     data.Name,
+		Archived: data.Archived,
+		Ts:       int(data.Ts),
+	}
+}
+
+// mapDevice converts models.LoginSession to the corresponding graphql type, current is the login
+// session of the request
+func mapDevice(data *models.LoginSession, current string) *types.Device {
+	return &types.Device{
+		ID:         data.ID,
+		UserAgent:  data.UserAgent,
+		IP:         data.IP,
+		CreatedAt:  int(data.CreatedAt),
+		LastUsedAt: int(data.LastUsedAt),
+		Current:    data.ID == current,
+	}
+}
+
+// mapRate converts models.Rate to the corresponding graphql type
+func mapRate(data *models.Rate) *types.Rate {
+	var scopeID *string
+	if data.ScopeID != "" {
+		scopeID = &data.ScopeID
+	}
+
+	return &types.Rate{
+		ID:            data.ID,
+		Scope:         types.RateScope(data.Scope),
+		ScopeID:       scopeID,
+		HourlyRate:    data.HourlyRate,
+		EffectiveFrom: int(data.EffectiveFrom),
+		Ts:            int(data.Ts),
+	}
+}
+
+// overlapIDs returns the ids of the sessions new sessions overlap, each once
+func overlapIDs(sessions []*models.Session) []string {
+	ids := make([]string, 0, len(sessions))
+	seen := map[string]bool{}
+	for _, s := range sessions {
+		if !seen[s.ID] {
+			seen[s.ID] = true
+			ids = append(ids, s.ID)
+		}
+	}
+	return ids
+}
+
+// weekdayIndex returns the position of a graphql weekday, sunday is 0
+func weekdayIndex(day types.Weekday) int {
+	for i, d := range types.AllWeekday {
+		if d == day {
+			return i
+		}
+	}
+	return 0
+}
+
 // mapUser converts models.Session the corresponding graphql type
 func mapUser(data *models.User) *types.User {
+	weekStart := types.WeekdaySunday
+	if data.WeekStart > 0 && data.WeekStart < len(types.AllWeekday) {
+		weekStart = types.AllWeekday[data.WeekStart]
+	}
+
+	overlapPolicy := types.OverlapPolicy(data.OverlapPolicy)
+	if !overlapPolicy.IsValid() {
+		overlapPolicy = types.OverlapPolicyWarn
+	}
+
 	return &types.User{
-		ID:    data.ID,
-		Name:  &data.Name,
-		Email: data.Email,
-		Ts:    int(data.Ts),
+		ID:            data.ID,
+		Name:          &data.Name,
+		Email:         data.Email,
+		TimeZone:      data.Location().String(),
+		WeekStart:     weekStart,
+		OverlapPolicy: overlapPolicy,
+		EmailVerified: data.EmailVerified,
+		Ts:            int(data.Ts),
 	}
 }
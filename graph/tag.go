@@ -0,0 +1,50 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	types "github.com/victor-nach/time-tracker/graph/model"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"github.com/victor-nach/time-tracker/lib/validation"
+	"go.uber.org/zap"
+)
+
+// mergeTags replaces the tags with the into tag on every session of the authenticated user,
+// field names the argument holding the into tag in validation errors
+func (r *mutationResolver) mergeTags(ctx context.Context, operation string, tags []string, field, into string) (*types.Response, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error(operation, zap.Error(err))
+		return nil, err
+	}
+
+	tags = normalizeTags(tags)
+	target := normalizeTags([]string{into})
+	if len(tags) == 0 || len(target) == 0 {
+		err := rerrors.Format(rerrors.InvalidRequestErr, errors.New("tags must not be empty"))
+		r.logger.Error(operation, zap.Error(err))
+		return nil, err
+	}
+	// the sessions get the into tag, so it has the length limit of the tags saveSession takes
+	v := &validation.Errors{}
+	v.MaxLength(field, target[0], maxTagLength)
+	if err := v.Err(); err != nil {
+		r.logger.Error(operation, zap.Error(err))
+		return nil, err
+	}
+
+	count, err := r.store.MergeTags(ctx, claims.UserId, tags, target[0])
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error(operation, zap.Error(err))
+		return nil, err
+	}
+
+	return &types.Response{
+		Success: true,
+		Message: fmt.Sprintf("Successfully updated tags on %d sessions", count),
+	}, nil
+}
@@ -0,0 +1,72 @@
+package graph
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"github.com/victor-nach/time-tracker/lib/tokenhandler"
+	"github.com/victor-nach/time-tracker/mocks"
+	"github.com/victor-nach/time-tracker/server/middlewares"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestMutationResolver_MergeTags(t *testing.T) {
+	const (
+		success = iota
+		longTagError
+		longRenameError
+	)
+
+	var tests = []struct {
+		name     string
+		testType int
+	}{
+		{
+			name:     "Successfully merge tags",
+			testType: success,
+		},
+		{
+			name:     "Test merge into a tag over the length limit",
+			testType: longTagError,
+		},
+		{
+			name:     "Test rename to a tag over the length limit",
+			testType: longRenameError,
+		},
+	}
+
+	long := strings.Repeat("a", maxTagLength+1)
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			storeMock := new(mocks.Datastore)
+			resolvers := NewResolver(storeMock, nil, zaptest.NewLogger(t))
+			ctx := context.WithValue(context.Background(), middlewares.AuthContextKey,
+				tokenhandler.Claims{UserId: "userId"})
+
+			switch testCase.testType {
+			case success:
+				storeMock.On("MergeTags", mock.Anything, "userId", []string{"dev", "code"}, "coding").Return(int64(2), nil)
+
+				resp, err := resolvers.Mutation().MergeTags(ctx, []string{"dev", " code ", "dev"}, "coding ")
+				assert.NoError(t, err)
+				assert.True(t, resp.Success)
+
+			case longTagError:
+				_, err := resolvers.Mutation().MergeTags(ctx, []string{"dev"}, long)
+				assert.Equal(t, rerrors.ValidationErr, err.(*rerrors.Err).Code)
+				assert.Equal(t, "into", err.(*rerrors.Err).Fields[0].Field)
+				storeMock.AssertNotCalled(t, "MergeTags", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+			case longRenameError:
+				_, err := resolvers.Mutation().RenameTag(ctx, "dev", long)
+				assert.Equal(t, rerrors.ValidationErr, err.(*rerrors.Err).Code)
+				assert.Equal(t, "to", err.(*rerrors.Err).Fields[0].Field)
+			}
+			storeMock.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+
+	types "github.com/victor-nach/time-tracker/graph/model"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"go.uber.org/zap"
+)
+
+func (r *mutationResolver) RenameTag(ctx context.Context, from string, to string) (*types.Response, error) {
+	return r.mergeTags(ctx, "rename tag", []string{from}, "to", to)
+}
+
+func (r *mutationResolver) MergeTags(ctx context.Context, tags []string, into string) (*types.Response, error) {
+	return r.mergeTags(ctx, "merge tags", tags, "into", into)
+}
+
+func (r *queryResolver) Tags(ctx context.Context) ([]*types.TagCount, error) {
+	claims, err := r.getClaimsFromCtx(ctx)
+	if err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, err)
+		r.logger.Error("tags", zap.Error(err))
+		return nil, err
+	}
+
+	tags, err := r.store.GetTags(ctx, claims.UserId)
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		r.logger.Error("tags", zap.Error(err))
+		return nil, err
+	}
+
+	tagsResp := make([]*types.TagCount, len(tags))
+	for i, t := range tags {
+		tagsResp[i] = &types.TagCount{
+			Name:  t.Name,
+			Count: int(t.Count),
+		}
+	}
+
+	return tagsResp, nil
+}
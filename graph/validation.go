@@ -0,0 +1,108 @@
+package graph
+
+import (
+	"fmt"
+	"net/mail"
+	"time"
+	"unicode/utf8"
+
+	types "github.com/victor-nach/time-tracker/graph/model"
+	"github.com/victor-nach/time-tracker/lib/validation"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+const (
+	maxTitleLength       = 200
+	maxDescriptionLength = 5000
+	maxTags              = 50
+	maxTagLength         = 50
+	minPasscodeLength    = 8
+	// bcrypt ignores the bytes after the 72nd
+	maxPasscodeBytes = 72
+	maxEmailLength   = 254
+	// maxFuture leaves room for clocks that run ahead and users in timezones ahead of the server
+	maxFuture = 24 * time.Hour
+)
+
+// validateSession checks the input of a new session, now bounds how far in the future it may end
+func validateSession(input *types.SessionInput, now time.Time) error {
+	v := &validation.Errors{}
+	validateSessionInfo(v, input.Title, input.Description, input.Tags)
+
+	start, end := int64(input.Start), int64(input.End)
+	latest := now.Add(maxFuture).Unix()
+	if start <= 0 {
+		v.Add("start", "must be a positive unix timestamp")
+	} else if start > latest {
+		v.Add("start", "must not be more than a day in the future")
+	}
+	if end <= start {
+		v.Add("end", "must be after start")
+	} else if end > latest {
+		v.Add("end", "must not be more than a day in the future")
+	}
+
+	session := models.Session{Start: start, End: end}
+	var previous int64
+	for i, segment := range input.Segments {
+		field := fmt.Sprintf("segments[%d]", i)
+		s := models.Segment{Start: int64(segment.Start), End: int64(segment.End)}
+		if s.End <= s.Start {
+			v.Add(field+".end", "must be after the start of the segment")
+		} else if s.Start < start || s.End > end {
+			v.Add(field, "must be within the start and end of the session")
+		}
+		if i > 0 && s.Start < previous {
+			v.Add(field+".start", "must not be before the end of the previous segment")
+		}
+		previous = s.End
+		session.Segments = append(session.Segments, s)
+	}
+
+	if input.Duration != nil {
+		if *input.Duration < 0 {
+			v.Add("duration", "must not be negative")
+		} else if int64(*input.Duration) != session.TotalDuration() {
+			v.Add("duration", "must match the time tracked by the session")
+		}
+	}
+	return v.Err()
+}
+
+// validateSessionUpdate checks the fields of a session that can be edited after it is saved
+func validateSessionUpdate(input *types.UpdateSessionInput) error {
+	v := &validation.Errors{}
+	validateSessionInfo(v, input.Title, input.Description, input.Tags)
+	return v.Err()
+}
+
+// validateSessionInfo bounds the size of the free text fields of a session
+func validateSessionInfo(v *validation.Errors, title, description *string, tags []string) {
+	if title != nil {
+		v.MaxLength("title", *title, maxTitleLength)
+	}
+	if description != nil {
+		v.MaxLength("description", *description, maxDescriptionLength)
+	}
+	v.Check(len(tags) <= maxTags, "tags", fmt.Sprintf("must have at most %d tags", maxTags))
+	for i, tag := range tags {
+		v.MaxLength(fmt.Sprintf("tags[%d]", i), tag, maxTagLength)
+	}
+}
+
+// validatePasscode checks a new passcode
+func validatePasscode(field, passcode string) error {
+	v := &validation.Errors{}
+	v.Check(utf8.RuneCountInString(passcode) >= minPasscodeLength, field, fmt.Sprintf("must be at least %d characters", minPasscodeLength))
+	v.Check(len(passcode) <= maxPasscodeBytes, field, fmt.Sprintf("must be at most %d bytes", maxPasscodeBytes))
+	return v.Err()
+}
+
+// validateEmail checks that email is a bare address like ada@email.com, without a display name
+func validateEmail(field, email string) error {
+	v := &validation.Errors{}
+	address, err := mail.ParseAddress(email)
+	v.Check(err == nil && address.Address == email && address.Name == "", field, "must be an email address")
+	v.MaxLength(field, email, maxEmailLength)
+	return v.Err()
+}
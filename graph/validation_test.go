@@ -0,0 +1,110 @@
+package graph
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	types "github.com/victor-nach/time-tracker/graph/model"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"github.com/victor-nach/time-tracker/lib/tokenhandler"
+	"github.com/victor-nach/time-tracker/mocks"
+	"github.com/victor-nach/time-tracker/server/middlewares"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestValidateSession(t *testing.T) {
+	now := time.Unix(1000000, 0)
+	intPtr := func(i int) *int { return &i }
+	longTitle := strings.Repeat("a", maxTitleLength+1)
+
+	var tests = []struct {
+		name   string
+		input  types.SessionInput
+		fields []string
+	}{
+		{
+			name:  "Valid session",
+			input: types.SessionInput{Start: 100, End: 200, Duration: intPtr(100)},
+		},
+		{
+			name: "Valid session with segments",
+			input: types.SessionInput{Start: 100, End: 400, Duration: intPtr(150), Segments: []*types.SegmentInput{
+				{Start: 100, End: 200}, {Start: 350, End: 400},
+			}},
+		},
+		{
+			name:   "End before start",
+			input:  types.SessionInput{Start: 200, End: 100},
+			fields: []string{"end"},
+		},
+		{
+			name:   "Negative start and duration",
+			input:  types.SessionInput{Start: -100, End: 100, Duration: intPtr(-200)},
+			fields: []string{"start", "duration"},
+		},
+		{
+			name:   "Duration disagrees with end minus start",
+			input:  types.SessionInput{Start: 100, End: 200, Duration: intPtr(500)},
+			fields: []string{"duration"},
+		},
+		{
+			name:   "Far future timestamps",
+			input:  types.SessionInput{Start: 100, End: int(now.Add(2 * maxFuture).Unix())},
+			fields: []string{"end"},
+		},
+		{
+			name: "Segments out of order and out of bounds",
+			input: types.SessionInput{Start: 100, End: 400, Segments: []*types.SegmentInput{
+				{Start: 200, End: 300}, {Start: 150, End: 180}, {Start: 350, End: 500}, {Start: 500, End: 500},
+			}},
+			fields: []string{"segments[1].start", "segments[2]", "segments[3].end"},
+		},
+		{
+			name:   "Too long title and tag",
+			input:  types.SessionInput{Title: &longTitle, Start: 100, End: 200, Tags: []string{"ok", longTitle}},
+			fields: []string{"title", "tags[1]"},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := validateSession(&testCase.input, now)
+			if len(testCase.fields) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			e, ok := err.(*rerrors.Err)
+			if assert.True(t, ok) {
+				assert.Equal(t, rerrors.ValidationErr, e.Code)
+				var fields []string
+				for _, f := range e.Fields {
+					fields = append(fields, f.Field)
+				}
+				assert.Equal(t, testCase.fields, fields)
+			}
+		})
+	}
+}
+
+func TestMutationResolver_SessionValidation(t *testing.T) {
+	storeMock := new(mocks.Datastore)
+	resolvers := NewResolver(storeMock, nil, zaptest.NewLogger(t))
+	ctx := context.WithValue(context.Background(), middlewares.AuthContextKey,
+		tokenhandler.Claims{UserId: "userId"})
+
+	_, err := resolvers.Mutation().SaveSession(ctx, &types.SessionInput{Start: 200, End: 100})
+	assert.Error(t, err)
+	assert.Equal(t, rerrors.ValidationErr, err.(*rerrors.Err).Code)
+
+	description := strings.Repeat("a", maxDescriptionLength+1)
+	_, err = resolvers.Mutation().UpdateSessionInfo(ctx, "sessionId", &types.UpdateSessionInput{Description: &description})
+	assert.Error(t, err)
+	assert.Equal(t, []rerrors.FieldError{{Field: "description", Message: "must be at most 5000 characters"}}, err.(*rerrors.Err).Fields)
+
+	// invalid input is rejected before the store is used
+	storeMock.AssertExpectations(t)
+	storeMock.AssertNotCalled(t, "GetSession")
+}
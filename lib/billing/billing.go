@@ -0,0 +1,183 @@
+package billing
+
+import (
+	"math"
+	"sort"
+
+	"github.com/victor-nach/time-tracker/models"
+)
+
+// Rule is an hourly rate together with the sessions it applies to
+type Rule struct {
+	// ProjectIDs limits the rule to sessions of these projects, a nil slice matches every session
+	ProjectIDs []string
+	// From is the unix time from which the rate is in effect
+	From int64
+	// Until ends the rule before this unix time, zero leaves it open
+	Until      int64
+	HourlyRate float64
+}
+
+// Matches reports whether the rule applies to the given session
+func (r Rule) Matches(session *models.Session) bool {
+	if session.Start < r.From {
+		return false
+	}
+	if r.Until != 0 && session.Start >= r.Until {
+		return false
+	}
+	if r.ProjectIDs == nil {
+		return true
+	}
+	for _, id := range r.ProjectIDs {
+		if id == session.ProjectID {
+			return true
+		}
+	}
+	return false
+}
+
+// Rules returns the rates as rules ordered by precedence, the first matching rule
+// holds the rate in effect for a session. Project rates take precedence over client
+// rates which take precedence over user rates, within a scope newer rates come first.
+// Client rates follow the client history of the projects, a session keeps the client
+// its project had when the session started
+func Rules(rates []*models.Rate, projects []*models.Project) []Rule {
+	clientSpans := map[string][]ClientSpan{}
+	for _, p := range projects {
+		for _, s := range ClientSpans(p) {
+			if s.ClientID != "" {
+				clientSpans[s.ClientID] = append(clientSpans[s.ClientID], s)
+			}
+		}
+	}
+
+	sorted := make([]*models.Rate, len(rates))
+	copy(sorted, rates)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		pi, pj := precedence(sorted[i].Scope), precedence(sorted[j].Scope)
+		if pi != pj {
+			return pi < pj
+		}
+		return sorted[i].EffectiveFrom > sorted[j].EffectiveFrom
+	})
+
+	rules := make([]Rule, 0, len(sorted))
+	for _, rate := range sorted {
+		rule := Rule{From: rate.EffectiveFrom, HourlyRate: rate.HourlyRate}
+		switch rate.Scope {
+		case models.RateScopeProject:
+			rule.ProjectIDs = []string{rate.ScopeID}
+		case models.RateScopeClient:
+			// a client without projects has no sessions to bill
+			rules = append(rules, clientRules(rule, clientSpans[rate.ScopeID])...)
+			continue
+		case models.RateScopeUser:
+		default:
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// ClientSpan is a period during which a project belonged to a client, a zero Until leaves it open
+type ClientSpan struct {
+	ProjectID string
+	ClientID  string
+	From      int64
+	Until     int64
+}
+
+// ClientSpans splits the life of a project by the clients it had, the current client has an open span
+func ClientSpans(p *models.Project) []ClientSpan {
+	spans := make([]ClientSpan, 0, len(p.ClientHistory)+1)
+	var from int64
+	for _, link := range p.ClientHistory {
+		spans = append(spans, ClientSpan{ProjectID: p.ID, ClientID: link.ClientID, From: from, Until: link.Until})
+		from = link.Until
+	}
+	return append(spans, ClientSpan{ProjectID: p.ID, ClientID: p.ClientID, From: from})
+}
+
+// ClientAt returns the client the project had at the unix time at, the client its sessions starting then are billed to
+func ClientAt(p *models.Project, at int64) string {
+	for _, s := range ClientSpans(p) {
+		if s.Until == 0 || at < s.Until {
+			return s.ClientID
+		}
+	}
+	return p.ClientID
+}
+
+// clientRules narrows a client rate to the spans of its projects, spans covering the
+// same period share a rule so projects that never changed client stay in one rule
+func clientRules(rate Rule, spans []ClientSpan) []Rule {
+	rules := []Rule{}
+	index := map[[2]int64]int{}
+	for _, s := range spans {
+		from, until := rate.From, s.Until
+		if s.From > from {
+			from = s.From
+		}
+		if until != 0 && from >= until {
+			continue
+		}
+		key := [2]int64{from, until}
+		i, ok := index[key]
+		if !ok {
+			i = len(rules)
+			index[key] = i
+			rules = append(rules, Rule{ProjectIDs: []string{}, From: from, Until: until, HourlyRate: rate.HourlyRate})
+		}
+		rules[i].ProjectIDs = append(rules[i].ProjectIDs, s.ProjectID)
+	}
+	return rules
+}
+
+func precedence(scope string) int {
+	switch scope {
+	case models.RateScopeProject:
+		return 0
+	case models.RateScopeClient:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Calculator computes the amount earned for sessions
+type Calculator struct {
+	rules []Rule
+}
+
+// NewCalculator returns a calculator for the rate history of a user
+func NewCalculator(rates []*models.Rate, projects []*models.Project) *Calculator {
+	return &Calculator{rules: Rules(rates, projects)}
+}
+
+// HourlyRate returns the hourly rate that was in effect when the session started
+func (c *Calculator) HourlyRate(session *models.Session) float64 {
+	if c == nil {
+		return 0
+	}
+	for _, rule := range c.rules {
+		if rule.Matches(session) {
+			return rule.HourlyRate
+		}
+	}
+	return 0
+}
+
+// Amount returns the amount earned for a session, non billable sessions earn nothing
+func (c *Calculator) Amount(session *models.Session) float64 {
+	if !session.Billable {
+		return 0
+	}
+	return Round(c.HourlyRate(session) * float64(session.Duration) / 3600)
+}
+
+// Round rounds an amount to two decimal places
+func Round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
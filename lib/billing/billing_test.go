@@ -0,0 +1,116 @@
+package billing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+func TestCalculator_Amount(t *testing.T) {
+	projects := []*models.Project{
+		{ID: "clientProject", ClientID: "client"},
+		{ID: "ratedProject", ClientID: "client"},
+		{ID: "personalProject"},
+	}
+	rates := []*models.Rate{
+		{Scope: models.RateScopeUser, HourlyRate: 10, EffectiveFrom: 0},
+		{Scope: models.RateScopeUser, HourlyRate: 15, EffectiveFrom: 2000},
+		{Scope: models.RateScopeClient, ScopeID: "client", HourlyRate: 20, EffectiveFrom: 1000},
+		{Scope: models.RateScopeProject, ScopeID: "ratedProject", HourlyRate: 30, EffectiveFrom: 1500},
+	}
+	calc := NewCalculator(rates, projects)
+
+	var tests = []struct {
+		name     string
+		session  models.Session
+		expected float64
+	}{
+		{
+			name:     "Test non billable session",
+			session:  models.Session{ProjectID: "ratedProject", Start: 3000, Duration: 3600},
+			expected: 0,
+		},
+		{
+			name:     "Test user rate",
+			session:  models.Session{Billable: true, Start: 500, Duration: 3600},
+			expected: 10,
+		},
+		{
+			name:     "Test newer user rate",
+			session:  models.Session{Billable: true, ProjectID: "personalProject", Start: 2500, Duration: 1800},
+			expected: 7.5,
+		},
+		{
+			name:     "Test client rate",
+			session:  models.Session{Billable: true, ProjectID: "clientProject", Start: 1200, Duration: 3600},
+			expected: 20,
+		},
+		{
+			name:     "Test client rate before project rate is in effect",
+			session:  models.Session{Billable: true, ProjectID: "ratedProject", Start: 1200, Duration: 3600},
+			expected: 20,
+		},
+		{
+			name:     "Test project rate",
+			session:  models.Session{Billable: true, ProjectID: "ratedProject", Start: 1600, Duration: 600},
+			expected: 5,
+		},
+		{
+			name:     "Test user rate before client rate is in effect",
+			session:  models.Session{Billable: true, ProjectID: "clientProject", Start: 900, Duration: 7200},
+			expected: 20,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, calc.Amount(&testCase.session))
+		})
+	}
+}
+
+func TestCalculator_NoRates(t *testing.T) {
+	var calc *Calculator
+	session := models.Session{Billable: true, Duration: 3600}
+	assert.Equal(t, float64(0), calc.Amount(&session))
+	assert.Equal(t, float64(0), NewCalculator(nil, nil).Amount(&session))
+}
+
+func TestCalculator_MovedProject(t *testing.T) {
+	rates := []*models.Rate{
+		{Scope: models.RateScopeClient, ScopeID: "oldClient", HourlyRate: 20, EffectiveFrom: 0},
+		{Scope: models.RateScopeClient, ScopeID: "newClient", HourlyRate: 40, EffectiveFrom: 0},
+	}
+	past := models.Session{Billable: true, ProjectID: "project", Start: 1000, Duration: 3600}
+	later := models.Session{Billable: true, ProjectID: "project", Start: 3000, Duration: 3600}
+
+	before := NewCalculator(rates, []*models.Project{{ID: "project", ClientID: "oldClient"}})
+	assert.Equal(t, float64(20), before.Amount(&past))
+
+	// the project moved to the new client at 2000, past sessions keep the old client rate
+	moved := []*models.Project{{
+		ID:            "project",
+		ClientID:      "newClient",
+		ClientHistory: []models.ClientLink{{ClientID: "oldClient", Until: 2000}},
+	}}
+	after := NewCalculator(rates, moved)
+	assert.Equal(t, float64(20), after.Amount(&past))
+	assert.Equal(t, float64(40), after.Amount(&later))
+}
+
+func TestCalculator_ProjectWithoutClientBefore(t *testing.T) {
+	rates := []*models.Rate{
+		{Scope: models.RateScopeUser, HourlyRate: 10, EffectiveFrom: 0},
+		{Scope: models.RateScopeClient, ScopeID: "client", HourlyRate: 40, EffectiveFrom: 0},
+	}
+	project := &models.Project{
+		ID:            "project",
+		ClientID:      "client",
+		ClientHistory: []models.ClientLink{{Until: 2000}},
+	}
+	calc := NewCalculator(rates, []*models.Project{project})
+
+	assert.Equal(t, float64(10), calc.Amount(&models.Session{Billable: true, ProjectID: "project", Start: 1000, Duration: 3600}))
+	assert.Equal(t, float64(40), calc.Amount(&models.Session{Billable: true, ProjectID: "project", Start: 2000, Duration: 3600}))
+}
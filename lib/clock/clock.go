@@ -0,0 +1,20 @@
+package clock
+
+import "time"
+
+//Clock tells the current time, it is injected wherever the result depends on "now"
+// so day, week and month boundaries can be tested at a fixed time
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+//New returns a clock reading the system time
+func New() Clock {
+	return systemClock{}
+}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
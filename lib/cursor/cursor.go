@@ -0,0 +1,37 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/victor-nach/time-tracker/models"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Encode returns the opaque string form of a cursor
+func Encode(c models.Cursor) string {
+	raw := strconv.FormatInt(c.Ts, 10) + ":" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Decode parses a cursor returned by Encode
+func Decode(s string) (*models.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, ErrInvalidCursor
+	}
+	ts, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &models.Cursor{Ts: ts, ID: parts[1]}, nil
+}
@@ -0,0 +1,24 @@
+package cursor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	c := models.Cursor{Ts: 1623810251, ID: "01F8B6JHN1W0PQ2B7T4S6X9YZ1"}
+
+	decoded, err := Decode(Encode(c))
+	assert.NoError(t, err)
+	assert.Equal(t, c, *decoded)
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	for _, s := range []string{"", "not base64!", Encode(models.Cursor{Ts: 1}), "MTIzNA"} {
+		c, err := Decode(s)
+		assert.Nil(t, c)
+		assert.Equal(t, ErrInvalidCursor, err)
+	}
+}
@@ -0,0 +1,186 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/victor-nach/time-tracker/lib/billing"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatXLSX   = "xlsx"
+)
+
+const (
+	ColumnID          = "id"
+	ColumnTitle       = "title"
+	ColumnDescription = "description"
+	ColumnProject     = "project"
+	ColumnClient      = "client"
+	ColumnTags        = "tags"
+	ColumnBillable    = "billable"
+	ColumnStart       = "start"
+	ColumnEnd         = "end"
+	ColumnDuration    = "duration"
+	ColumnAmount      = "amount"
+)
+
+// DefaultColumns are exported when no columns are requested
+var DefaultColumns = []string{
+	ColumnID, ColumnTitle, ColumnDescription, ColumnProject, ColumnClient, ColumnTags,
+	ColumnBillable, ColumnStart, ColumnEnd, ColumnDuration, ColumnAmount,
+}
+
+var (
+	ErrUnknownFormat = errors.New("unknown export format")
+	ErrUnknownColumn = errors.New("unknown export column")
+)
+
+// Encoder writes exported sessions one row at a time
+type Encoder interface {
+	Write(values []interface{}) error
+	Close() error
+}
+
+// NewEncoder returns an encoder for the format that writes the header of the columns to w
+func NewEncoder(format string, w io.Writer, columns []string) (Encoder, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVEncoder(w, columns)
+	case FormatNDJSON:
+		return newNDJSONEncoder(w, columns), nil
+	case FormatXLSX:
+		return newXLSXEncoder(w, columns)
+	}
+	return nil, ErrUnknownFormat
+}
+
+// ValidFormat reports whether the format can be exported
+func ValidFormat(format string) bool {
+	return format == FormatCSV || format == FormatNDJSON || format == FormatXLSX
+}
+
+// ContentType returns the mime type of the format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "application/octet-stream"
+}
+
+// ParseColumns parses a comma separated list of columns, an empty list selects the default columns
+func ParseColumns(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return DefaultColumns, nil
+	}
+
+	known := map[string]bool{}
+	for _, column := range DefaultColumns {
+		known[column] = true
+	}
+
+	var columns []string
+	for _, column := range strings.Split(s, ",") {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if !known[column] {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownColumn, column)
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// Lookup resolves the related entities of exported sessions
+type Lookup struct {
+	Projects   map[string]*models.Project
+	Clients    map[string]*models.Client
+	Calculator *billing.Calculator
+	Location   *time.Location
+}
+
+// NewLookup indexes the projects and clients of a user
+func NewLookup(projects []*models.Project, clients []*models.Client, calc *billing.Calculator, loc *time.Location) Lookup {
+	l := Lookup{
+		Projects:   map[string]*models.Project{},
+		Clients:    map[string]*models.Client{},
+		Calculator: calc,
+		Location:   loc,
+	}
+	for _, p := range projects {
+		l.Projects[p.ID] = p
+	}
+	for _, c := range clients {
+		l.Clients[c.ID] = c
+	}
+	return l
+}
+
+// Row returns the values of the columns for a session, times are formatted in the location of the lookup
+func (l Lookup) Row(columns []string, session *models.Session) []interface{} {
+	loc := l.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	project := l.Projects[session.ProjectID]
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		switch column {
+		case ColumnID:
+			values[i] = session.ID
+		case ColumnTitle:
+			values[i] = session.Title
+		case ColumnDescription:
+			values[i] = session.Description
+		case ColumnProject:
+			values[i] = ""
+			if project != nil {
+				values[i] = project.Name
+			}
+		case ColumnClient:
+			values[i] = ""
+			if project != nil && l.Clients[project.ClientID] != nil {
+				values[i] = l.Clients[project.ClientID].Name
+			}
+		case ColumnTags:
+			tags := session.Tags
+			if tags == nil {
+				tags = []string{}
+			}
+			values[i] = tags
+		case ColumnBillable:
+			values[i] = session.Billable
+		case ColumnStart:
+			values[i] = time.Unix(session.Start, 0).In(loc).Format(time.RFC3339)
+		case ColumnEnd:
+			values[i] = time.Unix(session.End, 0).In(loc).Format(time.RFC3339)
+		case ColumnDuration:
+			values[i] = session.Duration
+		case ColumnAmount:
+			values[i] = l.Calculator.Amount(session)
+		}
+	}
+	return values
+}
+
+// text returns the plain text form of a value for the tabular formats
+func text(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ", ")
+	}
+	return fmt.Sprint(value)
+}
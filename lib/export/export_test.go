@@ -0,0 +1,97 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-nach/time-tracker/lib/billing"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+func TestLookup_Row(t *testing.T) {
+	projects := []*models.Project{{ID: "projectId", ClientID: "clientId", Name: "Website"}}
+	clients := []*models.Client{{ID: "clientId", Name: "Acme"}}
+	rates := []*models.Rate{{Scope: models.RateScopeUser, HourlyRate: 20}}
+	loc, _ := time.LoadLocation("Africa/Lagos")
+	lookup := NewLookup(projects, clients, billing.NewCalculator(rates, projects), loc)
+
+	session := &models.Session{
+		ID:        "sessionId",
+		Title:     "Landing page",
+		ProjectID: "projectId",
+		Tags:      []string{"design", "code"},
+		Billable:  true,
+		Start:     1614641400,
+		End:       1614643200,
+		Duration:  1800,
+	}
+	assert.Equal(t, []interface{}{"Landing page", "Website", "Acme", []string{"design", "code"}, "2021-03-02T00:30:00+01:00", int64(1800), 10.0},
+		lookup.Row([]string{ColumnTitle, ColumnProject, ColumnClient, ColumnTags, ColumnStart, ColumnDuration, ColumnAmount}, session))
+
+	session.ProjectID, session.Billable = "", false
+	assert.Equal(t, []interface{}{"", "", 0.0}, lookup.Row([]string{ColumnProject, ColumnClient, ColumnAmount}, session))
+}
+
+func TestParseColumns(t *testing.T) {
+	columns, err := ParseColumns("")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultColumns, columns)
+
+	columns, err = ParseColumns("Title, duration")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{ColumnTitle, ColumnDuration}, columns)
+
+	_, err = ParseColumns("title,password")
+	assert.Error(t, err)
+}
+
+func TestNewEncoder(t *testing.T) {
+	columns := []string{ColumnTitle, ColumnTags, ColumnBillable, ColumnDuration, ColumnAmount}
+	rows := [][]interface{}{
+		{"Landing page", []string{"design", "code"}, true, int64(1800), 10.0},
+		{`Review "Q1" <notes>`, []string{}, false, int64(60), 0.0},
+	}
+	encode := func(format string) []byte {
+		var buf bytes.Buffer
+		enc, err := NewEncoder(format, &buf, columns)
+		assert.NoError(t, err)
+		for _, row := range rows {
+			assert.NoError(t, enc.Write(row))
+		}
+		assert.NoError(t, enc.Close())
+		return buf.Bytes()
+	}
+
+	assert.Equal(t, "title,tags,billable,duration,amount\n"+
+		"Landing page,\"design, code\",true,1800,10\n"+
+		"\"Review \"\"Q1\"\" <notes>\",,false,60,0\n", string(encode(FormatCSV)))
+
+	assert.Equal(t, `{"title":"Landing page","tags":["design","code"],"billable":true,"duration":1800,"amount":10}`+"\n"+
+		`{"title":"Review \"Q1\" \u003cnotes\u003e","tags":[],"billable":false,"duration":60,"amount":0}`+"\n", string(encode(FormatNDJSON)))
+
+	data := encode(FormatXLSX)
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	var sheet string
+	for _, f := range zr.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, err := f.Open()
+			assert.NoError(t, err)
+			b, _ := ioutil.ReadAll(rc)
+			sheet = string(b)
+		}
+	}
+	assert.Len(t, zr.File, 5)
+	assert.Equal(t, 3, strings.Count(sheet, "<row>"))
+	assert.Contains(t, sheet, `<t xml:space="preserve">design, code</t>`)
+	assert.Contains(t, sheet, `<c><v>1800</v></c><c><v>10</v></c>`)
+	assert.Contains(t, sheet, `Review &#34;Q1&#34; &lt;notes&gt;`)
+
+	_, err = NewEncoder("pdf", &bytes.Buffer{}, columns)
+	assert.Equal(t, ErrUnknownFormat, err)
+}
@@ -0,0 +1,40 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+// ndjsonEncoder writes one json object per line, keys follow the order of the columns
+type ndjsonEncoder struct {
+	w       *bufio.Writer
+	columns []string
+}
+
+func newNDJSONEncoder(w io.Writer, columns []string) *ndjsonEncoder {
+	return &ndjsonEncoder{w: bufio.NewWriter(w), columns: columns}
+}
+
+func (e *ndjsonEncoder) Write(values []interface{}) error {
+	e.w.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			e.w.WriteByte(',')
+		}
+		key, _ := json.Marshal(e.columns[i])
+		value, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		e.w.Write(key)
+		e.w.WriteByte(':')
+		e.w.Write(value)
+	}
+	_, err := e.w.WriteString("}\n")
+	return err
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return e.w.Flush()
+}
@@ -0,0 +1,272 @@
+package ical
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	dateTimeLayout = "20060102T150405"
+	dateLayout     = "20060102"
+	// lines longer than this many octets are folded
+	maxLineLength = 75
+)
+
+var ErrInvalidCalendar = errors.New("file is not an icalendar file")
+
+// Event is a VEVENT of a calendar
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Categories  []string
+	Start       int64
+	End         int64
+}
+
+// Hash identifies the event across uploads, uploading the same calendar again yields the same hashes
+func (e Event) Hash() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "ics\x00%s\x00%d\x00%d", e.UID, e.Start, e.End)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Writer writes events as an icalendar file
+type Writer struct {
+	w   *bufio.Writer
+	now time.Time
+}
+
+// NewWriter writes the header of a calendar to w
+func NewWriter(w io.Writer, name string) *Writer {
+	cw := &Writer{w: bufio.NewWriter(w), now: time.Now().UTC()}
+	cw.line("BEGIN:VCALENDAR")
+	cw.line("VERSION:2.0")
+	cw.line("PRODID:-//time-tracker//sessions//EN")
+	cw.line("CALSCALE:GREGORIAN")
+	cw.line("X-WR-CALNAME:" + escape(name))
+	return cw
+}
+
+// Write writes an event, times are written in UTC
+func (cw *Writer) Write(e Event) error {
+	cw.line("BEGIN:VEVENT")
+	cw.line("UID:" + escape(e.UID))
+	cw.line("DTSTAMP:" + cw.now.Format(dateTimeLayout) + "Z")
+	cw.line("DTSTART:" + time.Unix(e.Start, 0).UTC().Format(dateTimeLayout) + "Z")
+	cw.line("DTEND:" + time.Unix(e.End, 0).UTC().Format(dateTimeLayout) + "Z")
+	cw.line("SUMMARY:" + escape(e.Summary))
+	if e.Description != "" {
+		cw.line("DESCRIPTION:" + escape(e.Description))
+	}
+	if len(e.Categories) > 0 {
+		categories := make([]string, len(e.Categories))
+		for i, c := range e.Categories {
+			categories[i] = escape(c)
+		}
+		cw.line("CATEGORIES:" + strings.Join(categories, ","))
+	}
+	return cw.line("END:VEVENT")
+}
+
+// Close writes the end of the calendar and flushes the writer
+func (cw *Writer) Close() error {
+	cw.line("END:VCALENDAR")
+	return cw.w.Flush()
+}
+
+// line writes a content line folded at 75 octets without splitting utf-8 characters,
+// the leading space of a continuation line counts towards its length
+func (cw *Writer) line(s string) error {
+	limit := maxLineLength
+	for len(s) > limit {
+		cut := limit
+		for !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		cw.w.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		limit = maxLineLength - 1
+	}
+	_, err := cw.w.WriteString(s + "\r\n")
+	return err
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+var unescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// Parse reads the events of an icalendar file. Times without a zone are read in loc,
+// all day events and events without an end or duration are skipped
+func Parse(r io.Reader, loc *time.Location) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || !strings.EqualFold(lines[0], "BEGIN:VCALENDAR") {
+		return nil, ErrInvalidCalendar
+	}
+
+	var events []Event
+	var event *Event
+	var allDay bool
+	var duration time.Duration
+	for _, line := range lines {
+		name, params, value := split(line)
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			event, allDay, duration = &Event{}, false, 0
+		case event == nil:
+			continue
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if event.End == 0 && duration > 0 {
+				event.End = event.Start + int64(duration/time.Second)
+			}
+			if !allDay && event.Start != 0 && event.End > event.Start {
+				events = append(events, *event)
+			}
+			event = nil
+		case name == "UID":
+			event.UID = value
+		case name == "SUMMARY":
+			event.Summary = unescaper.Replace(value)
+		case name == "DESCRIPTION":
+			event.Description = unescaper.Replace(value)
+		case name == "CATEGORIES":
+			for _, c := range splitList(value) {
+				event.Categories = append(event.Categories, unescaper.Replace(c))
+			}
+		case name == "DTSTART", name == "DTEND":
+			t, isDate, err := parseTime(value, params, loc)
+			if err != nil {
+				return nil, err
+			}
+			allDay = allDay || isDate
+			if name == "DTSTART" {
+				event.Start = t
+			} else {
+				event.End = t
+			}
+		case name == "DURATION":
+			if duration, err = parseDuration(value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return events, nil
+}
+
+// unfold joins folded content lines
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// split splits a content line into its upper cased name, its parameters and its value
+func split(line string) (string, map[string]string, string) {
+	colon := strings.Index(line, ":")
+	if colon == -1 {
+		return strings.ToUpper(line), nil, ""
+	}
+	parts := strings.Split(line[:colon], ";")
+	params := map[string]string{}
+	for _, p := range parts[1:] {
+		if eq := strings.Index(p, "="); eq != -1 {
+			params[strings.ToUpper(p[:eq])] = strings.Trim(p[eq+1:], `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, line[colon+1:]
+}
+
+// splitList splits a comma separated value on unescaped commas
+func splitList(value string) []string {
+	var list []string
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			list = append(list, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(list, value[start:])
+}
+
+func parseTime(value string, params map[string]string, loc *time.Location) (int64, bool, error) {
+	if params["VALUE"] == "DATE" || len(value) == len(dateLayout) {
+		t, err := time.ParseInLocation(dateLayout, value, loc)
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid date %q", value)
+		}
+		return t.Unix(), true, nil
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(dateTimeLayout, strings.TrimSuffix(value, "Z"))
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid date time %q", value)
+		}
+		return t.Unix(), false, nil
+	}
+	if tzid := params["TZID"]; tzid != "" {
+		if tz, err := time.LoadLocation(tzid); err == nil {
+			loc = tz
+		}
+	}
+	t, err := time.ParseInLocation(dateTimeLayout, value, loc)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid date time %q", value)
+	}
+	return t.Unix(), false, nil
+}
+
+// parseDuration parses the time part of an icalendar duration such as PT1H30M
+func parseDuration(value string) (time.Duration, error) {
+	s := strings.TrimPrefix(strings.ToUpper(value), "+")
+	if !strings.HasPrefix(s, "P") {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	s = strings.Replace(strings.TrimPrefix(s, "P"), "T", "", 1)
+
+	var total time.Duration
+	units := map[byte]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour, 'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+	n := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= '0' && c <= '9' {
+			n = n*10 + int(c-'0')
+			continue
+		}
+		unit, ok := units[c]
+		if !ok {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		total += time.Duration(n) * unit
+		n = 0
+	}
+	return total, nil
+}
@@ -0,0 +1,58 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	cal := NewWriter(&buf, "Sessions")
+	event := Event{
+		UID:         "sessionId@time-tracker",
+		Summary:     "Design review, part 1; notes",
+		Description: strings.Repeat("é", 60) + "\nProject: Website",
+		Categories:  []string{"design", "a,b"},
+		Start:       1614589200,
+		End:         1614594600,
+	}
+	assert.NoError(t, cal.Write(event))
+	assert.NoError(t, cal.Close())
+
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+	assert.Contains(t, out, "DTSTART:20210301T090000Z\r\n")
+	assert.Contains(t, out, `SUMMARY:Design review\, part 1\; notes`)
+	for _, line := range strings.Split(out, "\r\n") {
+		assert.LessOrEqual(t, len(line), maxLineLength)
+	}
+
+	// the written calendar reads back to the same event
+	events, err := Parse(&buf, time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, []Event{event}, events)
+}
+
+func TestParse(t *testing.T) {
+	loc, _ := time.LoadLocation("Africa/Lagos")
+	calendar := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\nUID:zoned\r\nSUMMARY:Stand\r\n up\r\nDTSTART;TZID=Europe/Berlin:20210301T090000\r\nDTEND;TZID=Europe/Berlin:20210301T091500\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:floating\r\nSUMMARY:Planning\r\nDTSTART:20210301T090000\r\nDURATION:PT1H30M\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:allday\r\nSUMMARY:Holiday\r\nDTSTART;VALUE=DATE:20210302\r\nDTEND;VALUE=DATE:20210303\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	events, err := Parse(strings.NewReader(calendar), loc)
+	assert.NoError(t, err)
+	assert.Equal(t, []Event{
+		{UID: "zoned", Summary: "Standup", Start: 1614585600, End: 1614586500},
+		{UID: "floating", Summary: "Planning", Start: 1614585600, End: 1614591000},
+	}, events)
+
+	_, err = Parse(strings.NewReader("id,title\n"), loc)
+	assert.Equal(t, ErrInvalidCalendar, err)
+}
@@ -0,0 +1,243 @@
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SourceToggl    = "toggl"
+	SourceClockify = "clockify"
+	SourceHarvest  = "harvest"
+)
+
+var ErrUnknownLayout = errors.New("csv layout is not a toggl, clockify or harvest export")
+
+// Entry is a time entry read from an export of another tracker
+type Entry struct {
+	Row         int
+	Title       string
+	Description string
+	Project     string
+	Client      string
+	Tags        []string
+	Billable    bool
+	Start       int64
+	End         int64
+	Duration    int64
+}
+
+// Hash identifies the entry across imports, re-importing the same export yields the same hashes
+func (e Entry) Hash(source string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%d\x00%d\x00%s\x00%s\x00%s", source, e.Start, e.End, e.Title, e.Project, e.Client)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// RowError describes why a row of the csv could not be imported, rows are numbered
+// from the header which is row 1, blank lines are not counted
+type RowError struct {
+	Row     int
+	Message string
+}
+
+// layout maps the columns of an export onto an entry
+type layout struct {
+	source   string
+	required []string
+	parse    func(row map[string]string, loc *time.Location) (Entry, error)
+}
+
+// clockify exports share the columns of toggl exports, so they are detected first
+var layouts = []layout{
+	{source: SourceClockify, required: []string{"description", "start date", "start time", "end date", "end time", "duration (h)"}, parse: parseSpan},
+	{source: SourceToggl, required: []string{"description", "start date", "start time", "end date", "end time"}, parse: parseSpan},
+	{source: SourceHarvest, required: []string{"date", "notes", "hours"}, parse: parseHarvest},
+}
+
+// Parse reads an export of Toggl, Clockify or Harvest. Wall clock times in the export are read in loc.
+// Rows that can not be read are reported as row errors and do not stop the parse
+func Parse(r io.Reader, loc *time.Location) (source string, entries []Entry, rowErrs []RowError, err error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return "", nil, nil, ErrUnknownLayout
+	}
+	if err != nil {
+		return "", nil, nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		// exports written by excel start with a byte order mark
+		name = strings.TrimPrefix(name, "\ufeff")
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	l, ok := detect(columns)
+	if !ok {
+		return "", nil, nil, ErrUnknownLayout
+	}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rowErrs = append(rowErrs, RowError{Row: line, Message: err.Error()})
+			continue
+		}
+
+		row := map[string]string{}
+		empty := true
+		for name, i := range columns {
+			if i < len(record) {
+				row[name] = strings.TrimSpace(record[i])
+				empty = empty && row[name] == ""
+			}
+		}
+		if empty {
+			continue
+		}
+
+		entry, err := l.parse(row, loc)
+		if err == nil && entry.End <= entry.Start {
+			err = errors.New("end must be after start")
+		}
+		if err != nil {
+			rowErrs = append(rowErrs, RowError{Row: line, Message: err.Error()})
+			continue
+		}
+		if entry.Title == "" {
+			entry.Title = "Imported session"
+		}
+		entry.Row = line
+		entries = append(entries, entry)
+	}
+	return l.source, entries, rowErrs, nil
+}
+
+// detect picks the first layout whose required columns are all present
+func detect(columns map[string]int) (layout, bool) {
+	for _, l := range layouts {
+		ok := true
+		for _, name := range l.required {
+			if _, found := columns[name]; !found {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return l, true
+		}
+	}
+	return layout{}, false
+}
+
+// parseSpan reads the entries of exports that record the start and end of each entry
+func parseSpan(row map[string]string, loc *time.Location) (Entry, error) {
+	start, err := parseTime(row["start date"], row["start time"], loc)
+	if err != nil {
+		return Entry{}, err
+	}
+	end, err := parseTime(row["end date"], row["end time"], loc)
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry{
+		Title:    row["description"],
+		Project:  row["project"],
+		Client:   row["client"],
+		Tags:     tags(row["tags"], row["task"]),
+		Billable: isYes(row["billable"]),
+		Start:    start,
+		End:      end,
+		Duration: end - start,
+	}, nil
+}
+
+func parseHarvest(row map[string]string, loc *time.Location) (Entry, error) {
+	// harvest only records the hours of a day, entries are placed at the start of the day
+	day, err := parseDate(row["date"], loc)
+	if err != nil {
+		return Entry{}, err
+	}
+	hours, err := strconv.ParseFloat(strings.Replace(row["hours"], ",", ".", 1), 64)
+	if err != nil || hours <= 0 {
+		return Entry{}, fmt.Errorf("invalid hours %q", row["hours"])
+	}
+	duration := int64(hours*3600 + 0.5)
+
+	title := row["notes"]
+	if title == "" {
+		title = row["task"]
+	}
+	return Entry{
+		Title:    title,
+		Project:  row["project"],
+		Client:   row["client"],
+		Tags:     tags("", row["task"]),
+		Billable: isYes(row["billable?"]),
+		Start:    day.Unix(),
+		End:      day.Unix() + duration,
+		Duration: duration,
+	}, nil
+}
+
+var dateLayouts = []string{"2006-01-02", "01/02/2006", "02.01.2006"}
+
+var timeLayouts = []string{"15:04:05", "15:04", "03:04:05 PM", "03:04 PM", "3:04:05 PM", "3:04 PM"}
+
+func parseDate(value string, loc *time.Location) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+func parseTime(date, clock string, loc *time.Location) (int64, error) {
+	day, err := parseDate(date, loc)
+	if err != nil {
+		return 0, err
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, strings.ToUpper(clock)); err == nil {
+			return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc).Unix(), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid time %q", clock)
+}
+
+// tags splits a comma separated list of tags, the task of an entry is kept as a tag too
+func tags(list, task string) []string {
+	var result []string
+	for _, tag := range strings.Split(list, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			result = append(result, tag)
+		}
+	}
+	if task != "" {
+		result = append(result, task)
+	}
+	return result
+}
+
+func isYes(value string) bool {
+	switch strings.ToLower(value) {
+	case "yes", "true", "1":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Berlin")
+	// 2021-03-01 09:00 in Berlin
+	start := time.Date(2021, 3, 1, 9, 0, 0, 0, loc).Unix()
+
+	var tests = []struct {
+		name     string
+		csv      string
+		source   string
+		expected []Entry
+		rowErrs  []RowError
+	}{
+		{
+			name: "Test toggl export",
+			csv: "\ufeffUser,Email,Client,Project,Task,Description,Billable,Start date,Start time,End date,End time,Duration,Tags\n" +
+				"Ada,ada@email.com,Acme,Website,Design,Landing page,Yes,2021-03-01,09:00:00,2021-03-01,10:30:00,01:30:00,\"ui, review\"\n" +
+				"Ada,ada@email.com,,,,,No,2021-03-01,11:00:00,2021-03-01,10:00:00,00:00:00,\n",
+			source: SourceToggl,
+			expected: []Entry{{
+				Row: 2, Title: "Landing page", Project: "Website", Client: "Acme", Tags: []string{"ui", "review", "Design"},
+				Billable: true, Start: start, End: start + 5400, Duration: 5400,
+			}},
+			rowErrs: []RowError{{Row: 3, Message: "end must be after start"}},
+		},
+		{
+			name: "Test clockify export",
+			csv: "Project,Client,Description,Task,User,Group,Email,Tags,Billable,Start Date,Start Time,End Date,End Time,Duration (h),Duration (decimal)\n" +
+				"Website,Acme,,,Ada,,ada@email.com,ui,Yes,03/01/2021,09:00:00 AM,03/01/2021,01:00:00 PM,04:00:00,4.00\n" +
+				"Website,Acme,Bugs,,Ada,,ada@email.com,,No,03/01/2021,late,03/01/2021,01:00:00 PM,04:00:00,4.00\n",
+			source: SourceClockify,
+			expected: []Entry{{
+				Row: 2, Title: "Imported session", Project: "Website", Client: "Acme", Tags: []string{"ui"},
+				Billable: true, Start: start, End: start + 4*3600, Duration: 4 * 3600,
+			}},
+			rowErrs: []RowError{{Row: 3, Message: `invalid time "late"`}},
+		},
+		{
+			name: "Test harvest export",
+			csv: "Date,Client,Project,Project Code,Task,Notes,Hours,Hours Rounded,Billable?,Invoiced?\n" +
+				"2021-03-01,Acme,Website,,Development,,1.5,1.5,Yes,No\n" +
+				"\n" +
+				"2021-03-02,Acme,Website,,Development,Fixes,-1,0,Yes,No\n",
+			source: SourceHarvest,
+			expected: []Entry{{
+				Row: 2, Title: "Development", Project: "Website", Client: "Acme", Tags: []string{"Development"},
+				Billable: true, Start: start - 9*3600, End: start - 9*3600 + 5400, Duration: 5400,
+			}},
+			rowErrs: []RowError{{Row: 3, Message: `invalid hours "-1"`}},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			source, entries, rowErrs, err := Parse(strings.NewReader(testCase.csv), loc)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.source, source)
+			assert.Equal(t, testCase.expected, entries)
+			assert.Equal(t, testCase.rowErrs, rowErrs)
+		})
+	}
+
+	_, _, _, err := Parse(strings.NewReader("id,title\n1,foo\n"), loc)
+	assert.Equal(t, ErrUnknownLayout, err)
+}
+
+func TestEntry_Hash(t *testing.T) {
+	entry := Entry{Row: 2, Title: "Landing page", Start: 100, End: 200}
+	moved := entry
+	moved.Row = 5
+	assert.Equal(t, entry.Hash(SourceToggl), moved.Hash(SourceToggl))
+
+	moved.End = 300
+	assert.NotEqual(t, entry.Hash(SourceToggl), moved.Hash(SourceToggl))
+	assert.NotEqual(t, entry.Hash(SourceToggl), entry.Hash(SourceHarvest))
+}
@@ -0,0 +1,24 @@
+package mailer
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type logMailer struct {
+	logger *zap.Logger
+}
+
+// NewLog returns a mailer writing mails to the log instead of sending them, for local development
+func NewLog(logger *zap.Logger) Mailer {
+	return logMailer{logger: logger}
+}
+
+func (l logMailer) Send(ctx context.Context, msg Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+	l.logger.Info("mail", zap.String("to", msg.To), zap.String("subject", msg.Subject), zap.String("body", msg.Body))
+	return nil
+}
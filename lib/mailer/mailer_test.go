@@ -0,0 +1,48 @@
+package mailer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestFormat(t *testing.T) {
+	date := time.Date(2021, 3, 1, 9, 30, 0, 0, time.UTC)
+	msg := Message{To: "ada@email.com", Subject: "Réinitialiser", Body: "Hello\nhttps://example.com/reset\n"}
+
+	expected := "From: tracker@email.com\r\n" +
+		"To: ada@email.com\r\n" +
+		"Subject: =?utf-8?q?R=C3=A9initialiser?=\r\n" +
+		"Date: Mon, 01 Mar 2021 09:30:00 +0000\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"Content-Transfer-Encoding: 8bit\r\n" +
+		"\r\n" +
+		"Hello\r\nhttps://example.com/reset\r\n"
+	assert.Equal(t, expected, string(format("tracker@email.com", msg, date)))
+}
+
+func TestSend_InvalidHeader(t *testing.T) {
+	mailers := []Mailer{NewSMTP(SMTPConfig{Host: "localhost", Port: "25"}), NewLog(zap.NewNop())}
+	for _, m := range mailers {
+		err := m.Send(context.Background(), Message{To: "ada@email.com\r\nBcc: eve@email.com", Subject: "Hi"})
+		assert.Equal(t, ErrInvalidHeader, err)
+		err = m.Send(context.Background(), Message{To: "ada@email.com", Subject: "Hi\nBcc: eve@email.com"})
+		assert.Equal(t, ErrInvalidHeader, err)
+	}
+}
+
+func TestLog(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	m := NewLog(zap.New(core))
+
+	assert.NoError(t, m.Send(context.Background(), Message{To: "ada@email.com", Subject: "Hi", Body: "token"}))
+	entries := logs.All()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "ada@email.com", entries[0].ContextMap()["to"])
+	assert.Equal(t, "token", entries[0].ContextMap()["body"])
+}
@@ -0,0 +1,52 @@
+package period
+
+import (
+	"errors"
+	"time"
+)
+
+// Names of the periods sessions can be filtered by
+const (
+	Day   = "day"
+	Week  = "week"
+	Month = "month"
+)
+
+// ErrUnknownPeriod is returned for a period other than day, week or month
+var ErrUnknownPeriod = errors.New("unknown period")
+
+// Bounds returns the [start, end) range of the day, week or month containing now in loc.
+// Boundaries are midnights of the calendar in loc, so a day lasts 23 or 25 hours when the
+// clocks change and a midnight skipped by a DST change becomes the first instant of the day
+func Bounds(name string, now time.Time, loc *time.Location, weekStart time.Weekday) (start, end time.Time, err error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	now = now.In(loc)
+	year, month, day := now.Date()
+
+	switch name {
+	case Day:
+		return midnight(year, month, day, loc), midnight(year, month, day+1, loc), nil
+	case Week:
+		daysSinceWeekStart := (int(now.Weekday()) - int(weekStart) + 7) % 7
+		day -= daysSinceWeekStart
+		return midnight(year, month, day, loc), midnight(year, month, day+7, loc), nil
+	case Month:
+		return midnight(year, month, 1, loc), midnight(year, month+1, 1, loc), nil
+	}
+	return time.Time{}, time.Time{}, ErrUnknownPeriod
+}
+
+// midnight returns the first instant of the day, time.Date normalizes days outside the month
+func midnight(year int, month time.Month, day int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	// when the clocks go forward at midnight time.Date returns an instant of the evening
+	// before, the day then starts when the clocks change
+	if t.Hour() != 0 {
+		_, before := t.Zone()
+		_, after := t.Add(24 * time.Hour).Zone()
+		t = t.Add(time.Duration(after-before) * time.Second)
+	}
+	return t
+}
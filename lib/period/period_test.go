@@ -0,0 +1,169 @@
+package period
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBounds(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+	saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+	assert.NoError(t, err)
+
+	var tests = []struct {
+		name      string
+		period    string
+		now       time.Time
+		loc       *time.Location
+		weekStart time.Weekday
+		start     time.Time
+		end       time.Time
+		hours     float64
+	}{
+		{
+			name:   "Day in UTC",
+			period: Day,
+			now:    time.Date(2021, 6, 16, 15, 30, 0, 0, time.UTC),
+			start:  time.Date(2021, 6, 16, 0, 0, 0, 0, time.UTC),
+			end:    time.Date(2021, 6, 17, 0, 0, 0, 0, time.UTC),
+			hours:  24,
+		},
+		{
+			name:   "Day follows the location and not the instant",
+			period: Day,
+			now:    time.Date(2021, 6, 17, 2, 0, 0, 0, time.UTC),
+			loc:    newYork,
+			start:  time.Date(2021, 6, 16, 0, 0, 0, 0, newYork),
+			end:    time.Date(2021, 6, 17, 0, 0, 0, 0, newYork),
+			hours:  24,
+		},
+		{
+			name:   "Day when the clocks go forward is 23 hours",
+			period: Day,
+			now:    time.Date(2021, 3, 14, 12, 0, 0, 0, newYork),
+			loc:    newYork,
+			start:  time.Date(2021, 3, 14, 0, 0, 0, 0, newYork),
+			end:    time.Date(2021, 3, 15, 0, 0, 0, 0, newYork),
+			hours:  23,
+		},
+		{
+			name:   "Day when the clocks go back is 25 hours",
+			period: Day,
+			now:    time.Date(2021, 11, 7, 12, 0, 0, 0, newYork),
+			loc:    newYork,
+			start:  time.Date(2021, 11, 7, 0, 0, 0, 0, newYork),
+			end:    time.Date(2021, 11, 8, 0, 0, 0, 0, newYork),
+			hours:  25,
+		},
+		{
+			name:   "Day without a midnight starts when the clocks change",
+			period: Day,
+			now:    time.Date(2018, 11, 4, 12, 0, 0, 0, saoPaulo),
+			loc:    saoPaulo,
+			start:  time.Date(2018, 11, 4, 3, 0, 0, 0, time.UTC),
+			end:    time.Date(2018, 11, 5, 0, 0, 0, 0, saoPaulo),
+			hours:  23,
+		},
+		{
+			name:   "Day before a missing midnight ends when the clocks change",
+			period: Day,
+			now:    time.Date(2018, 11, 3, 23, 30, 0, 0, saoPaulo),
+			loc:    saoPaulo,
+			start:  time.Date(2018, 11, 3, 0, 0, 0, 0, saoPaulo),
+			end:    time.Date(2018, 11, 4, 3, 0, 0, 0, time.UTC),
+			hours:  24,
+		},
+		{
+			name:      "Week starting on monday",
+			period:    Week,
+			now:       time.Date(2021, 6, 16, 15, 30, 0, 0, time.UTC),
+			weekStart: time.Monday,
+			start:     time.Date(2021, 6, 14, 0, 0, 0, 0, time.UTC),
+			end:       time.Date(2021, 6, 21, 0, 0, 0, 0, time.UTC),
+			hours:     7 * 24,
+		},
+		{
+			name:      "Week starting on sunday, now is the first day",
+			period:    Week,
+			now:       time.Date(2021, 6, 13, 0, 0, 0, 0, time.UTC),
+			weekStart: time.Sunday,
+			start:     time.Date(2021, 6, 13, 0, 0, 0, 0, time.UTC),
+			end:       time.Date(2021, 6, 20, 0, 0, 0, 0, time.UTC),
+			hours:     7 * 24,
+		},
+		{
+			name:      "Week across a month boundary",
+			period:    Week,
+			now:       time.Date(2021, 7, 1, 9, 0, 0, 0, time.UTC),
+			weekStart: time.Monday,
+			start:     time.Date(2021, 6, 28, 0, 0, 0, 0, time.UTC),
+			end:       time.Date(2021, 7, 5, 0, 0, 0, 0, time.UTC),
+			hours:     7 * 24,
+		},
+		{
+			name:      "Week across a DST change",
+			period:    Week,
+			now:       time.Date(2021, 11, 9, 9, 0, 0, 0, newYork),
+			loc:       newYork,
+			weekStart: time.Monday,
+			start:     time.Date(2021, 11, 8, 0, 0, 0, 0, newYork),
+			end:       time.Date(2021, 11, 15, 0, 0, 0, 0, newYork),
+			hours:     7 * 24,
+		},
+		{
+			name:      "Week containing the DST change",
+			period:    Week,
+			now:       time.Date(2021, 11, 5, 9, 0, 0, 0, newYork),
+			loc:       newYork,
+			weekStart: time.Monday,
+			start:     time.Date(2021, 11, 1, 0, 0, 0, 0, newYork),
+			end:       time.Date(2021, 11, 8, 0, 0, 0, 0, newYork),
+			hours:     7*24 + 1,
+		},
+		{
+			name:   "Month starts on the first day",
+			period: Month,
+			now:    time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+			start:  time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+			end:    time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC),
+			hours:  30 * 24,
+		},
+		{
+			name:   "Month across a year boundary",
+			period: Month,
+			now:    time.Date(2021, 12, 31, 23, 59, 59, 0, time.UTC),
+			start:  time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC),
+			end:    time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+			hours:  31 * 24,
+		},
+		{
+			name:   "Month containing a DST change",
+			period: Month,
+			now:    time.Date(2021, 3, 20, 9, 0, 0, 0, newYork),
+			loc:    newYork,
+			start:  time.Date(2021, 3, 1, 0, 0, 0, 0, newYork),
+			end:    time.Date(2021, 4, 1, 0, 0, 0, 0, newYork),
+			hours:  31*24 - 1,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			start, end, err := Bounds(testCase.period, testCase.now, testCase.loc, testCase.weekStart)
+			assert.NoError(t, err)
+			assert.True(t, testCase.start.Equal(start), "start %s", start)
+			assert.True(t, testCase.end.Equal(end), "end %s", end)
+			assert.Equal(t, testCase.hours, end.Sub(start).Hours())
+			assert.False(t, testCase.now.Before(start))
+			assert.True(t, testCase.now.Before(end))
+		})
+	}
+}
+
+func TestBounds_UnknownPeriod(t *testing.T) {
+	_, _, err := Bounds("year", time.Now(), time.UTC, time.Monday)
+	assert.Equal(t, ErrUnknownPeriod, err)
+}
@@ -0,0 +1,146 @@
+package report
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/victor-nach/time-tracker/lib/billing"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+// Bucket is a node of a report, its totals cover every session in its child buckets
+type Bucket struct {
+	Key          string
+	Duration     int64
+	SessionCount int64
+	Amount       float64
+	Buckets      []*Bucket
+}
+
+// Build nests the rows of a report into a tree of buckets ordered by key,
+// the returned root bucket holds the totals of the report
+func Build(rows []*models.ReportRow) *Bucket {
+	root := &Bucket{}
+	children := map[*Bucket]map[string]*Bucket{}
+
+	sorted := make([]*models.ReportRow, len(rows))
+	copy(sorted, rows)
+	// parents are created before their children
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].Keys) < len(sorted[j].Keys)
+	})
+
+	for _, row := range sorted {
+		bucket := root
+		for _, key := range row.Keys {
+			if children[bucket] == nil {
+				children[bucket] = map[string]*Bucket{}
+			}
+			child, ok := children[bucket][key]
+			if !ok {
+				child = &Bucket{Key: key}
+				children[bucket][key] = child
+				bucket.Buckets = append(bucket.Buckets, child)
+			}
+			bucket = child
+		}
+		bucket.Duration = row.Duration
+		bucket.SessionCount = row.SessionCount
+		bucket.Amount = row.Amount
+	}
+
+	sortBuckets(root)
+	return root
+}
+
+func sortBuckets(bucket *Bucket) {
+	sort.Slice(bucket.Buckets, func(i, j int) bool {
+		return bucket.Buckets[i].Key < bucket.Buckets[j].Key
+	})
+	for _, child := range bucket.Buckets {
+		sortBuckets(child)
+	}
+}
+
+// Rows aggregates sessions into the rows of a report for stores that can not aggregate in
+// their query language. Sessions are expected to be already filtered to the range of the query,
+// amounts are rounded per session like billing.Calculator does
+func Rows(sessions []*models.Session, query models.ReportQuery, calc *billing.Calculator, projects []*models.Project) []*models.ReportRow {
+	loc := query.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	byID := map[string]*models.Project{}
+	for _, p := range projects {
+		byID[p.ID] = p
+	}
+
+	rows := map[string]*models.ReportRow{}
+	var order []string
+	add := func(keys []string, s *models.Session, amount float64) {
+		id := strings.Join(keys, "\x00") + "\x00" + strconv.Itoa(len(keys))
+		row, ok := rows[id]
+		if !ok {
+			row = &models.ReportRow{Keys: append([]string{}, keys...)}
+			rows[id] = row
+			order = append(order, id)
+		}
+		row.Duration += s.Duration
+		row.SessionCount++
+		row.Amount += amount
+	}
+
+	for _, s := range sessions {
+		amount := calc.Amount(s)
+		start := time.Unix(s.Start, 0).In(loc)
+
+		// every combination of keys of the session, a session with several tags falls in several buckets
+		combos := [][]string{{}}
+		add([]string{}, s, amount)
+		for _, group := range query.GroupBy {
+			var values []string
+			switch group {
+			case models.ReportGroupDay:
+				values = []string{start.Format("2006-01-02")}
+			case models.ReportGroupWeek:
+				daysSinceWeekStart := (int(start.Weekday()) - int(query.WeekStart) + 7) % 7
+				values = []string{time.Date(start.Year(), start.Month(), start.Day()-daysSinceWeekStart, 0, 0, 0, 0, loc).Format("2006-01-02")}
+			case models.ReportGroupMonth:
+				values = []string{start.Format("2006-01")}
+			case models.ReportGroupProject:
+				values = []string{s.ProjectID}
+			case models.ReportGroupClient:
+				// the client the session is billed to, which the project may have left since
+				var client string
+				if p, ok := byID[s.ProjectID]; ok {
+					client = billing.ClientAt(p, s.Start)
+				}
+				values = []string{client}
+			case models.ReportGroupTag:
+				values = s.Tags
+				if len(values) == 0 {
+					values = []string{""}
+				}
+			}
+
+			var next [][]string
+			for _, combo := range combos {
+				for _, value := range values {
+					keys := append(append([]string{}, combo...), value)
+					add(keys, s, amount)
+					next = append(next, keys)
+				}
+			}
+			combos = next
+		}
+	}
+
+	result := make([]*models.ReportRow, len(order))
+	for i, id := range order {
+		rows[id].Amount = billing.Round(rows[id].Amount)
+		result[i] = rows[id]
+	}
+	return result
+}
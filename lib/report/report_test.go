@@ -0,0 +1,96 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-nach/time-tracker/lib/billing"
+	"github.com/victor-nach/time-tracker/models"
+)
+
+func TestBuild(t *testing.T) {
+	rows := []*models.ReportRow{
+		{Keys: []string{"2021-06-02", "design"}, Duration: 60, SessionCount: 1, Amount: 1},
+		{Keys: []string{"2021-06-01", "code"}, Duration: 120, SessionCount: 2, Amount: 2},
+		{Keys: []string{"2021-06-01", "design"}, Duration: 30, SessionCount: 1},
+		{Keys: []string{"2021-06-02"}, Duration: 60, SessionCount: 1, Amount: 1},
+		{Keys: []string{"2021-06-01"}, Duration: 120, SessionCount: 2, Amount: 2},
+		{Duration: 180, SessionCount: 3, Amount: 3},
+	}
+
+	expected := &Bucket{
+		Duration:     180,
+		SessionCount: 3,
+		Amount:       3,
+		Buckets: []*Bucket{
+			{
+				Key:          "2021-06-01",
+				Duration:     120,
+				SessionCount: 2,
+				Amount:       2,
+				Buckets: []*Bucket{
+					{Key: "code", Duration: 120, SessionCount: 2, Amount: 2},
+					{Key: "design", Duration: 30, SessionCount: 1},
+				},
+			},
+			{
+				Key:          "2021-06-02",
+				Duration:     60,
+				SessionCount: 1,
+				Amount:       1,
+				Buckets: []*Bucket{
+					{Key: "design", Duration: 60, SessionCount: 1, Amount: 1},
+				},
+			},
+		},
+	}
+	assert.Equal(t, expected, Build(rows))
+}
+
+func TestBuild_Empty(t *testing.T) {
+	assert.Equal(t, &Bucket{}, Build(nil))
+}
+
+func TestRows(t *testing.T) {
+	projects := []*models.Project{{ID: "website", ClientID: "acme"}, {ID: "blog"}}
+	calc := billing.NewCalculator([]*models.Rate{{Scope: models.RateScopeUser, HourlyRate: 10}}, projects)
+	sessions := []*models.Session{
+		// wednesday 2021-06-02 and sunday 2021-06-06 in UTC
+		{Start: 1622628000, Duration: 3600, ProjectID: "website", Billable: true},
+		{Start: 1622973600, Duration: 1800, ProjectID: "blog", Billable: true},
+		{Start: 1622973600, Duration: 600},
+	}
+
+	rows := Rows(sessions, models.ReportQuery{
+		GroupBy:   []string{models.ReportGroupWeek, models.ReportGroupClient},
+		WeekStart: time.Monday,
+	}, calc, projects)
+
+	assert.ElementsMatch(t, []*models.ReportRow{
+		{Keys: []string{}, Duration: 6000, SessionCount: 3, Amount: 15},
+		{Keys: []string{"2021-05-31"}, Duration: 6000, SessionCount: 3, Amount: 15},
+		{Keys: []string{"2021-05-31", "acme"}, Duration: 3600, SessionCount: 1, Amount: 10},
+		{Keys: []string{"2021-05-31", ""}, Duration: 2400, SessionCount: 2, Amount: 5},
+	}, rows)
+}
+
+func TestRows_MovedProject(t *testing.T) {
+	projects := []*models.Project{{ID: "website", ClientID: "globex", ClientHistory: []models.ClientLink{{ClientID: "acme", Until: 2000}}}}
+	calc := billing.NewCalculator([]*models.Rate{
+		{Scope: models.RateScopeClient, ScopeID: "acme", HourlyRate: 10},
+		{Scope: models.RateScopeClient, ScopeID: "globex", HourlyRate: 20},
+	}, projects)
+	sessions := []*models.Session{
+		{Start: 1000, Duration: 3600, ProjectID: "website", Billable: true},
+		{Start: 3000, Duration: 3600, ProjectID: "website", Billable: true},
+	}
+
+	// the hours before the move stay with the client they are billed to
+	rows := Rows(sessions, models.ReportQuery{GroupBy: []string{models.ReportGroupClient}}, calc, projects)
+	assert.ElementsMatch(t, []*models.ReportRow{
+		{Keys: []string{}, Duration: 7200, SessionCount: 2, Amount: 30},
+		{Keys: []string{"acme"}, Duration: 3600, SessionCount: 1, Amount: 10},
+		{Keys: []string{"globex"}, Duration: 3600, SessionCount: 1, Amount: 20},
+	}, rows)
+}
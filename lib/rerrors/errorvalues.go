@@ -1,8 +1,10 @@
 package rerrors
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 )
 
 const (
@@ -13,6 +15,21 @@ const (
 	CustomerNotFoundErr = 105
 	SessionNotFoundErr  = 106
 	EmailExistsError    = 107
+	TimerRunningErr     = 108
+	NoRunningTimerErr   = 109
+	TimerPausedErr      = 110
+	TimerNotPausedErr   = 111
+	ProjectNotFoundErr  = 112
+	ProjectInUseErr     = 113
+	ClientNotFoundErr   = 114
+	ClientInUseErr      = 115
+	ImportFormatErr     = 116
+	SessionOverlapErr   = 117
+	ValidationErr       = 118
+	DeviceNotFoundErr   = 119
+	InvalidTokenErr     = 120
+	EmailNotVerifiedErr = 121
+	EmailVerifiedErr    = 122
 )
 
 var (
@@ -26,6 +43,21 @@ var (
 		CustomerNotFoundErr: "CustomerNotFoundErr",
 		SessionNotFoundErr:  "SessionNotFoundErr",
 		EmailExistsError:    "EmailExistsError",
+		TimerRunningErr:     "TimerRunningErr",
+		NoRunningTimerErr:   "NoRunningTimerErr",
+		TimerPausedErr:      "TimerPausedErr",
+		TimerNotPausedErr:   "TimerNotPausedErr",
+		ProjectNotFoundErr:  "ProjectNotFoundErr",
+		ProjectInUseErr:     "ProjectInUseErr",
+		ClientNotFoundErr:   "ClientNotFoundErr",
+		ClientInUseErr:      "ClientInUseErr",
+		ImportFormatErr:     "ImportFormatErr",
+		SessionOverlapErr:   "SessionOverlapErr",
+		ValidationErr:       "ValidationErr",
+		DeviceNotFoundErr:   "DeviceNotFoundErr",
+		InvalidTokenErr:     "InvalidTokenErr",
+		EmailNotVerifiedErr: "EmailNotVerifiedErr",
+		EmailVerifiedErr:    "EmailVerifiedErr",
 	}
 
 	errMessages = map[int]string{
@@ -36,6 +68,21 @@ var (
 		CustomerNotFoundErr: "invalid customer id",
 		SessionNotFoundErr:  "invalid session id",
 		EmailExistsError:    "Dear user, this email already exists, please use a different email address",
+		TimerRunningErr:     "a timer is already running, please stop it before starting a new one",
+		NoRunningTimerErr:   "there is no running timer",
+		TimerPausedErr:      "the timer is already paused",
+		TimerNotPausedErr:   "the timer is not paused",
+		ProjectNotFoundErr:  "invalid project id",
+		ProjectInUseErr:     "this project still has sessions, archive it instead",
+		ClientNotFoundErr:   "invalid client id",
+		ClientInUseErr:      "this client still has projects, archive it instead",
+		ImportFormatErr:     "the file is not a supported toggl, clockify or harvest csv export",
+		SessionOverlapErr:   "this session overlaps sessions you already saved",
+		ValidationErr:       "some fields of the request are invalid",
+		DeviceNotFoundErr:   "invalid device id",
+		InvalidTokenErr:     "this link is invalid or has expired, please request a new one",
+		EmailNotVerifiedErr: "please verify your email to make changes, check your inbox for the verification link",
+		EmailVerifiedErr:    "this email is already verified",
 	}
 
 	errDetails = map[int]string{
@@ -46,6 +93,21 @@ var (
 		CustomerNotFoundErr: "invalid customer id",
 		SessionNotFoundErr:  "invalid session id",
 		EmailExistsError:    "Duplicate Email found",
+		TimerRunningErr:     "timer already running",
+		NoRunningTimerErr:   "no running timer",
+		TimerPausedErr:      "timer already paused",
+		TimerNotPausedErr:   "timer not paused",
+		ProjectNotFoundErr:  "invalid project id",
+		ProjectInUseErr:     "project referenced by sessions",
+		ClientNotFoundErr:   "invalid client id",
+		ClientInUseErr:      "client referenced by projects",
+		ImportFormatErr:     "unsupported import file",
+		SessionOverlapErr:   "session overlap",
+		ValidationErr:       "invalid fields",
+		DeviceNotFoundErr:   "invalid device id",
+		InvalidTokenErr:     "invalid or expired token",
+		EmailNotVerifiedErr: "email not verified",
+		EmailVerifiedErr:    "email already verified",
 	}
 )
 
@@ -91,3 +153,14 @@ func LogFormat(code int, err error) error {
 	log.Println(e)
 	return e
 }
+
+// FormatFields returns a formatted error listing the invalid fields of a request
+func FormatFields(code int, fields []FieldError) error {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.Field
+	}
+	e := Form(code, errors.New(strings.Join(names, ", ")))
+	e.Fields = fields
+	return e
+}
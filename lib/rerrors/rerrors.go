@@ -11,6 +11,13 @@ type Err struct {
 	ErrorType string
 	Message   string
 	Detail    string
+	Fields    []FieldError `json:",omitempty"`
+}
+
+// FieldError names an invalid field of a request and why it was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 // Ensure Customized error type implements error interface
@@ -34,6 +41,7 @@ func (e *Err) Error() string {
 		ErrorType: e.ErrorType,
 		Message:   e.Message,
 		Detail:    e.Detail,
+		Fields:    e.Fields,
 	}
 	b, _ := json.Marshal(err)
 
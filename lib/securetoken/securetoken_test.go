@@ -0,0 +1,19 @@
+package securetoken
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	first, err := Generate()
+	assert.NoError(t, err)
+	second, err := Generate()
+	assert.NoError(t, err)
+
+	assert.Len(t, first, 43)
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, Hash(first), Hash(first))
+	assert.NotEqual(t, Hash(first), Hash(second))
+}
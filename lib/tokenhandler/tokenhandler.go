@@ -12,19 +12,32 @@ const (
 	RefreshTokenDuration = 48 * time.Hour
 )
 
+// Values of the token_use claim, a token is only accepted where its use is expected
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
+)
+
 var (
 	ErrInvalidSigningMethod = errors.New("invalid token signing method")
 	ErrInvalidToken         = errors.New("invalid token")
+	ErrWrongTokenUse        = errors.New("token can not be used here")
 )
 
 type Claims struct {
-	UserId string `json:"user_id"`
+	UserId   string `json:"user_id"`
+	TokenUse string `json:"token_use"`
+	// Generation is the token generation of the user when an access token was issued
+	Generation int64 `json:"gen,omitempty"`
+	// SessionId is the login session an access token was issued to
+	SessionId string `json:"sid,omitempty"`
 	jwt.StandardClaims
 }
 
 type TokenHandler interface {
-	ValidateToken(token string) (*Claims, error)
-	NewToken(userId string, expirationTime time.Time) (string, error)
+	ValidateToken(token, use string) (*Claims, error)
+	NewToken(userId, sessionId string, generation int64, expirationTime time.Time) (string, error)
+	NewRefreshToken(userId, tokenId string, expirationTime time.Time) (string, error)
 }
 
 type tokenHandler struct {
@@ -40,22 +53,40 @@ func New(secret string) TokenHandler {
 	}
 }
 
-//NewToken ...
-func (t *tokenHandler) NewToken(userId string, expirationTime time.Time) (string, error) {
-	claims := &Claims{
-		UserId: userId,
+// NewToken returns an access token of the user, it is valid while the user's token generation is unchanged
+func (t *tokenHandler) NewToken(userId, sessionId string, generation int64, expirationTime time.Time) (string, error) {
+	return t.sign(&Claims{
+		UserId:     userId,
+		TokenUse:   AccessToken,
+		Generation: generation,
+		SessionId:  sessionId,
 		StandardClaims: jwt.StandardClaims{
 			IssuedAt:  time.Now().Unix(),
 			ExpiresAt: expirationTime.Unix(),
 		},
-	}
+	})
+}
+
+// NewRefreshToken returns a refresh token of the user, tokenId is the id of its stored record
+func (t *tokenHandler) NewRefreshToken(userId, tokenId string, expirationTime time.Time) (string, error) {
+	return t.sign(&Claims{
+		UserId:   userId,
+		TokenUse: RefreshToken,
+		StandardClaims: jwt.StandardClaims{
+			Id:        tokenId,
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: expirationTime.Unix(),
+		},
+	})
+}
+
+func (t *tokenHandler) sign(claims *Claims) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(t.jwtSecret))
-	return tokenString, err
+	return token.SignedString([]byte(t.jwtSecret))
 }
 
-//ValidateToken ...
-func (t *tokenHandler) ValidateToken(tokenString string) (*Claims, error) {
+// ValidateToken checks the signature and expiry of the token and that it was issued for use
+func (t *tokenHandler) ValidateToken(tokenString, use string) (*Claims, error) {
 	claims := &Claims{}
 	keyFunc := func(token *jwt.Token) (i interface{}, e error) {
 		if token.Method != jwt.SigningMethodHS256 {
@@ -72,7 +103,15 @@ func (t *tokenHandler) ValidateToken(tokenString string) (*Claims, error) {
 	if !token.Valid {
 		return nil, ErrInvalidToken
 	}
+	// tokens issued before token_use was added have no use and are rejected everywhere
+	if claims.TokenUse != use {
+		return nil, ErrWrongTokenUse
+	}
 	return &Claims{
-		UserId: claims.UserId,
+		UserId:         claims.UserId,
+		TokenUse:       claims.TokenUse,
+		Generation:     claims.Generation,
+		SessionId:      claims.SessionId,
+		StandardClaims: jwt.StandardClaims{Id: claims.Id},
 	}, nil
 }
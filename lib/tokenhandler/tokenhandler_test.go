@@ -1 +1,38 @@
 package tokenhandler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenHandler_TokenUse(t *testing.T) {
+	handler := New("secret")
+	expiry := time.Now().Add(time.Hour)
+
+	access, err := handler.NewToken("userId", "sessionId", 3, expiry)
+	assert.NoError(t, err)
+	refresh, err := handler.NewRefreshToken("userId", "tokenId", expiry)
+	assert.NoError(t, err)
+
+	claims, err := handler.ValidateToken(access, AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "userId", claims.UserId)
+	assert.Equal(t, int64(3), claims.Generation)
+	assert.Equal(t, "sessionId", claims.SessionId)
+
+	claims, err = handler.ValidateToken(refresh, RefreshToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "userId", claims.UserId)
+	assert.Equal(t, "tokenId", claims.Id)
+
+	// an access token can not be refreshed and a refresh token does not authenticate requests
+	_, err = handler.ValidateToken(access, RefreshToken)
+	assert.Equal(t, ErrWrongTokenUse, err)
+	_, err = handler.ValidateToken(refresh, AccessToken)
+	assert.Equal(t, ErrWrongTokenUse, err)
+
+	_, err = New("other secret").ValidateToken(access, AccessToken)
+	assert.Error(t, err)
+}
@@ -0,0 +1,43 @@
+package validation
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+)
+
+// Errors collects the invalid fields of a request so they can be reported together
+type Errors struct {
+	fields []rerrors.FieldError
+}
+
+// Add records that field is invalid
+func (e *Errors) Add(field, message string) {
+	e.fields = append(e.fields, rerrors.FieldError{Field: field, Message: message})
+}
+
+// Check records that field is invalid unless ok holds
+func (e *Errors) Check(ok bool, field, message string) {
+	if !ok {
+		e.Add(field, message)
+	}
+}
+
+// MaxLength records that field is invalid when value has more than max characters
+func (e *Errors) MaxLength(field, value string, max int) {
+	e.Check(utf8.RuneCountInString(value) <= max, field, fmt.Sprintf("must be at most %d characters", max))
+}
+
+// Fields returns the invalid fields in the order they were added
+func (e *Errors) Fields() []rerrors.FieldError {
+	return e.fields
+}
+
+// Err returns a ValidationErr listing the invalid fields, or nil when every field is valid
+func (e *Errors) Err() error {
+	if len(e.fields) == 0 {
+		return nil
+	}
+	return rerrors.FormatFields(rerrors.ValidationErr, e.fields)
+}
@@ -1,15 +1,25 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/victor-nach/time-tracker/config"
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/db/bolt"
+	"github.com/victor-nach/time-tracker/db/memory"
 	"github.com/victor-nach/time-tracker/db/mongo"
+	"github.com/victor-nach/time-tracker/db/postgres"
+	"github.com/victor-nach/time-tracker/lib/mailer"
 	"github.com/victor-nach/time-tracker/server"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
+	"time"
 
+	"go.etcd.io/bbolt"
 	"go.uber.org/zap"
 )
 
@@ -21,12 +31,71 @@ func main() {
 		log.Fatalf("failed to start logger: %v", err)
 	}
 
-	mongoStore, _, err := mongo.New(cfg.DBURL, cfg.DBName)
+	var dataStore db.Datastore
+	switch cfg.DBDriver {
+	case config.DriverMemory:
+		logger.Warn("using the in-memory datastore, data is lost when the service stops")
+		dataStore = memory.New()
+	case config.DriverMongo:
+		dataStore, _, err = mongo.New(cfg.DBURL, cfg.DBName)
+		if err != nil {
+			log.Fatalf("failed to open mongodb: %v", err)
+		}
+	case config.DriverPostgres:
+		dataStore, _, err = postgres.New(cfg.DBURL)
+		if err != nil {
+			log.Fatalf("failed to open postgres: %v", err)
+		}
+	case config.DriverBolt:
+		var conn *bbolt.DB
+		dataStore, conn, err = bolt.New(cfg.DataDir)
+		if err != nil {
+			log.Fatalf("failed to open %s: %v", filepath.Join(cfg.DataDir, bolt.FileName), err)
+		}
+		if cfg.BackupInterval != "" {
+			interval, err := time.ParseDuration(cfg.BackupInterval)
+			if err != nil {
+				log.Fatalf("invalid BACKUP_INTERVAL %q: %v", cfg.BackupInterval, err)
+			}
+			go snapshotEvery(conn, filepath.Join(cfg.DataDir, snapshotFile), interval, logger)
+		}
+	default:
+		log.Fatalf("unknown DB_DRIVER %q", cfg.DBDriver)
+	}
+
+	timeouts, err := db.ParseTimeouts(cfg.DBTimeout, cfg.DBOperationTimeouts)
+	if err != nil {
+		log.Fatalf("invalid datastore timeouts: %v", err)
+	}
+	dataStore = db.WithTimeouts(dataStore, timeouts)
+
+	authCacheTTL, err := time.ParseDuration(cfg.AuthCacheTTL)
 	if err != nil {
-		log.Fatalf("failed to open mongodb: %v", err)
+		log.Fatalf("invalid AUTH_CACHE_TTL %q: %v", cfg.AuthCacheTTL, err)
 	}
+	dataStore = db.WithAuthCache(dataStore, authCacheTTL)
 
-	srv := server.NewServer(mongoStore, cfg, logger)
+	var mail mailer.Mailer
+	switch cfg.Mailer {
+	case config.MailerLog:
+		mail = mailer.NewLog(logger)
+	case config.MailerSMTP:
+		mail = mailer.NewSMTP(mailer.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.MailFrom,
+		})
+	default:
+		log.Fatalf("unknown MAILER %q", cfg.Mailer)
+	}
+
+	if cfg.UnverifiedAccess != config.AccessFull && cfg.UnverifiedAccess != config.AccessReadOnly {
+		log.Fatalf("unknown UNVERIFIED_ACCESS %q", cfg.UnverifiedAccess)
+	}
+
+	srv := server.NewServer(dataStore, mail, cfg, logger)
 
 	// create channel to listen to shutdown signals
 	shutdownChan := make(chan os.Signal, 1)
@@ -35,12 +104,34 @@ func main() {
 	go func() {
 		addr := fmt.Sprintf(":%s", cfg.Port)
 		err := srv.Run(addr)
-		if err != nil {
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatal(fmt.Sprintf("failed to start service: %v", err))
 		}
 	}()
 
 	<-shutdownChan
 	log.Println("Closing application")
-	// do cleanups before exit
+	// requests in flight and mails being sent get some time to finish
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Error("shutdown", zap.Error(err))
+	}
+}
+
+// shutdownTimeout is how long the service waits for requests and mails on shutdown
+const shutdownTimeout = 30 * time.Second
+
+// snapshotFile is the name of the snapshot the bolt driver keeps in the data directory
+const snapshotFile = "tracker.snapshot.db"
+
+// snapshotEvery replaces the snapshot of the bolt database at path on every tick of interval
+func snapshotEvery(conn *bbolt.DB, path string, interval time.Duration, logger *zap.Logger) {
+	for range time.Tick(interval) {
+		if err := bolt.Snapshot(conn, path); err != nil {
+			logger.Error("snapshot", zap.Error(err))
+			continue
+		}
+		logger.Info("snapshot", zap.String("path", path))
+	}
 }
@@ -3,7 +3,10 @@
 package mocks
 
 import (
+	context "context"
+
 	mock "github.com/stretchr/testify/mock"
+
 	models "github.com/victor-nach/time-tracker/models"
 )
 
@@ -12,22 +15,52 @@ type Datastore struct {
 	mock.Mock
 }
 
-// CreateSession provides a mock function with given fields: session
-func (_m *Datastore) CreateSession(session *models.Session) (*models.Session, error) {
-	ret := _m.Called(session)
+// ConfirmDrafts provides a mock function with given fields: ctx, owner, ids, policy
+func (_m *Datastore) ConfirmDrafts(ctx context.Context, owner string, ids []string, policy string) (int64, []*models.Session, error) {
+	ret := _m.Called(ctx, owner, ids, policy)
 
-	var r0 *models.Session
-	if rf, ok := ret.Get(0).(func(*models.Session) *models.Session); ok {
-		r0 = rf(session)
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string, string) int64); ok {
+		r0 = rf(ctx, owner, ids, policy)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 []*models.Session
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string, string) []*models.Session); ok {
+		r1 = rf(ctx, owner, ids, policy)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]*models.Session)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, []string, string) error); ok {
+		r2 = rf(ctx, owner, ids, policy)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// CreateClient provides a mock function with given fields: ctx, client
+func (_m *Datastore) CreateClient(ctx context.Context, client *models.Client) (*models.Client, error) {
+	ret := _m.Called(ctx, client)
+
+	var r0 *models.Client
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Client) *models.Client); ok {
+		r0 = rf(ctx, client)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*models.Session)
+			r0 = ret.Get(0).(*models.Client)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*models.Session) error); ok {
-		r1 = rf(session)
+	if rf, ok := ret.Get(1).(func(context.Context, *models.Client) error); ok {
+		r1 = rf(ctx, client)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -35,22 +68,73 @@ func (_m *Datastore) CreateSession(session *models.Session) (*models.Session, er
 	return r0, r1
 }
 
-// CreateUser provides a mock function with given fields: user
-func (_m *Datastore) CreateUser(user *models.User) (*models.User, error) {
-	ret := _m.Called(user)
+// CreateLoginSession provides a mock function with given fields: ctx, session
+func (_m *Datastore) CreateLoginSession(ctx context.Context, session *models.LoginSession) error {
+	ret := _m.Called(ctx, session)
 
-	var r0 *models.User
-	if rf, ok := ret.Get(0).(func(*models.User) *models.User); ok {
-		r0 = rf(user)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.LoginSession) error); ok {
+		r0 = rf(ctx, session)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateOneTimeToken provides a mock function with given fields: ctx, token
+func (_m *Datastore) CreateOneTimeToken(ctx context.Context, token *models.OneTimeToken) error {
+	ret := _m.Called(ctx, token)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.OneTimeToken) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateProject provides a mock function with given fields: ctx, project
+func (_m *Datastore) CreateProject(ctx context.Context, project *models.Project) (*models.Project, error) {
+	ret := _m.Called(ctx, project)
+
+	var r0 *models.Project
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Project) *models.Project); ok {
+		r0 = rf(ctx, project)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*models.User)
+			r0 = ret.Get(0).(*models.Project)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *models.Project) error); ok {
+		r1 = rf(ctx, project)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateRate provides a mock function with given fields: ctx, rate
+func (_m *Datastore) CreateRate(ctx context.Context, rate *models.Rate) (*models.Rate, error) {
+	ret := _m.Called(ctx, rate)
+
+	var r0 *models.Rate
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Rate) *models.Rate); ok {
+		r0 = rf(ctx, rate)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Rate)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*models.User) error); ok {
-		r1 = rf(user)
+	if rf, ok := ret.Get(1).(func(context.Context, *models.Rate) error); ok {
+		r1 = rf(ctx, rate)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -58,13 +142,13 @@ func (_m *Datastore) CreateUser(user *models.User) (*models.User, error) {
 	return r0, r1
 }
 
-// DeleteSession provides a mock function with given fields: id
-func (_m *Datastore) DeleteSession(id string) error {
-	ret := _m.Called(id)
+// CreateRefreshToken provides a mock function with given fields: ctx, token
+func (_m *Datastore) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	ret := _m.Called(ctx, token)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.RefreshToken) error); ok {
+		r0 = rf(ctx, token)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -72,13 +156,13 @@ func (_m *Datastore) DeleteSession(id string) error {
 	return r0
 }
 
-// GetSession provides a mock function with given fields: id, owner
-func (_m *Datastore) GetSession(id string, owner string) (*models.Session, error) {
-	ret := _m.Called(id, owner)
+// CreateSession provides a mock function with given fields: ctx, session
+func (_m *Datastore) CreateSession(ctx context.Context, session *models.Session) (*models.Session, error) {
+	ret := _m.Called(ctx, session)
 
 	var r0 *models.Session
-	if rf, ok := ret.Get(0).(func(string, string) *models.Session); ok {
-		r0 = rf(id, owner)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Session) *models.Session); ok {
+		r0 = rf(ctx, session)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.Session)
@@ -86,8 +170,8 @@ func (_m *Datastore) GetSession(id string, owner string) (*models.Session, error
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(id, owner)
+	if rf, ok := ret.Get(1).(func(context.Context, *models.Session) error); ok {
+		r1 = rf(ctx, session)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -95,13 +179,13 @@ func (_m *Datastore) GetSession(id string, owner string) (*models.Session, error
 	return r0, r1
 }
 
-// GetSessions provides a mock function with given fields: owner, filter
-func (_m *Datastore) GetSessions(owner string, filter string) ([]*models.Session, error) {
-	ret := _m.Called(owner, filter)
+// CreateSessionWithPolicy provides a mock function with given fields: ctx, session, policy
+func (_m *Datastore) CreateSessionWithPolicy(ctx context.Context, session *models.Session, policy string) ([]*models.Session, error) {
+	ret := _m.Called(ctx, session, policy)
 
 	var r0 []*models.Session
-	if rf, ok := ret.Get(0).(func(string, string) []*models.Session); ok {
-		r0 = rf(owner, filter)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Session, string) []*models.Session); ok {
+		r0 = rf(ctx, session, policy)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*models.Session)
@@ -109,8 +193,8 @@ func (_m *Datastore) GetSessions(owner string, filter string) ([]*models.Session
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(owner, filter)
+	if rf, ok := ret.Get(1).(func(context.Context, *models.Session, string) error); ok {
+		r1 = rf(ctx, session, policy)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -118,13 +202,27 @@ func (_m *Datastore) GetSessions(owner string, filter string) ([]*models.Session
 	return r0, r1
 }
 
-// GetUser provides a mock function with given fields: id
-func (_m *Datastore) GetUser(id string) (*models.User, error) {
-	ret := _m.Called(id)
+// CreateSessions provides a mock function with given fields: ctx, sessions
+func (_m *Datastore) CreateSessions(ctx context.Context, sessions []*models.Session) error {
+	ret := _m.Called(ctx, sessions)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*models.Session) error); ok {
+		r0 = rf(ctx, sessions)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateUser provides a mock function with given fields: ctx, user
+func (_m *Datastore) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	ret := _m.Called(ctx, user)
 
 	var r0 *models.User
-	if rf, ok := ret.Get(0).(func(string) *models.User); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, *models.User) *models.User); ok {
+		r0 = rf(ctx, user)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.User)
@@ -132,8 +230,8 @@ func (_m *Datastore) GetUser(id string) (*models.User, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, *models.User) error); ok {
+		r1 = rf(ctx, user)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -141,22 +239,106 @@ func (_m *Datastore) GetUser(id string) (*models.User, error) {
 	return r0, r1
 }
 
-// GetUserByEmail provides a mock function with given fields: email
-func (_m *Datastore) GetUserByEmail(email string) (*models.User, error) {
-	ret := _m.Called(email)
+// DeleteClient provides a mock function with given fields: ctx, id
+func (_m *Datastore) DeleteClient(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
 
-	var r0 *models.User
-	if rf, ok := ret.Get(0).(func(string) *models.User); ok {
-		r0 = rf(email)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteLoginSession provides a mock function with given fields: ctx, id
+func (_m *Datastore) DeleteLoginSession(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteLoginSessions provides a mock function with given fields: ctx, owner
+func (_m *Datastore) DeleteLoginSessions(ctx context.Context, owner string) error {
+	ret := _m.Called(ctx, owner)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, owner)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteOneTimeTokens provides a mock function with given fields: ctx, owner, purpose
+func (_m *Datastore) DeleteOneTimeTokens(ctx context.Context, owner string, purpose string) error {
+	ret := _m.Called(ctx, owner, purpose)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, owner, purpose)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteProject provides a mock function with given fields: ctx, id
+func (_m *Datastore) DeleteProject(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteSession provides a mock function with given fields: ctx, id
+func (_m *Datastore) DeleteSession(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetClient provides a mock function with given fields: ctx, id, owner
+func (_m *Datastore) GetClient(ctx context.Context, id string, owner string) (*models.Client, error) {
+	ret := _m.Called(ctx, id, owner)
+
+	var r0 *models.Client
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *models.Client); ok {
+		r0 = rf(ctx, id, owner)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*models.User)
+			r0 = ret.Get(0).(*models.Client)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(email)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, id, owner)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -164,16 +346,766 @@ func (_m *Datastore) GetUserByEmail(email string) (*models.User, error) {
 	return r0, r1
 }
 
-// UpdateSession provides a mock function with given fields: id, info
-func (_m *Datastore) UpdateSession(id string, info models.SessionInfo) error {
-	ret := _m.Called(id, info)
+// GetClients provides a mock function with given fields: ctx, owner, includeArchived
+func (_m *Datastore) GetClients(ctx context.Context, owner string, includeArchived bool) ([]*models.Client, error) {
+	ret := _m.Called(ctx, owner, includeArchived)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(string, models.SessionInfo) error); ok {
-		r0 = rf(id, info)
+	var r0 []*models.Client
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) []*models.Client); ok {
+		r0 = rf(ctx, owner, includeArchived)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Client)
+		}
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, bool) error); ok {
+		r1 = rf(ctx, owner, includeArchived)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDraftSessions provides a mock function with given fields: ctx, owner
+func (_m *Datastore) GetDraftSessions(ctx context.Context, owner string) ([]*models.Session, error) {
+	ret := _m.Called(ctx, owner)
+
+	var r0 []*models.Session
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*models.Session); ok {
+		r0 = rf(ctx, owner)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Session)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, owner)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetImportedHashes provides a mock function with given fields: ctx, owner, hashes
+func (_m *Datastore) GetImportedHashes(ctx context.Context, owner string, hashes []string) ([]string, error) {
+	ret := _m.Called(ctx, owner, hashes)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) []string); ok {
+		r0 = rf(ctx, owner, hashes)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string) error); ok {
+		r1 = rf(ctx, owner, hashes)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLoginSession provides a mock function with given fields: ctx, id, owner
+func (_m *Datastore) GetLoginSession(ctx context.Context, id string, owner string) (*models.LoginSession, error) {
+	ret := _m.Called(ctx, id, owner)
+
+	var r0 *models.LoginSession
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *models.LoginSession); ok {
+		r0 = rf(ctx, id, owner)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.LoginSession)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, id, owner)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLoginSessions provides a mock function with given fields: ctx, owner
+func (_m *Datastore) GetLoginSessions(ctx context.Context, owner string) ([]*models.LoginSession, error) {
+	ret := _m.Called(ctx, owner)
+
+	var r0 []*models.LoginSession
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*models.LoginSession); ok {
+		r0 = rf(ctx, owner)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.LoginSession)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, owner)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOneTimeToken provides a mock function with given fields: ctx, id
+func (_m *Datastore) GetOneTimeToken(ctx context.Context, id string) (*models.OneTimeToken, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *models.OneTimeToken
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.OneTimeToken); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.OneTimeToken)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOverlaps provides a mock function with given fields: ctx, owner
+func (_m *Datastore) GetOverlaps(ctx context.Context, owner string) ([]*models.Overlap, error) {
+	ret := _m.Called(ctx, owner)
+
+	var r0 []*models.Overlap
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*models.Overlap); ok {
+		r0 = rf(ctx, owner)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Overlap)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, owner)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetProject provides a mock function with given fields: ctx, id, owner
+func (_m *Datastore) GetProject(ctx context.Context, id string, owner string) (*models.Project, error) {
+	ret := _m.Called(ctx, id, owner)
+
+	var r0 *models.Project
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *models.Project); ok {
+		r0 = rf(ctx, id, owner)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Project)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, id, owner)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetProjects provides a mock function with given fields: ctx, owner, includeArchived
+func (_m *Datastore) GetProjects(ctx context.Context, owner string, includeArchived bool) ([]*models.Project, error) {
+	ret := _m.Called(ctx, owner, includeArchived)
+
+	var r0 []*models.Project
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) []*models.Project); ok {
+		r0 = rf(ctx, owner, includeArchived)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Project)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, bool) error); ok {
+		r1 = rf(ctx, owner, includeArchived)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRates provides a mock function with given fields: ctx, owner
+func (_m *Datastore) GetRates(ctx context.Context, owner string) ([]*models.Rate, error) {
+	ret := _m.Called(ctx, owner)
+
+	var r0 []*models.Rate
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*models.Rate); ok {
+		r0 = rf(ctx, owner)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Rate)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, owner)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRefreshToken provides a mock function with given fields: ctx, id
+func (_m *Datastore) GetRefreshToken(ctx context.Context, id string) (*models.RefreshToken, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *models.RefreshToken
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.RefreshToken); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.RefreshToken)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReport provides a mock function with given fields: ctx, owner, query
+func (_m *Datastore) GetReport(ctx context.Context, owner string, query models.ReportQuery) ([]*models.ReportRow, error) {
+	ret := _m.Called(ctx, owner, query)
+
+	var r0 []*models.ReportRow
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.ReportQuery) []*models.ReportRow); ok {
+		r0 = rf(ctx, owner, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.ReportRow)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, models.ReportQuery) error); ok {
+		r1 = rf(ctx, owner, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRunningTimer provides a mock function with given fields: ctx, owner
+func (_m *Datastore) GetRunningTimer(ctx context.Context, owner string) (*models.Session, error) {
+	ret := _m.Called(ctx, owner)
+
+	var r0 *models.Session
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.Session); ok {
+		r0 = rf(ctx, owner)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Session)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, owner)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSession provides a mock function with given fields: ctx, id, owner
+func (_m *Datastore) GetSession(ctx context.Context, id string, owner string) (*models.Session, error) {
+	ret := _m.Called(ctx, id, owner)
+
+	var r0 *models.Session
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *models.Session); ok {
+		r0 = rf(ctx, id, owner)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Session)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, id, owner)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSessions provides a mock function with given fields: ctx, owner, filter
+func (_m *Datastore) GetSessions(ctx context.Context, owner string, filter models.SessionFilter) ([]*models.Session, error) {
+	ret := _m.Called(ctx, owner, filter)
+
+	var r0 []*models.Session
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.SessionFilter) []*models.Session); ok {
+		r0 = rf(ctx, owner, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Session)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, models.SessionFilter) error); ok {
+		r1 = rf(ctx, owner, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSessionsPage provides a mock function with given fields: ctx, owner, filter, page
+func (_m *Datastore) GetSessionsPage(ctx context.Context, owner string, filter models.SessionFilter, page models.Page) (*models.SessionPage, error) {
+	ret := _m.Called(ctx, owner, filter, page)
+
+	var r0 *models.SessionPage
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.SessionFilter, models.Page) *models.SessionPage); ok {
+		r0 = rf(ctx, owner, filter, page)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.SessionPage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, models.SessionFilter, models.Page) error); ok {
+		r1 = rf(ctx, owner, filter, page)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTags provides a mock function with given fields: ctx, owner
+func (_m *Datastore) GetTags(ctx context.Context, owner string) ([]*models.TagCount, error) {
+	ret := _m.Called(ctx, owner)
+
+	var r0 []*models.TagCount
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*models.TagCount); ok {
+		r0 = rf(ctx, owner)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.TagCount)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, owner)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTokenGeneration provides a mock function with given fields: ctx, id
+func (_m *Datastore) GetTokenGeneration(ctx context.Context, id string) (int64, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, string) int64); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUser provides a mock function with given fields: ctx, id
+func (_m *Datastore) GetUser(ctx context.Context, id string) (*models.User, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *models.User
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.User); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserByCalendarToken provides a mock function with given fields: ctx, token
+func (_m *Datastore) GetUserByCalendarToken(ctx context.Context, token string) (*models.User, error) {
+	ret := _m.Called(ctx, token)
+
+	var r0 *models.User
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.User); ok {
+		r0 = rf(ctx, token)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserByEmail provides a mock function with given fields: ctx, email
+func (_m *Datastore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	ret := _m.Called(ctx, email)
+
+	var r0 *models.User
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.User); ok {
+		r0 = rf(ctx, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IncrementTokenGeneration provides a mock function with given fields: ctx, id
+func (_m *Datastore) IncrementTokenGeneration(ctx context.Context, id string) (int64, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, string) int64); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MergeTags provides a mock function with given fields: ctx, owner, tags, into
+func (_m *Datastore) MergeTags(ctx context.Context, owner string, tags []string, into string) (int64, error) {
+	ret := _m.Called(ctx, owner, tags, into)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string, string) int64); ok {
+		r0 = rf(ctx, owner, tags, into)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string, string) error); ok {
+		r1 = rf(ctx, owner, tags, into)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PauseTimer provides a mock function with given fields: ctx, owner, at
+func (_m *Datastore) PauseTimer(ctx context.Context, owner string, at int64) (*models.Session, error) {
+	ret := _m.Called(ctx, owner, at)
+
+	var r0 *models.Session
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) *models.Session); ok {
+		r0 = rf(ctx, owner, at)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Session)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64) error); ok {
+		r1 = rf(ctx, owner, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResumeTimer provides a mock function with given fields: ctx, owner, at
+func (_m *Datastore) ResumeTimer(ctx context.Context, owner string, at int64) (*models.Session, error) {
+	ret := _m.Called(ctx, owner, at)
+
+	var r0 *models.Session
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) *models.Session); ok {
+		r0 = rf(ctx, owner, at)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Session)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64) error); ok {
+		r1 = rf(ctx, owner, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RevokeRefreshFamily provides a mock function with given fields: ctx, family
+func (_m *Datastore) RevokeRefreshFamily(ctx context.Context, family string) error {
+	ret := _m.Called(ctx, family)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, family)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeUserRefreshTokens provides a mock function with given fields: ctx, owner
+func (_m *Datastore) RevokeUserRefreshTokens(ctx context.Context, owner string) error {
+	ret := _m.Called(ctx, owner)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, owner)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RotateRefreshToken provides a mock function with given fields: ctx, id, next
+func (_m *Datastore) RotateRefreshToken(ctx context.Context, id string, next *models.RefreshToken) error {
+	ret := _m.Called(ctx, id, next)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *models.RefreshToken) error); ok {
+		r0 = rf(ctx, id, next)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StartTimer provides a mock function with given fields: ctx, session
+func (_m *Datastore) StartTimer(ctx context.Context, session *models.Session) (*models.Session, error) {
+	ret := _m.Called(ctx, session)
+
+	var r0 *models.Session
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Session) *models.Session); ok {
+		r0 = rf(ctx, session)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Session)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *models.Session) error); ok {
+		r1 = rf(ctx, session)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StopTimer provides a mock function with given fields: ctx, owner, end
+func (_m *Datastore) StopTimer(ctx context.Context, owner string, end int64) (*models.Session, error) {
+	ret := _m.Called(ctx, owner, end)
+
+	var r0 *models.Session
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) *models.Session); ok {
+		r0 = rf(ctx, owner, end)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Session)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64) error); ok {
+		r1 = rf(ctx, owner, end)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StreamSessions provides a mock function with given fields: ctx, owner, filter, fn
+func (_m *Datastore) StreamSessions(ctx context.Context, owner string, filter models.SessionFilter, fn func(*models.Session) error) error {
+	ret := _m.Called(ctx, owner, filter, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.SessionFilter, func(*models.Session) error) error); ok {
+		r0 = rf(ctx, owner, filter, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateClient provides a mock function with given fields: ctx, id, info
+func (_m *Datastore) UpdateClient(ctx context.Context, id string, info models.ClientInfo) error {
+	ret := _m.Called(ctx, id, info)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.ClientInfo) error); ok {
+		r0 = rf(ctx, id, info)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateLoginSession provides a mock function with given fields: ctx, id, info
+func (_m *Datastore) UpdateLoginSession(ctx context.Context, id string, info models.LoginSessionInfo) error {
+	ret := _m.Called(ctx, id, info)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.LoginSessionInfo) error); ok {
+		r0 = rf(ctx, id, info)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateProject provides a mock function with given fields: ctx, id, info
+func (_m *Datastore) UpdateProject(ctx context.Context, id string, info models.ProjectInfo) error {
+	ret := _m.Called(ctx, id, info)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.ProjectInfo) error); ok {
+		r0 = rf(ctx, id, info)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateSession provides a mock function with given fields: ctx, id, info
+func (_m *Datastore) UpdateSession(ctx context.Context, id string, info models.SessionInfo) error {
+	ret := _m.Called(ctx, id, info)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.SessionInfo) error); ok {
+		r0 = rf(ctx, id, info)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateUser provides a mock function with given fields: ctx, id, info
+func (_m *Datastore) UpdateUser(ctx context.Context, id string, info models.UserInfo) error {
+	ret := _m.Called(ctx, id, info)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.UserInfo) error); ok {
+		r0 = rf(ctx, id, info)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UseOneTimeToken provides a mock function with given fields: ctx, id, purpose, now
+func (_m *Datastore) UseOneTimeToken(ctx context.Context, id string, purpose string, now int64) (*models.OneTimeToken, error) {
+	ret := _m.Called(ctx, id, purpose, now)
+
+	var r0 *models.OneTimeToken
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64) *models.OneTimeToken); ok {
+		r0 = rf(ctx, id, purpose, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.OneTimeToken)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int64) error); ok {
+		r1 = rf(ctx, id, purpose, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
@@ -15,20 +15,20 @@ type TokenHandler struct {
 	mock.Mock
 }
 
-// NewToken provides a mock function with given fields: userId, expirationTime
-func (_m *TokenHandler) NewToken(userId string, expirationTime time.Time) (string, error) {
-	ret := _m.Called(userId, expirationTime)
+// NewRefreshToken provides a mock function with given fields: userId, tokenId, expirationTime
+func (_m *TokenHandler) NewRefreshToken(userId string, tokenId string, expirationTime time.Time) (string, error) {
+	ret := _m.Called(userId, tokenId, expirationTime)
 
 	var r0 string
-	if rf, ok := ret.Get(0).(func(string, time.Time) string); ok {
-		r0 = rf(userId, expirationTime)
+	if rf, ok := ret.Get(0).(func(string, string, time.Time) string); ok {
+		r0 = rf(userId, tokenId, expirationTime)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, time.Time) error); ok {
-		r1 = rf(userId, expirationTime)
+	if rf, ok := ret.Get(1).(func(string, string, time.Time) error); ok {
+		r1 = rf(userId, tokenId, expirationTime)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -36,13 +36,34 @@ func (_m *TokenHandler) NewToken(userId string, expirationTime time.Time) (strin
 	return r0, r1
 }
 
-// ValidateToken provides a mock function with given fields: token
-func (_m *TokenHandler) ValidateToken(token string) (*tokenhandler.Claims, error) {
-	ret := _m.Called(token)
+// NewToken provides a mock function with given fields: userId, sessionId, generation, expirationTime
+func (_m *TokenHandler) NewToken(userId string, sessionId string, generation int64, expirationTime time.Time) (string, error) {
+	ret := _m.Called(userId, sessionId, generation, expirationTime)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string, string, int64, time.Time) string); ok {
+		r0 = rf(userId, sessionId, generation, expirationTime)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, int64, time.Time) error); ok {
+		r1 = rf(userId, sessionId, generation, expirationTime)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ValidateToken provides a mock function with given fields: token, use
+func (_m *TokenHandler) ValidateToken(token string, use string) (*tokenhandler.Claims, error) {
+	ret := _m.Called(token, use)
 
 	var r0 *tokenhandler.Claims
-	if rf, ok := ret.Get(0).(func(string) *tokenhandler.Claims); ok {
-		r0 = rf(token)
+	if rf, ok := ret.Get(0).(func(string, string) *tokenhandler.Claims); ok {
+		r0 = rf(token, use)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*tokenhandler.Claims)
@@ -50,8 +71,8 @@ func (_m *TokenHandler) ValidateToken(token string) (*tokenhandler.Claims, error
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(token)
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(token, use)
 	} else {
 		r1 = ret.Error(1)
 	}
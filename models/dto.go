@@ -1,6 +1,112 @@
 package models
 
+import "time"
+
 type SessionInfo struct {
 	Title       *string `json:"title"`
 	Description *string `json:"description"`
+	ProjectID   *string `json:"projectId"`
+	Billable    *bool   `json:"billable"`
+	// Tags replaces the tags of the session when not nil
+	Tags []string `json:"tags"`
+}
+
+// SessionFilter defines the criteria used to list sessions
+type SessionFilter struct {
+	// Period matches sessions overlapping the current day, week or month like a From and To range
+	Period    string `json:"period"`
+	ProjectID string `json:"projectId"`
+	// AnyTags matches sessions with at least one of the tags
+	AnyTags []string `json:"anyTags"`
+	// AllTags matches sessions with every one of the tags
+	AllTags []string `json:"allTags"`
+	// From and To match sessions overlapping the [From, To) range, zero leaves that side open
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+	// Location and WeekStart are the user settings used to work out period boundaries
+	Location  *time.Location `json:"-"`
+	WeekStart time.Weekday   `json:"-"`
+}
+
+type ProjectInfo struct {
+	ClientID      *string       `json:"clientId"`
+	ClientHistory *[]ClientLink `json:"clientHistory"`
+	Name          *string       `json:"name"`
+	Description   *string       `json:"description"`
+	Archived      *bool         `json:"archived"`
+}
+
+type ClientInfo struct {
+	Name     *string `json:"name"`
+	Archived *bool   `json:"archived"`
+}
+
+type UserInfo struct {
+	Name      *string `json:"name"`
+	TimeZone  *string `json:"timeZone"`
+	WeekStart *int    `json:"weekStart"`
+	// CalendarToken is stored hashed, an empty token disables the calendar feed
+	CalendarToken *string `json:"calendarToken"`
+	OverlapPolicy *string `json:"overlapPolicy"`
+	// Password is stored hashed
+	Password      *string `json:"password"`
+	EmailVerified *bool   `json:"emailVerified"`
+}
+
+// LoginSessionInfo is what a device reports when it uses the tokens of a login session
+type LoginSessionInfo struct {
+	UserAgent  *string `json:"userAgent"`
+	IP         *string `json:"ip"`
+	LastUsedAt *int64  `json:"lastUsedAt"`
+}
+
+// Cursor is the position of a session in the most recent first order of sessions
+type Cursor struct {
+	Ts int64  `json:"ts"`
+	ID string `json:"id"`
+}
+
+// Page selects a window of sessions, First and After page forward
+// while Last and Before page backward
+type Page struct {
+	First  int     `json:"first"`
+	After  *Cursor `json:"after"`
+	Last   int     `json:"last"`
+	Before *Cursor `json:"before"`
+}
+
+// SessionPage is a window of sessions along with the information needed to page further
+type SessionPage struct {
+	Sessions        []*Session `json:"sessions"`
+	TotalCount      int64      `json:"totalCount"`
+	HasNextPage     bool       `json:"hasNextPage"`
+	HasPreviousPage bool       `json:"hasPreviousPage"`
+}
+
+const (
+	ReportGroupDay     = "day"
+	ReportGroupWeek    = "week"
+	ReportGroupMonth   = "month"
+	ReportGroupProject = "project"
+	ReportGroupTag     = "tag"
+	ReportGroupClient  = "client"
+)
+
+// ReportQuery defines a report over the sessions that start in the [From, To) range
+type ReportQuery struct {
+	From    int64    `json:"from"`
+	To      int64    `json:"to"`
+	GroupBy []string `json:"groupBy"`
+	// Location and WeekStart are the user settings used to bucket sessions by day, week and month
+	Location  *time.Location `json:"-"`
+	WeekStart time.Weekday   `json:"-"`
+}
+
+// ReportRow holds the totals of one bucket of a report. Keys has a value for each of the
+// first len(Keys) groups of the query, the row without keys holds the totals of the report
+type ReportRow struct {
+	Keys         []string `json:"keys"`
+	Duration     int64    `json:"duration"`
+	SessionCount int64    `json:"sessionCount"`
+	Amount       float64  `json:"amount"`
 }
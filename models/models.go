@@ -1,14 +1,127 @@
 package models
 
+import "time"
+
 type Session struct {
+	ID          string    `json:"id"`
+	Owner       string    `json:"owner"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	ProjectID   string    `json:"projectId"`
+	Tags        []string  `json:"tags"`
+	Billable    bool      `json:"billable"`
+	Start       int64     `json:"start"`
+	End         int64     `json:"end"`
+	Duration    int64     `json:"duration"`
+	Segments    []Segment `json:"segments"`
+	Running     bool      `json:"running"`
+	Paused      bool      `json:"paused"`
+	ImportHash  string    `json:"importHash,omitempty"`
+	// Draft sessions come from calendar uploads and stay hidden until the user confirms them
+	Draft bool  `json:"draft"`
+	Ts    int64 `json:"Ts"`
+}
+
+// Segment is an interval of a session during which time was being tracked,
+// End is zero while the segment is still open
+type Segment struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// Intervals returns the active segments of the session,
+// sessions saved without segments are treated as a single start/end segment
+func (s *Session) Intervals() []Segment {
+	if len(s.Segments) > 0 {
+		return s.Segments
+	}
+	if s.Running {
+		return []Segment{{Start: s.Start}}
+	}
+	return []Segment{{Start: s.Start, End: s.End}}
+}
+
+// TotalDuration returns the sum of the closed segments of the session
+func (s *Session) TotalDuration() int64 {
+	var total int64
+	for _, segment := range s.Intervals() {
+		if segment.End > segment.Start {
+			total += segment.End - segment.Start
+		}
+	}
+	return total
+}
+
+// TagCount is a tag together with the number of sessions using it
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+type Project struct {
 	ID          string `json:"id"`
 	Owner       string `json:"owner"`
-	Title       string `json:"title"`
+	ClientID    string `json:"clientId"`
+	Name        string `json:"name"`
 	Description string `json:"description"`
-	Start       int64  `json:"start"`
-	End         int64  `json:"end"`
-	Duration    int64  `json:"duration"`
+	Archived    bool   `json:"archived"`
 	Ts          int64  `json:"Ts"`
+	// ClientHistory holds the clients the project had before the current one, oldest first
+	ClientHistory []ClientLink `json:"clientHistory"`
+}
+
+// ClientLink is a client a project belonged to until the unix time Until,
+// an empty ClientID means the project had no client
+type ClientLink struct {
+	ClientID string `json:"clientId"`
+	Until    int64  `json:"until"`
+}
+
+type Client struct {
+	ID       string `json:"id"`
+	Owner    string `json:"owner"`
+	Name     string `json:"name"`
+	Archived bool   `json:"archived"`
+	Ts       int64  `json:"Ts"`
+}
+
+const (
+	RateScopeUser    = "user"
+	RateScopeClient  = "client"
+	RateScopeProject = "project"
+)
+
+// Policies for a new session that overlaps sessions the user already saved
+const (
+	// OverlapReject refuses to save the session
+	OverlapReject = "reject"
+	// OverlapWarn saves the session and reports the sessions it overlaps
+	OverlapWarn = "warn"
+	// OverlapTrim removes the overlapping time from the new session before saving it
+	OverlapTrim = "trim"
+)
+
+// Overlap is a pair of saved sessions that tracked the same time, First starts before Second.
+// Start and End span the shared time and Duration is the total of it, breaks excluded
+type Overlap struct {
+	First    *Session `json:"first"`
+	Second   *Session `json:"second"`
+	Start    int64    `json:"start"`
+	End      int64    `json:"end"`
+	Duration int64    `json:"duration"`
+}
+
+// Rate is an hourly rate for a user, client or project that applies from EffectiveFrom
+// until a newer rate for the same scope takes over. Rates are never updated,
+// so the rate in effect at any point in time can always be worked out
+type Rate struct {
+	ID            string  `json:"id"`
+	Owner         string  `json:"owner"`
+	Scope         string  `json:"scope"`
+	ScopeID       string  `json:"scopeId"`
+	HourlyRate    float64 `json:"hourlyRate"`
+	EffectiveFrom int64   `json:"effectiveFrom"`
+	Ts            int64   `json:"Ts"`
 }
 
 type User struct {
@@ -16,5 +129,70 @@ type User struct {
 	Name     string `json:"name"`
 	Email    string `json:"email"`
 	Password string `json:"password"`
-	Ts       int64  `json:"Ts"`
+	// TimeZone is the IANA time zone used for day, week and month boundaries, UTC when empty
+	TimeZone string `json:"timeZone"`
+	// WeekStart is the first day of the week, sunday is 0
+	WeekStart int `json:"weekStart"`
+	// CalendarToken is the sha256 hash of the secret token of the user's calendar feed
+	CalendarToken string `json:"calendarToken"`
+	// OverlapPolicy decides what happens to a new session overlapping saved ones, warn when empty
+	OverlapPolicy string `json:"overlapPolicy"`
+	// TokenGeneration is carried by access tokens, incrementing it revokes every access token of the user
+	TokenGeneration int64 `json:"tokenGeneration"`
+	// EmailVerified is set once the user opened the verification link mailed to them
+	EmailVerified bool  `json:"emailVerified"`
+	Ts            int64 `json:"Ts"`
+}
+
+// Location returns the time zone of the user, falling back to UTC for unknown zones
+func (u *User) Location() *time.Location {
+	loc, err := time.LoadLocation(u.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// RefreshToken is the record of a refresh token, the signed token carries its ID.
+// Every rotation replaces the token with a new one of the same Family, the tokens
+// issued from one login, so a token used twice reveals that the family leaked
+type RefreshToken struct {
+	ID        string `json:"id"`
+	Family    string `json:"family"`
+	Owner     string `json:"owner"`
+	ExpiresAt int64  `json:"expiresAt"`
+	// Used is set once the token was exchanged for a new one
+	Used bool `json:"used"`
+	// Revoked is set on every token of a family that can no longer be refreshed
+	Revoked bool  `json:"revoked"`
+	Ts      int64 `json:"Ts"`
+}
+
+// purposes of one time tokens
+const (
+	PurposePasswordReset     = "password_reset"
+	PurposeEmailVerification = "email_verification"
+)
+
+// OneTimeToken is the record of a token mailed to a user to prove they own their email, its ID
+// is the hash of the token so a leaked database can not be used to take over accounts
+type OneTimeToken struct {
+	ID        string `json:"id"`
+	Owner     string `json:"owner"`
+	Purpose   string `json:"purpose"`
+	ExpiresAt int64  `json:"expiresAt"`
+	// Used is set once the token was redeemed, a token can only be used once
+	Used bool  `json:"used"`
+	Ts   int64 `json:"Ts"`
+}
+
+// LoginSession is a signed in device, it lives from a login until the device is signed out.
+// Its ID is the family of the refresh tokens issued to the device
+type LoginSession struct {
+	ID         string `json:"id"`
+	Owner      string `json:"owner"`
+	UserAgent  string `json:"userAgent"`
+	IP         string `json:"ip"`
+	CreatedAt  int64  `json:"createdAt"`
+	LastUsedAt int64  `json:"lastUsedAt"`
 }
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/lib/ical"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"github.com/victor-nach/time-tracker/lib/securetoken"
+	"github.com/victor-nach/time-tracker/models"
+	"go.uber.org/zap"
+)
+
+// calendarHandler serves the sessions of a user as an icalendar feed, the secret token in the url
+// authenticates the feed because calendar apps can not send an Authorization header
+type calendarHandler struct {
+	store  db.Datastore
+	logger *zap.Logger
+}
+
+func (h calendarHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	token := chi.URLParam(r, "token")
+	user, err := h.store.GetUserByCalendarToken(ctx, securetoken.Hash(token))
+	if token == "" || err != nil {
+		err = rerrors.Format(rerrors.InvalidAuthErr, errors.New("invalid calendar token"))
+		h.logger.Error("calendar", zap.Error(err))
+		writeErr(w, http.StatusNotFound, err)
+		return
+	}
+
+	params := r.URL.Query()
+	filter := models.SessionFilter{ProjectID: params.Get("projectId"), AnyTags: params["tag"]}
+
+	projects, err := h.store.GetProjects(ctx, user.ID, true)
+	if err != nil {
+		err = rerrors.Format(rerrors.DatabaseErr, err)
+		h.logger.Error("calendar", zap.Error(err))
+		writeErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	projectNames := map[string]string{}
+	for _, p := range projects {
+		projectNames[p.ID] = p.Name
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	cal := ical.NewWriter(w, "Time tracker - "+user.Name)
+	// the response has started, errors can only be logged from here on
+	err = h.store.StreamSessions(ctx, user.ID, filter, func(session *models.Session) error {
+		return cal.Write(sessionEvent(session, projectNames[session.ProjectID]))
+	})
+	if err != nil {
+		h.logger.Error("calendar", zap.Error(err))
+		return
+	}
+	if err := cal.Close(); err != nil {
+		h.logger.Error("calendar", zap.Error(err))
+	}
+}
+
+// sessionEvent converts a session to a calendar event, the project and duration are added to the description
+func sessionEvent(session *models.Session, project string) ical.Event {
+	var description []string
+	if session.Description != "" {
+		description = append(description, session.Description, "")
+	}
+	if project != "" {
+		description = append(description, "Project: "+project)
+	}
+	description = append(description, fmt.Sprintf("Duration: %s", time.Duration(session.Duration)*time.Second))
+
+	title := session.Title
+	if title == "" {
+		title = "Session"
+	}
+	return ical.Event{
+		UID:         session.ID + "@time-tracker",
+		Summary:     title,
+		Description: strings.Join(description, "\n"),
+		Categories:  session.Tags,
+		Start:       session.Start,
+		End:         session.End,
+	}
+}
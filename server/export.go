@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/lib/billing"
+	"github.com/victor-nach/time-tracker/lib/export"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"github.com/victor-nach/time-tracker/lib/tokenhandler"
+	"github.com/victor-nach/time-tracker/models"
+	"github.com/victor-nach/time-tracker/server/middlewares"
+	"go.uber.org/zap"
+)
+
+// exportHandler streams the sessions of the authenticated user as csv, ndjson or xlsx
+type exportHandler struct {
+	store  db.Datastore
+	logger *zap.Logger
+}
+
+func (h exportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	claims, ok := ctx.Value(middlewares.AuthContextKey).(tokenhandler.Claims)
+	if !ok {
+		h.fail(w, http.StatusUnauthorized, rerrors.Format(rerrors.InvalidAuthErr, errors.New("no auth token")))
+		return
+	}
+
+	params := r.URL.Query()
+	format := params.Get("format")
+	if format == "" {
+		format = export.FormatCSV
+	}
+	if !export.ValidFormat(format) {
+		h.fail(w, http.StatusBadRequest, rerrors.Format(rerrors.InvalidRequestErr, export.ErrUnknownFormat))
+		return
+	}
+	columns, err := export.ParseColumns(params.Get("columns"))
+	if err != nil {
+		h.fail(w, http.StatusBadRequest, rerrors.Format(rerrors.InvalidRequestErr, err))
+		return
+	}
+
+	filter := models.SessionFilter{ProjectID: params.Get("projectId")}
+	for key, value := range map[string]*int64{"from": &filter.From, "to": &filter.To} {
+		if params.Get(key) == "" {
+			continue
+		}
+		if *value, err = strconv.ParseInt(params.Get(key), 10, 64); err != nil || *value < 0 {
+			h.fail(w, http.StatusBadRequest, rerrors.Format(rerrors.InvalidRequestErr, errors.New(key+" must be a unix timestamp")))
+			return
+		}
+	}
+	if filter.To != 0 && filter.From >= filter.To {
+		h.fail(w, http.StatusBadRequest, rerrors.Format(rerrors.InvalidRequestErr, errors.New("range from must be before to")))
+		return
+	}
+
+	lookup, err := h.lookup(ctx, claims.UserId)
+	if err != nil {
+		h.fail(w, http.StatusInternalServerError, rerrors.Format(rerrors.DatabaseErr, err))
+		return
+	}
+
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", `attachment; filename="sessions.`+format+`"`)
+	enc, err := export.NewEncoder(format, w, columns)
+	if err != nil {
+		h.logger.Error("export", zap.Error(err))
+		return
+	}
+
+	// the response has started, errors can only be logged from here on
+	err = h.store.StreamSessions(ctx, claims.UserId, filter, func(session *models.Session) error {
+		return enc.Write(lookup.Row(columns, session))
+	})
+	if err != nil {
+		h.logger.Error("export", zap.Error(err))
+		return
+	}
+	if err := enc.Close(); err != nil {
+		h.logger.Error("export", zap.Error(err))
+	}
+}
+
+// lookup loads the projects, clients and rates needed to fill the columns of a user's sessions
+func (h exportHandler) lookup(ctx context.Context, owner string) (export.Lookup, error) {
+	user, err := h.store.GetUser(ctx, owner)
+	if err != nil {
+		return export.Lookup{}, err
+	}
+	projects, err := h.store.GetProjects(ctx, owner, true)
+	if err != nil {
+		return export.Lookup{}, err
+	}
+	clients, err := h.store.GetClients(ctx, owner, true)
+	if err != nil {
+		return export.Lookup{}, err
+	}
+	rates, err := h.store.GetRates(ctx, owner)
+	if err != nil {
+		return export.Lookup{}, err
+	}
+	return export.NewLookup(projects, clients, billing.NewCalculator(rates, projects), user.Location()), nil
+}
+
+func (h exportHandler) fail(w http.ResponseWriter, status int, err error) {
+	h.logger.Error("export", zap.Error(err))
+	writeErr(w, status, err)
+}
+
+// writeErr writes the json form of an internal error
+func writeErr(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(err.Error()))
+}
+
+// errStatus returns the http status matching an internal error
+func errStatus(err error) int {
+	e, ok := err.(*rerrors.Err)
+	if !ok {
+		return http.StatusInternalServerError
+	}
+	switch e.Code {
+	case rerrors.InvalidAuthErr:
+		return http.StatusUnauthorized
+	case rerrors.EmailNotVerifiedErr:
+		return http.StatusForbidden
+	case rerrors.DatabaseErr, rerrors.InternalErr:
+		return http.StatusInternalServerError
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/victor-nach/time-tracker/graph/generated"
+	"github.com/victor-nach/time-tracker/lib/rerrors"
+	"go.uber.org/zap"
+)
+
+// maxImportSize limits the size of an uploaded export
+const maxImportSize = 20 << 20
+
+// importHandler accepts a multipart upload of a csv export and imports it like the importSessions mutation
+type importHandler struct {
+	mutation generated.MutationResolver
+	// requireVerified refuses users that did not verify their email, nil when they are not restricted
+	requireVerified func(ctx context.Context) error
+	logger          *zap.Logger
+}
+
+func (h importHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		h.fail(w, http.StatusMethodNotAllowed, rerrors.Format(rerrors.InvalidRequestErr, errors.New("import expects a POST request")))
+		return
+	}
+
+	if h.requireVerified != nil {
+		if err := h.requireVerified(r.Context()); err != nil {
+			h.fail(w, errStatus(err), err)
+			return
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		h.fail(w, http.StatusBadRequest, rerrors.Format(rerrors.InvalidRequestErr, err))
+		return
+	}
+	defer file.Close()
+
+	dryRun, _ := strconv.ParseBool(r.FormValue("dryRun"))
+	result, err := h.mutation.ImportSessions(r.Context(), graphql.Upload{
+		File:        file,
+		Filename:    header.Filename,
+		Size:        header.Size,
+		ContentType: header.Header.Get("Content-Type"),
+	}, &dryRun)
+	if err != nil {
+		h.fail(w, errStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		h.logger.Error("import", zap.Error(err))
+	}
+}
+
+func (h importHandler) fail(w http.ResponseWriter, status int, err error) {
+	h.logger.Error("import", zap.Error(err))
+	writeErr(w, status, err)
+}
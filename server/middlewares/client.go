@@ -0,0 +1,28 @@
+package middlewares
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+var ClientContextKey = &ctxKey{Name: "ClientKey"}
+
+// Client is the device a request comes from
+type Client struct {
+	UserAgent string
+	IP        string
+}
+
+// HandleClient stores the user agent and ip address of the request in its context. The ip is the
+// remote address of the connection, behind a proxy the address should be set from its headers first
+func HandleClient(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+		ctx := context.WithValue(r.Context(), ClientContextKey, Client{UserAgent: r.UserAgent(), IP: ip})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
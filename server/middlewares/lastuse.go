@@ -0,0 +1,70 @@
+package middlewares
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/victor-nach/time-tracker/db"
+	"github.com/victor-nach/time-tracker/lib/clock"
+	"github.com/victor-nach/time-tracker/lib/tokenhandler"
+	"github.com/victor-nach/time-tracker/models"
+	"go.uber.org/zap"
+)
+
+// pruneLastUse is the number of remembered sessions above which stale ones are dropped
+const pruneLastUse = 10000
+
+// LastUse records when the login sessions of access tokens were last used. A session is written
+// at most once per interval, so requests do not each cost a database write
+type LastUse struct {
+	store    db.Datastore
+	interval time.Duration
+	clock    clock.Clock
+	logger   *zap.Logger
+
+	mu      sync.Mutex
+	written map[string]time.Time
+}
+
+// NewLastUse returns a LastUse writing the last use of a session at most once per interval
+func NewLastUse(store db.Datastore, interval time.Duration, logger *zap.Logger) *LastUse {
+	return &LastUse{
+		store:    store,
+		interval: interval,
+		clock:    clock.New(),
+		logger:   logger,
+		written:  map[string]time.Time{},
+	}
+}
+
+// Record is the AuthMiddleware hook, tokens issued before login sessions existed have no session
+func (l *LastUse) Record(ctx context.Context, claims *tokenhandler.Claims) {
+	if claims.SessionId == "" || !l.due(claims.SessionId) {
+		return
+	}
+	now := l.clock.Now().Unix()
+	if err := l.store.UpdateLoginSession(ctx, claims.SessionId, models.LoginSessionInfo{LastUsedAt: &now}); err != nil {
+		l.logger.Error("record last use", zap.Error(err))
+	}
+}
+
+// due reports whether the last use of the session should be written and marks it written
+func (l *LastUse) due(sessionId string) bool {
+	now := l.clock.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if written, ok := l.written[sessionId]; ok && now.Sub(written) < l.interval {
+		return false
+	}
+	// sessions not used for an interval are forgotten so the map does not grow forever
+	if len(l.written) >= pruneLastUse {
+		for id, written := range l.written {
+			if now.Sub(written) >= l.interval {
+				delete(l.written, id)
+			}
+		}
+	}
+	l.written[sessionId] = now
+	return true
+}
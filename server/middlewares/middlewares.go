@@ -2,6 +2,7 @@ package middlewares
 
 import (
 	"context"
+	"github.com/victor-nach/time-tracker/db"
 	"github.com/victor-nach/time-tracker/lib/tokenhandler"
 	"net/http"
 	"strings"
@@ -17,16 +18,26 @@ var AuthContextKey = &ctxKey{Name: "AuthKey "}
 
 type AuthMiddleware struct {
 	tokenHandler tokenhandler.TokenHandler
+	store        db.Datastore
+	onUse        func(ctx context.Context, claims *tokenhandler.Claims)
 	logger       *zap.Logger
 }
 
-func NewAuthMiddleware(tokenHandler tokenhandler.TokenHandler, logger *zap.Logger) *AuthMiddleware {
+// NewAuthMiddleware returns the middleware authenticating requests, the store should cache
+// token generations and login sessions as they are read on every authenticated request
+func NewAuthMiddleware(tokenHandler tokenhandler.TokenHandler, store db.Datastore, logger *zap.Logger) *AuthMiddleware {
 	return &AuthMiddleware{
 		tokenHandler: tokenHandler,
+		store:        store,
 		logger:       logger,
 	}
 }
 
+// OnUse sets a hook called with the claims of every authenticated request, set it before HandleAuth is used
+func (A *AuthMiddleware) OnUse(hook func(ctx context.Context, claims *tokenhandler.Claims)) {
+	A.onUse = hook
+}
+
 func (A AuthMiddleware) HandleAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authorization := r.Header.Get("Authorization")
@@ -46,15 +57,40 @@ func (A AuthMiddleware) HandleAuth(next http.Handler) http.Handler {
 			return
 		}
 
-		claims, err := A.tokenHandler.ValidateToken(jwtToken)
+		claims, err := A.tokenHandler.ValidateToken(jwtToken, tokenhandler.AccessToken)
 		if err != nil {
 			A.logger.Error("failed to validate token", zap.Error(err))
 			next.ServeHTTP(w, r)
 			return
 		}
 
+		// signing out every device increments the generation, which revokes the access tokens issued before
+		generation, err := A.store.GetTokenGeneration(r.Context(), claims.UserId)
+		if err != nil || generation != claims.Generation {
+			A.logger.Error("revoked token", zap.String("userId", claims.UserId), zap.Error(err))
+			next.ServeHTTP(w, r)
+			return
+		}
+		// signing out one device deletes its login session. Tokens without one can not be revoked
+		// by revokeDevice, so they are refused and the device has to log in again
+		if claims.SessionId == "" {
+			A.logger.Error("token without login session", zap.String("userId", claims.UserId))
+			next.ServeHTTP(w, r)
+			return
+		}
+		if _, err := A.store.GetLoginSession(r.Context(), claims.SessionId, claims.UserId); err != nil {
+			A.logger.Error("revoked token", zap.String("userId", claims.UserId), zap.Error(err))
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if A.onUse != nil {
+			A.onUse(r.Context(), claims)
+		}
+
 		ctx := context.WithValue(r.Context(), AuthContextKey, tokenhandler.Claims{
-			UserId: claims.UserId,
+			UserId:    claims.UserId,
+			SessionId: claims.SessionId,
 		})
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -0,0 +1,77 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-nach/time-tracker/db/memory"
+	"github.com/victor-nach/time-tracker/lib/tokenhandler"
+	"github.com/victor-nach/time-tracker/models"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestAuthMiddleware_HandleAuth(t *testing.T) {
+	const (
+		success = iota
+		revokedSessionError
+		missingSessionError
+	)
+
+	var tests = []struct {
+		name     string
+		testType int
+	}{
+		{
+			name:     "Successfully authenticate a signed in device",
+			testType: success,
+		},
+		{
+			name:     "Test token of a revoked device",
+			testType: revokedSessionError,
+		},
+		{
+			name:     "Test token without login session",
+			testType: missingSessionError,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := context.Background()
+			store, tokenHandler := memory.New(), tokenhandler.New("secret")
+			_, err := store.CreateUser(ctx, &models.User{ID: "userId", Email: "ada@email.com"})
+			assert.NoError(t, err)
+			assert.NoError(t, store.CreateLoginSession(ctx, &models.LoginSession{ID: "sessionId", Owner: "userId"}))
+
+			sessionId := "sessionId"
+			switch testCase.testType {
+			case revokedSessionError:
+				assert.NoError(t, store.DeleteLoginSession(ctx, "sessionId"))
+			case missingSessionError:
+				sessionId = ""
+			}
+			token, err := tokenHandler.NewToken("userId", sessionId, 0, time.Now().Add(time.Minute))
+			assert.NoError(t, err)
+
+			var claims tokenhandler.Claims
+			authenticated := false
+			handler := NewAuthMiddleware(tokenHandler, store, zaptest.NewLogger(t)).
+				HandleAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					claims, authenticated = r.Context().Value(AuthContextKey).(tokenhandler.Claims)
+				}))
+			req := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, testCase.testType == success, authenticated)
+			if authenticated {
+				assert.Equal(t, "userId", claims.UserId)
+				assert.Equal(t, "sessionId", claims.SessionId)
+			}
+		})
+	}
+}
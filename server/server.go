@@ -13,6 +13,7 @@ import (
 	"github.com/victor-nach/time-tracker/db"
 	"github.com/victor-nach/time-tracker/graph"
 	"github.com/victor-nach/time-tracker/graph/generated"
+	"github.com/victor-nach/time-tracker/lib/mailer"
 	"github.com/victor-nach/time-tracker/lib/rerrors"
 	"github.com/victor-nach/time-tracker/lib/tokenhandler"
 	"github.com/victor-nach/time-tracker/server/middlewares"
@@ -20,27 +21,48 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"time"
 )
 
 //Server ...
 type Server struct {
-	server *handler.Server
-	router *chi.Mux
+	server   *handler.Server
+	export   http.Handler
+	importer http.Handler
+	calendar http.Handler
+	router   *chi.Mux
+	http     *http.Server
+	// resolvers is kept to wait for the mails they send in the background on shutdown
+	resolvers *graph.Resolver
 }
 
 //NewServer returns a new server
-func NewServer(dataStore db.Datastore, cfg *config.Secrets, logger *zap.Logger) *Server {
+func NewServer(dataStore db.Datastore, mail mailer.Mailer, cfg *config.Secrets, logger *zap.Logger) *Server {
 	tokenHandler := tokenhandler.New(cfg.JWTSecret)
-	resolvers := graph.NewResolver(dataStore, tokenHandler, logger)
+	resolvers := graph.NewResolver(dataStore, tokenHandler, logger).WithMailer(mail, cfg.AppURL)
 
 	srv := handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{Resolvers: resolvers}))
 
 	// set default error presenter
 	srv.SetErrorPresenter(gqlErrorParser)
 
+	// the import endpoint writes sessions like a mutation, so it gets the same restriction
+	importer := importHandler{mutation: resolvers.Mutation(), logger: logger}
+	if cfg.UnverifiedAccess == config.AccessReadOnly {
+		srv.AroundFields(resolvers.ReadOnlyUnverified)
+		importer.requireVerified = resolvers.RequireVerifiedEmail
+	}
+
 	router := chi.NewRouter()
 
-	authMw := middlewares.NewAuthMiddleware(tokenHandler, logger)
+	// the client is read by the resolvers to record the devices users sign in from
+	if cfg.TrustProxy {
+		router.Use(middleware.RealIP)
+	}
+	router.Use(middlewares.HandleClient)
+
+	authMw := middlewares.NewAuthMiddleware(tokenHandler, dataStore, logger)
+	authMw.OnUse(middlewares.NewLastUse(dataStore, time.Minute, logger).Record)
 	router.Use(authMw.HandleAuth)
 
 	router.Use(cors.New(cors.Options{
@@ -53,7 +75,15 @@ func NewServer(dataStore db.Datastore, cfg *config.Secrets, logger *zap.Logger)
 	router.Use(middleware.Recoverer)
 	router.Use(middleware.RequestID)
 
-	return &Server{server: srv, router: router}
+	return &Server{
+		server:    srv,
+		export:    exportHandler{store: dataStore, logger: logger},
+		importer:  importer,
+		calendar:  calendarHandler{store: dataStore, logger: logger},
+		router:    router,
+		http:      &http.Server{Handler: router},
+		resolvers: resolvers,
+	}
 }
 
 //Run starts the server on a specified address
@@ -61,7 +91,19 @@ func (s *Server) Run(address string) error {
 	log.Printf("connect to http://localhost%s/ for GraphQL playground", address)
 	s.router.Handle("/", playground.Handler("GraphQL playground", "/graphql"))
 	s.router.Handle("/graphql", s.server)
-	return http.ListenAndServe(address, s.router)
+	s.router.Handle("/export", s.export)
+	s.router.Handle("/import", s.importer)
+	s.router.Handle("/calendar/{token}.ics", s.calendar)
+	s.http.Addr = address
+	return s.http.ListenAndServe()
+}
+
+//Shutdown stops accepting requests, then waits for the requests in flight and the mails sent in the background
+func (s *Server) Shutdown(ctx context.Context) error {
+	if err := s.http.Shutdown(ctx); err != nil {
+		return err
+	}
+	return s.resolvers.WaitMails(ctx)
 }
 
 //gqlErrorParser parses internal error type to graphql error type
@@ -84,6 +126,9 @@ func gqlErrorParser(ctx context.Context, e error) *gqlerror.Error {
 			"code":      r.Code,
 			"errorType": r.ErrorType,
 		}
+		if len(r.Fields) > 0 {
+			err.Extensions["fields"] = r.Fields
+		}
 	}
 	return err
 }